@@ -1,10 +1,9 @@
 package domain
 
 import (
-	"crypto/rsa"
-	"io/ioutil"
-	"log"
+	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/dgrijalva/jwt-go/request"
@@ -19,6 +18,12 @@ const (
 	FailedDatastoreInitialize AuthErrorCode = 4
 	FailedGettingUser         AuthErrorCode = 5
 	UserNotFound              AuthErrorCode = 6
+	TokenExpired              AuthErrorCode = 7
+	TokenNotValidYet          AuthErrorCode = 8
+	InvalidTokenIssuer        AuthErrorCode = 9
+	InvalidTokenAudience      AuthErrorCode = 10
+	InvalidTokenClaims        AuthErrorCode = 11
+	SigningKeyNotFound        AuthErrorCode = 12
 )
 
 func (e AuthErrorCode) Error() string {
@@ -35,67 +40,147 @@ func (e AuthErrorCode) Error() string {
 		return "failed getting user"
 	case UserNotFound:
 		return "user not found"
+	case TokenExpired:
+		return "token is expired"
+	case TokenNotValidYet:
+		return "token is not valid yet"
+	case InvalidTokenIssuer:
+		return "invalid token issuer"
+	case InvalidTokenAudience:
+		return "invalid token audience"
+	case InvalidTokenClaims:
+		return "invalid token claims"
+	case SigningKeyNotFound:
+		return "signing key not found"
 	default:
 		return "unknown token error"
 	}
 }
 
-var publicKey = loadPublicKey()
+// TokenClaims is the claims in JWT issued by the front-end.
+type TokenClaims struct {
+	Provider  string        `json:"provider"`
+	ID        string        `json:"id"`
+	Issuer    string        `json:"iss"`
+	Audience  TokenAudience `json:"aud"`
+	ExpiresAt int64         `json:"exp"`
+	NotBefore int64         `json:"nbf"`
+	IssuedAt  int64         `json:"iat"`
+}
 
-func loadPublicKey() *rsa.PublicKey {
-	keyData, err := ioutil.ReadFile("./public.pem")
-	if err != nil {
-		log.Printf("%v", err)
-		panic("failed to read pem file.")
+// Valid is called by the parser, the claims are validated by TokenValidator instead for the clock skew.
+func (c *TokenClaims) Valid() error {
+	return nil
+}
+
+// ProviderID returns user account provider and subject.
+func (c TokenClaims) ProviderID() string {
+	return c.Provider + "_" + c.ID
+}
+
+// TokenAudience is "aud" claim, which is a string or an array of strings.
+type TokenAudience []string
+
+func (a *TokenAudience) UnmarshalJSON(data []byte) error {
+	var audience string
+	if err := json.Unmarshal(data, &audience); err == nil {
+		*a = TokenAudience{audience}
+		return nil
 	}
 
-	key, err := jwt.ParseRSAPublicKeyFromPEM(keyData)
-	if err != nil {
-		log.Printf("%v", err)
-		panic("failed to parse pem file.")
+	var audiences []string
+	if err := json.Unmarshal(data, &audiences); err != nil {
+		return err
 	}
+	*a = audiences
+
+	return nil
+}
+
+// TokenValidator verifies the signature and the claims of JWT.
+type TokenValidator struct {
+	keys      KeySource
+	issuer    string
+	audiences []string
+	clockSkew time.Duration
+	now       func() time.Time
+}
+
+// NewTokenValidator returns the validator accepts tokens issued by issuer for one of audiences.
+// clockSkew is the tolerance of "exp" and "nbf" for the difference of clocks between servers.
+func NewTokenValidator(keys KeySource, issuer string, audiences []string, clockSkew time.Duration) *TokenValidator {
+	return &TokenValidator{keys: keys, issuer: issuer, audiences: audiences, clockSkew: clockSkew, now: time.Now}
+}
 
-	return key
+var tokenValidator *TokenValidator
+
+// SetTokenValidator replaces the validator of JWT. this must be called before handling requests.
+func SetTokenValidator(validator *TokenValidator) {
+	tokenValidator = validator
 }
 
 // ValidTokenClaims returns claims in JWT.
-func ValidTokenClaims(r *http.Request) (map[string]interface{}, error) {
+func ValidTokenClaims(r *http.Request) (TokenClaims, error) {
+	var claims TokenClaims
+
 	rawHeader := r.Header.Get("Authorization")
 	if rawHeader == "" {
-		return nil, TokenNotFound
+		return claims, TokenNotFound
 	}
 
-	token, err := request.ParseFromRequest(r, request.AuthorizationHeaderExtractor, func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodRSA)
-		if !ok {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	_, err := request.ParseFromRequest(r, request.AuthorizationHeaderExtractor, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			return nil, UnexpectedSigningMethod
 		}
-		return publicKey, nil
-	})
-	if err != nil {
-		return nil, err
-	}
 
-	if !token.Valid {
-		return nil, InvalidToken
+		kid, _ := token.Header["kid"].(string)
+		return tokenValidator.keys.Key(r.Context(), kid)
+	}, request.WithClaims(&claims), request.WithParser(parser))
+	if err != nil {
+		if validationErr, ok := err.(*jwt.ValidationError); ok {
+			if authErr, ok := validationErr.Inner.(AuthErrorCode); ok {
+				return claims, authErr
+			}
+			if _, ok := validationErr.Inner.(*json.UnmarshalTypeError); ok {
+				return claims, InvalidTokenClaims // e.g. "provider" is not a string.
+			}
+		}
+		return claims, InvalidToken
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, InvalidToken
+	if err := tokenValidator.validateClaims(claims); err != nil {
+		return claims, err
 	}
 
 	return claims, nil
 }
 
-// ProviderID is return user account provider and subject in JWT.
-func ProviderID(r *http.Request) (string, error) {
-	claims, err := ValidTokenClaims(r)
+// validateClaims requires all of "exp", "nbf", "iss" and "aud", the times are compared with the clock skew.
+func (v *TokenValidator) validateClaims(claims TokenClaims) error {
+	if claims.Provider == "" || claims.ID == "" || claims.ExpiresAt == 0 || claims.NotBefore == 0 {
+		return InvalidTokenClaims
+	}
+
+	now := v.now()
+	if now.Add(-v.clockSkew).Unix() >= claims.ExpiresAt {
+		return TokenExpired
+	}
+	if now.Add(v.clockSkew).Unix() < claims.NotBefore {
+		return TokenNotValidYet
+	}
 
-	if err != nil {
-		return "", err
+	if claims.Issuer != v.issuer {
+		return InvalidTokenIssuer
+	}
+
+	for _, audience := range claims.Audience {
+		for _, expected := range v.audiences {
+			if audience == expected {
+				return nil
+			}
+		}
 	}
 
-	providerID := claims["provider"].(string) + "_" + claims["id"].(string)
-	return providerID, nil
+	return InvalidTokenAudience
 }
@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/sync/singleflight"
+)
+
+// minJWKSRefreshInterval limits fetching JWKS, because anyone can send tokens with unknown kid.
+const minJWKSRefreshInterval = time.Minute
+
+// KeySource provides the public key verifying the signature of JWT.
+type KeySource interface {
+	// Key returns the key identified by kid, kid is empty when the token has no "kid" header.
+	// SigningKeyNotFound is returned when the key is not found.
+	Key(ctx context.Context, kid string) (*rsa.PublicKey, error)
+}
+
+// pemKeySource is the single key read from the PEM file, it is used regardless of kid.
+type pemKeySource struct {
+	key *rsa.PublicKey
+}
+
+// NewPEMKeySource reads the public key from the PEM file.
+func NewPEMKeySource(path string) (KeySource, error) {
+	keyData, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := jwt.ParseRSAPublicKeyFromPEM(keyData)
+	if err != nil {
+		return nil, err
+	}
+
+	return pemKeySource{key: key}, nil
+}
+
+func (s pemKeySource) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	return s.key, nil
+}
+
+// jwksKeySource caches the keys of JWKS document, and fetches it again when kid is not in the cache.
+// so the keys can be rotated without restarting. the document is fetched without holding the lock,
+// and the requests missing the cache at the same time share one fetch.
+type jwksKeySource struct {
+	url       string
+	client    *http.Client
+	fetches   singleflight.Group
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWKSKeySource returns the KeySource of JWKS document at url, the document is fetched on the first use.
+func NewJWKSKeySource(url string, client *http.Client) KeySource {
+	return &jwksKeySource{url: url, client: client, keys: make(map[string]*rsa.PublicKey)}
+}
+
+func (s *jwksKeySource) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if key, ok := s.findKey(kid); ok {
+		return key, nil
+	}
+
+	// the fetch is shared by the waiting requests, so it isn't canceled with the context of one of them.
+	result := s.fetches.DoChan("", func() (interface{}, error) {
+		return nil, s.refresh(context.Background())
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-result:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+	}
+
+	if key, ok := s.findKey(kid); ok {
+		return key, nil
+	}
+
+	return nil, SigningKeyNotFound
+}
+
+// refresh replaces the cached keys, unless they were fetched within minJWKSRefreshInterval.
+func (s *jwksKeySource) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	if time.Since(s.fetchedAt) < minJWKSRefreshInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.fetchedAt = time.Now() // the failed fetch is also limited.
+	s.mu.Unlock()
+
+	keys, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+
+	return nil
+}
+
+// findKey returns the key of kid. the token without kid is accepted only when JWKS has the single key.
+func (s *jwksKeySource) findKey(kid string) (*rsa.PublicKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (s *jwksKeySource) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: %s", resp.Status)
+	}
+
+	var keySet jsonWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&keySet); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range keySet.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		key, err := jwk.rsaPublicKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus of key %q: %v", k.Kid, err)
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, fmt.Errorf("invalid exponent of key %q", k.Kid)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
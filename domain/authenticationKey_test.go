@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestJWKSKeySourceFetchesOnce(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{{
+			Kty: "RSA",
+			Kid: "key1",
+			N:   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	source := NewJWKSKeySource(server.URL, server.Client())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, err := source.Key(context.Background(), "key1")
+			if err == nil && key.N.Cmp(privateKey.N) != 0 {
+				t.Error("got the wrong key")
+			}
+			errs <- err
+		}()
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("fetched %d times, want 1", n)
+	}
+
+	// the unknown kid doesn't fetch again within the interval.
+	if _, err := source.Key(context.Background(), "unknown"); err != SigningKeyNotFound {
+		t.Errorf("got %v, want SigningKeyNotFound", err)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("fetched %d times for the unknown kid, want 1", n)
+	}
+}
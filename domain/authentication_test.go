@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateClaims(t *testing.T) {
+	now := time.Date(2021, 4, 10, 12, 0, 0, 0, time.UTC)
+	validator := NewTokenValidator(nil, "https://issuer.example", []string{"aud1", "aud2"}, time.Minute)
+	validator.now = func() time.Time { return now }
+
+	valid := TokenClaims{
+		Provider:  "google",
+		ID:        "1",
+		Issuer:    "https://issuer.example",
+		Audience:  TokenAudience{"other", "aud2"},
+		ExpiresAt: now.Add(time.Hour).Unix(),
+		NotBefore: now.Add(-time.Hour).Unix(),
+	}
+
+	tests := []struct {
+		name   string
+		modify func(claims *TokenClaims)
+		err    error
+	}{
+		{"valid", func(claims *TokenClaims) {}, nil},
+		{"no provider", func(claims *TokenClaims) { claims.Provider = "" }, InvalidTokenClaims},
+		{"no id", func(claims *TokenClaims) { claims.ID = "" }, InvalidTokenClaims},
+		{"no exp", func(claims *TokenClaims) { claims.ExpiresAt = 0 }, InvalidTokenClaims},
+		{"no nbf", func(claims *TokenClaims) { claims.NotBefore = 0 }, InvalidTokenClaims},
+		{"expired", func(claims *TokenClaims) { claims.ExpiresAt = now.Add(-2 * time.Minute).Unix() }, TokenExpired},
+		{"expired in the clock skew", func(claims *TokenClaims) { claims.ExpiresAt = now.Add(-30 * time.Second).Unix() }, nil},
+		{"expired at the clock skew", func(claims *TokenClaims) { claims.ExpiresAt = now.Add(-time.Minute).Unix() }, TokenExpired},
+		{"not valid yet", func(claims *TokenClaims) { claims.NotBefore = now.Add(2 * time.Minute).Unix() }, TokenNotValidYet},
+		{"not valid yet in the clock skew", func(claims *TokenClaims) { claims.NotBefore = now.Add(time.Minute).Unix() }, nil},
+		{"other issuer", func(claims *TokenClaims) { claims.Issuer = "https://other.example" }, InvalidTokenIssuer},
+		{"no issuer", func(claims *TokenClaims) { claims.Issuer = "" }, InvalidTokenIssuer},
+		{"other audience", func(claims *TokenClaims) { claims.Audience = TokenAudience{"other"} }, InvalidTokenAudience},
+		{"no audience", func(claims *TokenClaims) { claims.Audience = nil }, InvalidTokenAudience},
+	}
+
+	for _, test := range tests {
+		claims := valid
+		test.modify(&claims)
+		if err := validator.validateClaims(claims); err != test.err {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+		}
+	}
+}
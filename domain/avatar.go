@@ -49,112 +49,74 @@ type AvatarRotation struct {
 
 // GetPublicAvatarByID gets avatar by id.
 func GetPublicAvatarByID(ctx context.Context, id int64) (Avatar, error) {
-	avatar := new(Avatar)
-
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+	avatar, err := repositories.Avatars.Get(ctx, 0, id)
 	if err != nil {
-		return *avatar, err
-	}
-
-	key := datastore.IDKey("Avatar", id, nil)
-	if err := client.Get(ctx, key, avatar); err != nil {
-		if err == datastore.ErrNoSuchEntity {
-			return *avatar, AvatarNotFound
-		} else {
-			return *avatar, err
+		if err == ErrNoSuchEntity {
+			return avatar, AvatarNotFound
 		}
+		return avatar, err
 	}
 
-	avatar.ID = id
 	avatar.URL = createAvatarPublicURL(id)
 
-	return *avatar, nil
+	return avatar, nil
 }
 
 func GetCurrentUsersAvatarByID(ctx context.Context, id int64, userID int64) (Avatar, error) {
-	avatar := new(Avatar)
-
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+	avatar, err := repositories.Avatars.Get(ctx, userID, id)
 	if err != nil {
-		return *avatar, err
-	}
-
-	ancestor := datastore.IDKey("User", userID, nil)
-	key := datastore.IDKey("Avatar", id, ancestor)
-	if err := client.Get(ctx, key, avatar); err != nil {
-		if err == datastore.ErrNoSuchEntity {
-			return *avatar, AvatarNotFound
-		} else {
-			return *avatar, err
+		if err == ErrNoSuchEntity {
+			return avatar, AvatarNotFound
 		}
+		return avatar, err
 	}
 
 	url, err := createAvatarSignedURLs(ctx, id)
 	if err != nil {
-		return *avatar, err
+		return avatar, err
 	}
 
-	avatar.ID = id
 	avatar.URL = url
 
-	return *avatar, nil
+	return avatar, nil
 }
 
-// GetCurrentUsersAvatars gets avatars belongs to user.
-func GetCurrentUsersAvatars(ctx context.Context, userID int64) ([]Avatar, error) {
-	var avatars []Avatar
-
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+// GetCurrentUsersAvatars gets the page of avatars belongs to user, and the cursor of next page.
+func GetCurrentUsersAvatars(ctx context.Context, userID int64, page Page) ([]Avatar, string, error) {
+	avatars, nextCursor, err := repositories.Avatars.ListByUserID(ctx, userID, page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	ancestor := datastore.IDKey("User", userID, nil)
-	query := datastore.NewQuery("Avatar").Ancestor(ancestor).Order("-Created")
-	keys, err := client.GetAll(ctx, query, &avatars)
-	if err != nil {
-		return nil, err
-	}
-
-	for i, key := range keys {
-		url, err := createAvatarSignedURLs(ctx, key.ID)
+	for i := range avatars {
+		url, err := createAvatarSignedURLs(ctx, avatars[i].ID)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
-		avatars[i].ID = key.ID
 		avatars[i].URL = url
 	}
 
-	return avatars, nil
+	return avatars, nextCursor, nil
 }
 
-// GetPublicAvatars gets public avatars.
-func GetPublicAvatars(ctx context.Context) ([]Avatar, error) {
-	var avatars []Avatar
-
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+// GetPublicAvatars gets the page of public avatars, and the cursor of next page.
+func GetPublicAvatars(ctx context.Context, page Page) ([]Avatar, string, error) {
+	avatars, nextCursor, err := repositories.Avatars.ListPublic(ctx, page)
 	if err != nil {
-		return avatars, err
+		return nil, "", err
 	}
 
-	query := datastore.NewQuery("Avatar").Filter("IsPublic =", true).Order("-Created")
-	keys, err := client.GetAll(ctx, query, &avatars)
-	if err != nil {
-		return nil, err
+	for i := range avatars {
+		avatars[i].URL = createAvatarPublicURL(avatars[i].ID)
 	}
 
-	for i, key := range keys {
-		avatars[i].ID = key.ID
-		avatars[i].URL = createAvatarPublicURL(key.ID)
-	}
-
-	return avatars, nil
+	return avatars, nextCursor, nil
 }
 
 func createAvatarPublicURL(id int64) string {
 	fileID := strconv.FormatInt(id, 10)
-	return "https://storage.googleapis.com/" + infrastructure.PublicBucketName() + "/avatar/" + fileID + ".vrm"
+	return infrastructure.GetPublicURL(infrastructure.PublicBucketName(), "avatar/"+fileID+".vrm")
 }
 
 func createAvatarSignedURLs(ctx context.Context, id int64) (string, error) {
@@ -162,7 +124,7 @@ func createAvatarSignedURLs(ctx context.Context, id int64) (string, error) {
 	filePath := infrastructure.StorageObjectFilePath("Avatar", fileID, "vrm")
 	bucketName := infrastructure.MaterialBucketName()
 
-	url, err := infrastructure.GetGCSSignedURL(ctx, bucketName, filePath, "GET", "")
+	url, err := infrastructure.GetSignedURL(ctx, bucketName, filePath, "GET", "")
 	if err != nil {
 		return url, err
 	}
@@ -172,19 +134,75 @@ func createAvatarSignedURLs(ctx context.Context, id int64) (string, error) {
 
 // CreateAvatar creates a new avatar belongs to user.
 func CreateAvatar(ctx context.Context, avatar *Avatar, user *User) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
-	}
-
 	currentTime := time.Now()
 	avatar.IsPublic = false
 	avatar.Created = currentTime
 	avatar.Updated = currentTime
 
-	ancestor := datastore.IDKey("User", user.ID, nil)
-	key := datastore.IncompleteKey("Avatar", ancestor)
-	putKey, err := client.Put(ctx, key, avatar)
+	return repositories.Avatars.Create(ctx, user.ID, avatar)
+}
+
+type datastoreAvatarRepository struct {
+	datastoreStore
+}
+
+func avatarKey(userID int64, id int64) *datastore.Key {
+	if userID == 0 {
+		return datastore.IDKey("Avatar", id, nil)
+	}
+
+	ancestor := datastore.IDKey("User", userID, nil)
+	return datastore.IDKey("Avatar", id, ancestor)
+}
+
+func (r datastoreAvatarRepository) Get(ctx context.Context, userID int64, id int64) (Avatar, error) {
+	var avatar Avatar
+
+	if err := r.get(ctx, avatarKey(userID, id), &avatar); err != nil {
+		return avatar, err
+	}
+
+	avatar.ID = id
+
+	return avatar, nil
+}
+
+func (r datastoreAvatarRepository) ListByUserID(ctx context.Context, userID int64, page Page) ([]Avatar, string, error) {
+	var avatars []Avatar
+
+	ancestor := datastore.IDKey("User", userID, nil)
+	query := datastore.NewQuery("Avatar").Ancestor(ancestor).Order("-Created")
+	keys, nextCursor, err := getPage(ctx, r.client, query, page, &avatars)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i, key := range keys {
+		avatars[i].ID = key.ID
+	}
+
+	return avatars, nextCursor, nil
+}
+
+func (r datastoreAvatarRepository) ListPublic(ctx context.Context, page Page) ([]Avatar, string, error) {
+	var avatars []Avatar
+
+	query := datastore.NewQuery("Avatar").Filter("IsPublic =", true).Order("-Created")
+	keys, nextCursor, err := getPage(ctx, r.client, query, page, &avatars)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i, key := range keys {
+		avatars[i].ID = key.ID
+	}
+
+	return avatars, nextCursor, nil
+}
+
+func (r datastoreAvatarRepository) Create(ctx context.Context, userID int64, avatar *Avatar) error {
+	key := datastore.IncompleteKey("Avatar", datastore.IDKey("User", userID, nil))
+	putKey, err := r.put(ctx, key, avatar)
 	if err != nil {
 		return err
 	}
@@ -193,3 +211,7 @@ func CreateAvatar(ctx context.Context, avatar *Avatar, user *User) error {
 
 	return nil
 }
+
+func (r datastoreAvatarRepository) Delete(ctx context.Context, userID int64, id int64) error {
+	return r.delete(ctx, avatarKey(userID, id))
+}
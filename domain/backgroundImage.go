@@ -18,21 +18,32 @@ type BackgroundImage struct {
 
 // GetAllBackgroundImages is return all sorted images.
 func GetAllBackgroundImages(ctx context.Context) ([]BackgroundImage, error) {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+	images, err := repositories.BackgroundImages.List(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	for i := range images {
+		images[i].URL = infrastructure.GetPublicBackgroundImageURL(strconv.FormatInt(images[i].ID, 10))
+	}
+
+	return images, nil
+}
+
+type datastoreBackgroundImageRepository struct {
+	datastoreStore
+}
+
+func (r datastoreBackgroundImageRepository) List(ctx context.Context) ([]BackgroundImage, error) {
 	var images []BackgroundImage
 	query := datastore.NewQuery("BackgroundImage").Order("SortID")
-	keys, err := client.GetAll(ctx, query, &images)
+	keys, err := r.client.GetAll(ctx, query, &images)
 	if err != nil {
 		return nil, err
 	}
 
 	for i, key := range keys {
 		images[i].ID = key.ID
-		images[i].URL = infrastructure.GetPublicBackgroundImageURL(strconv.FormatInt(key.ID, 10))
 	}
 
 	return images, nil
@@ -19,65 +19,131 @@ type BackgroundMusic struct {
 	Created  time.Time `json:"created"`
 }
 
-// GetPublicBackgroundMusics is return sorted public musics.
-func GetPublicBackgroundMusics(ctx context.Context) ([]BackgroundMusic, error) {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+// GetPublicBackgroundMusics is return the page of sorted public musics, and the cursor of next page.
+func GetPublicBackgroundMusics(ctx context.Context, page Page) ([]BackgroundMusic, string, error) {
+	musics, nextCursor, err := repositories.BackgroundMusics.ListPublic(ctx, page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
+	for i := range musics {
+		musics[i].URL = infrastructure.GetPublicBackgroundMusicURL(strconv.FormatInt(musics[i].ID, 10))
+	}
+
+	return musics, nextCursor, nil
+}
+
+// GetCurrentUsersBackgroundMusics is return the page of musics belongs to user, and the cursor of next page.
+func GetCurrentUsersBackgroundMusics(ctx context.Context, userID int64, page Page) ([]BackgroundMusic, string, error) {
+	musics, nextCursor, err := repositories.BackgroundMusics.ListByUserID(ctx, userID, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i := range musics {
+		url, err := getBackgroundMusicSignedURL(ctx, musics[i].ID)
+		if err != nil {
+			return nil, "", err
+		}
+		musics[i].URL = url
+	}
+
+	return musics, nextCursor, nil
+}
+
+func CreateBackgroundMusic(ctx context.Context, userID int64, backgroundMusic *BackgroundMusic) error {
+	backgroundMusic.Created = time.Now()
+
+	return repositories.BackgroundMusics.Create(ctx, userID, backgroundMusic)
+}
+
+func getBackgroundMusicSignedURL(ctx context.Context, id int64) (string, error) {
+	fileID := strconv.FormatInt(id, 10)
+	filePath := infrastructure.StorageObjectFilePath("bgm", fileID, "mp3")
+	fileType := "" // this is unnecessary when GET request
+	bucketName := infrastructure.MaterialBucketName()
+	url, err := infrastructure.GetSignedURL(ctx, bucketName, filePath, "GET", fileType)
+
+	if err != nil {
+		return "", err
+	}
+
+	return url, nil
+}
+
+type datastoreBackgroundMusicRepository struct {
+	datastoreStore
+}
+
+func (r datastoreBackgroundMusicRepository) Get(ctx context.Context, userID int64, id int64) (BackgroundMusic, error) {
+	var music BackgroundMusic
+
+	ancestor := datastore.IDKey("User", userID, nil)
+	if err := r.get(ctx, datastore.IDKey("BackgroundMusic", id, ancestor), &music); err != nil {
+		return music, err
+	}
+
+	music.ID = id
+
+	return music, nil
+}
+
+func (r datastoreBackgroundMusicRepository) ListPublic(ctx context.Context, page Page) ([]BackgroundMusic, string, error) {
 	var musics []BackgroundMusic
 	query := datastore.NewQuery("BackgroundMusic").Filter("IsPublic =", true).Order("SortID")
-	keys, err := client.GetAll(ctx, query, &musics)
+	keys, nextCursor, err := getPage(ctx, r.client, query, page, &musics)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	for i, key := range keys {
 		musics[i].ID = key.ID
-		musics[i].URL = infrastructure.GetPublicBackgroundMusicURL(strconv.FormatInt(key.ID, 10))
 	}
 
-	return musics, nil
+	return musics, nextCursor, nil
 }
 
-func GetCurrentUsersBackgroundMusics(ctx context.Context, userID int64) ([]BackgroundMusic, error) {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return nil, err
-	}
-
+func (r datastoreBackgroundMusicRepository) ListByUserID(ctx context.Context, userID int64, page Page) ([]BackgroundMusic, string, error) {
 	var musics []BackgroundMusic
 	ancestor := datastore.IDKey("User", userID, nil)
 	query := datastore.NewQuery("BackgroundMusic").Ancestor(ancestor).Order("-Created")
-	keys, err := client.GetAll(ctx, query, &musics)
+	keys, nextCursor, err := getPage(ctx, r.client, query, page, &musics)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	for i, key := range keys {
 		musics[i].ID = key.ID
-		url, err := getBackgroundMusicSignedURL(ctx, key.ID)
-		if err != nil {
-			return nil, err
-		}
-		musics[i].URL = url
 	}
 
-	return musics, nil
+	return musics, nextCursor, nil
 }
 
-func CreateBackgroundMusic(ctx context.Context, userID int64, backgroundMusic *BackgroundMusic) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
+func (r datastoreBackgroundMusicRepository) ListAvailableIDs(ctx context.Context, userID int64) ([]int64, error) {
+	var ids []int64
+
+	queries := []*datastore.Query{
+		datastore.NewQuery("BackgroundMusic").Filter("IsPublic =", true).KeysOnly(),
+		datastore.NewQuery("BackgroundMusic").Ancestor(datastore.IDKey("User", userID, nil)).KeysOnly(),
+	}
+	for _, query := range queries {
+		keys, err := r.client.GetAll(ctx, query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			ids = append(ids, key.ID)
+		}
 	}
 
-	backgroundMusic.Created = time.Now()
+	return ids, nil
+}
 
+func (r datastoreBackgroundMusicRepository) Create(ctx context.Context, userID int64, backgroundMusic *BackgroundMusic) error {
 	ancestor := datastore.IDKey("User", userID, nil)
 	key := datastore.IncompleteKey("BackgroundMusic", ancestor)
-	putKey, err := client.Put(ctx, key, backgroundMusic)
+	putKey, err := r.put(ctx, key, backgroundMusic)
 	if err != nil {
 		return err
 	}
@@ -87,16 +153,8 @@ func CreateBackgroundMusic(ctx context.Context, userID int64, backgroundMusic *B
 	return nil
 }
 
-func getBackgroundMusicSignedURL(ctx context.Context, id int64) (string, error) {
-	fileID := strconv.FormatInt(id, 10)
-	filePath := infrastructure.StorageObjectFilePath("bgm", fileID, "mp3")
-	fileType := "" // this is unnecessary when GET request
-	bucketName := infrastructure.MaterialBucketName()
-	url, err := infrastructure.GetGCSSignedURL(ctx, bucketName, filePath, "GET", fileType)
-
-	if err != nil {
-		return "", err
-	}
-
-	return url, nil
+func (r datastoreBackgroundMusicRepository) Delete(ctx context.Context, userID int64, id int64) error {
+	ancestor := datastore.IDKey("User", userID, nil)
+	key := datastore.IDKey("BackgroundMusic", id, ancestor)
+	return r.delete(ctx, key)
 }
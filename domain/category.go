@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"cloud.google.com/go/datastore"
-	"github.com/super-dog-human/teraconnectgo/infrastructure"
 )
 
 // Category of the class type.
@@ -17,15 +16,19 @@ type Category struct {
 
 // GetJapaneseCategories is return categories by the subject.
 func GetJapaneseCategories(ctx context.Context, subjectID int64) ([]Category, error) {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return nil, err
-	}
+	return repositories.Subjects.ListJapaneseCategories(ctx, subjectID)
+}
 
+// GetJapaneseCategory is return a category from id.
+func GetJapaneseCategory(ctx context.Context, id int64, subjectID int64) (Category, error) {
+	return repositories.Subjects.GetJapaneseCategory(ctx, subjectID, id)
+}
+
+func (r datastoreSubjectRepository) ListJapaneseCategories(ctx context.Context, subjectID int64) ([]Category, error) {
 	var categories []Category
 	ancestor := datastore.IDKey("Subject", subjectID, nil)
 	query := datastore.NewQuery("JapaneseCategory").Ancestor(ancestor).Order("SortID")
-	keys, err := client.GetAll(ctx, query, &categories)
+	keys, err := r.client.GetAll(ctx, query, &categories)
 	if err != nil {
 		return nil, err
 	}
@@ -37,21 +40,15 @@ func GetJapaneseCategories(ctx context.Context, subjectID int64) ([]Category, er
 	return categories, nil
 }
 
-// GetJapaneseCategory is return a category from id.
-func GetJapaneseCategory(ctx context.Context, id int64, subjectID int64) (Category, error) {
-	category := new(Category)
-
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return *category, err
-	}
+func (r datastoreSubjectRepository) GetJapaneseCategory(ctx context.Context, subjectID int64, id int64) (Category, error) {
+	var category Category
 
 	ancestor := datastore.IDKey("Subject", subjectID, nil)
 	key := datastore.IDKey("JapaneseCategory", id, ancestor)
-	if err := client.Get(ctx, key, category); err != nil {
-		return *category, err
+	if err := r.get(ctx, key, &category); err != nil {
+		return category, err
 	}
 	category.ID = id
 
-	return *category, nil
+	return category, nil
 }
@@ -51,6 +51,52 @@ func (s *LessonStatus) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+type LessonReviewStatus int8
+
+const (
+	LessonReviewStatusRequested        LessonReviewStatus = 0
+	LessonReviewStatusApproved         LessonReviewStatus = 1
+	LessonReviewStatusChangesRequested LessonReviewStatus = 2
+)
+
+func (r LessonReviewStatus) String() string {
+	switch r {
+	case LessonReviewStatusRequested:
+		return "requested"
+	case LessonReviewStatusApproved:
+		return "approved"
+	case LessonReviewStatusChangesRequested:
+		return "changesRequested"
+	default:
+		return "unknown"
+	}
+}
+
+func (r LessonReviewStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+func (s *LessonReviewStatus) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("data should be a string, got %s", data)
+	}
+
+	var status LessonReviewStatus
+	switch str {
+	case "requested":
+		status = LessonReviewStatusRequested
+	case "approved":
+		status = LessonReviewStatusApproved
+	case "changesRequested":
+		status = LessonReviewStatusChangesRequested
+	default:
+		return fmt.Errorf("invalid LessonReviewStatus %s", str)
+	}
+	*s = status
+	return nil
+}
+
 type DrawingAction int8
 
 const (
@@ -265,3 +311,33 @@ func (a *MusicAction) UnmarshalJSON(data []byte) error {
 	*a = action
 	return nil
 }
+
+type LessonSortOrder int8
+
+const (
+	LessonSortOrderNew     LessonSortOrder = 0
+	LessonSortOrderPopular LessonSortOrder = 1
+)
+
+func (r LessonSortOrder) String() string {
+	switch r {
+	case LessonSortOrderNew:
+		return "new"
+	case LessonSortOrderPopular:
+		return "popular"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLessonSortOrder returns LessonSortOrder from query string. blank is treated as "new".
+func ParseLessonSortOrder(str string) (LessonSortOrder, error) {
+	switch str {
+	case "", "new":
+		return LessonSortOrderNew, nil
+	case "popular":
+		return LessonSortOrderPopular, nil
+	default:
+		return LessonSortOrderNew, fmt.Errorf("invalid LessonSortOrder %s", str)
+	}
+}
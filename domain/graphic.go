@@ -27,6 +27,7 @@ func (e GraphicErrorCode) Error() string {
 // Graphic is used for lesson.
 type Graphic struct {
 	ID       int64     `json:"id" datastore:"-"`
+	UserID   int64     `json:"userID" datastore:"-"` // the graphic is stored under the user.
 	LessonID int64     `json:"lessonID"`
 	FileType string    `json:"fileType"`
 	IsPublic bool      `json:"isPublic"`
@@ -35,97 +36,52 @@ type Graphic struct {
 }
 
 func GetGraphicByID(ctx context.Context, id int64, userID int64) (Graphic, error) {
-	graphic := new(Graphic)
-
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+	graphic, err := repositories.Graphics.Get(ctx, userID, id)
 	if err != nil {
-		return *graphic, err
-	}
-
-	ancestor := datastore.IDKey("User", userID, nil)
-	key := datastore.IDKey("Graphic", id, ancestor)
-
-	if err := client.Get(ctx, key, graphic); err != nil {
-		if err == datastore.ErrNoSuchEntity {
-			return *graphic, GraphicNotFound
+		if err == ErrNoSuchEntity {
+			return graphic, GraphicNotFound
 		}
-		return *graphic, err
+		return graphic, err
 	}
 
-	graphic.ID = id
-
-	return *graphic, nil
+	return graphic, nil
 }
 
-func GetGraphicsByLessonID(ctx context.Context, lessonID int64, graphics *[]*Graphic) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
-	}
-
-	query := datastore.NewQuery("Graphic").Filter("LessonID =", lessonID).Order("Created")
-
-	keys, err := client.GetAll(ctx, query, graphics)
+// GetGraphicsByLessonID gets the page of graphics belongs to lesson, and returns the cursor of next page.
+func GetGraphicsByLessonID(ctx context.Context, lessonID int64, page Page, graphics *[]*Graphic) (string, error) {
+	found, nextCursor, err := repositories.Graphics.ListByLessonID(ctx, lessonID, page)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if len(*graphics) == 0 {
-		return GraphicNotFound
+	if len(found) == 0 && page.IsFirst() {
+		return "", GraphicNotFound
 	}
 
-	for i, graphic := range *graphics {
-		graphic.ID = keys[i].ID
+	for _, graphic := range found {
 		url, err := GetGraphicSignedURL(ctx, graphic)
 		if err != nil {
-			return err
+			return "", err
 		}
 		graphic.URL = url
 	}
 
-	return nil
+	*graphics = append(*graphics, found...)
+
+	return nextCursor, nil
 }
 
 func CreateGraphics(ctx context.Context, userID int64, graphics []*Graphic) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
-	}
-
-	parentKey := datastore.IDKey("User", userID, nil)
-
-	keys := make([]*datastore.Key, len(graphics))
 	currentTime := time.Now()
-	for i, graphic := range graphics {
-		keys[i] = datastore.IncompleteKey("Graphic", parentKey)
+	for _, graphic := range graphics {
 		graphic.Created = currentTime
 	}
 
-	putKeys, err := client.PutMulti(ctx, keys, graphics)
-	if err != nil {
-		return err
-	}
-
-	for i, graphic := range graphics {
-		graphic.ID = putKeys[i].ID
-	}
-
-	return nil
+	return repositories.Graphics.CreateMulti(ctx, userID, graphics)
 }
 
 func DeleteGraphicByID(ctx context.Context, id int64, userID int64) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
-	}
-
-	ancestor := datastore.IDKey("User", userID, nil)
-	key := datastore.IDKey("Graphic", id, ancestor)
-	if err := client.Delete(ctx, key); err != nil {
-		return err
-	}
-
-	return nil
+	return repositories.Graphics.Delete(ctx, userID, id)
 }
 
 func DeleteGraphicFileByID(ctx context.Context, graphic Graphic) error {
@@ -133,7 +89,7 @@ func DeleteGraphicFileByID(ctx context.Context, graphic Graphic) error {
 	fileID := strconv.FormatInt(graphic.ID, 10)
 	filePath := infrastructure.StorageObjectFilePath("Graphic", fileID, graphic.FileType)
 
-	if err := infrastructure.DeleteObjectFromGCS(ctx, bucketName, filePath); err != nil {
+	if err := infrastructure.DeleteFile(ctx, bucketName, filePath); err != nil {
 		return err
 	}
 
@@ -145,7 +101,7 @@ func GetGraphicSignedURL(ctx context.Context, graphic *Graphic) (string, error)
 	filePath := infrastructure.StorageObjectFilePath("Graphic", fileID, graphic.FileType)
 	fileType := "" // this is unnecessary when GET request
 	bucketName := infrastructure.MaterialBucketName()
-	url, err := infrastructure.GetGCSSignedURL(ctx, bucketName, filePath, "GET", fileType)
+	url, err := infrastructure.GetSignedURL(ctx, bucketName, filePath, "GET", fileType)
 
 	if err != nil {
 		return "", err
@@ -153,3 +109,97 @@ func GetGraphicSignedURL(ctx context.Context, graphic *Graphic) (string, error)
 
 	return url, nil
 }
+
+type datastoreGraphicRepository struct {
+	datastoreStore
+}
+
+func (r datastoreGraphicRepository) Get(ctx context.Context, userID int64, id int64) (Graphic, error) {
+	var graphic Graphic
+
+	ancestor := datastore.IDKey("User", userID, nil)
+	key := datastore.IDKey("Graphic", id, ancestor)
+	if err := r.get(ctx, key, &graphic); err != nil {
+		return graphic, err
+	}
+
+	graphic.ID = id
+	graphic.UserID = userID
+
+	return graphic, nil
+}
+
+func (r datastoreGraphicRepository) GetMulti(ctx context.Context, userID int64, ids []int64) ([]Graphic, error) {
+	ancestor := datastore.IDKey("User", userID, nil)
+	keys := make([]*datastore.Key, len(ids))
+	for i, id := range ids {
+		keys[i] = datastore.IDKey("Graphic", id, ancestor)
+	}
+
+	found := make([]Graphic, len(ids))
+	err := r.getMulti(ctx, keys, found)
+	multiErr, isMultiErr := err.(datastore.MultiError)
+	if err != nil && !isMultiErr {
+		return nil, err
+	}
+
+	var graphics []Graphic
+	for i := range found {
+		if isMultiErr && multiErr[i] != nil {
+			if multiErr[i] == datastore.ErrNoSuchEntity {
+				continue
+			}
+			return nil, multiErr[i]
+		}
+
+		found[i].ID = ids[i]
+		found[i].UserID = userID
+		graphics = append(graphics, found[i])
+	}
+
+	return graphics, nil
+}
+
+func (r datastoreGraphicRepository) ListByLessonID(ctx context.Context, lessonID int64, page Page) ([]*Graphic, string, error) {
+	var graphics []*Graphic
+
+	query := datastore.NewQuery("Graphic").Filter("LessonID =", lessonID).Order("Created")
+	keys, nextCursor, err := getPage(ctx, r.client, query, page, &graphics)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i, graphic := range graphics {
+		graphic.ID = keys[i].ID
+		graphic.UserID = keys[i].Parent.ID
+	}
+
+	return graphics, nextCursor, nil
+}
+
+func (r datastoreGraphicRepository) CreateMulti(ctx context.Context, userID int64, graphics []*Graphic) error {
+	parentKey := datastore.IDKey("User", userID, nil)
+
+	keys := make([]*datastore.Key, len(graphics))
+	for i := range graphics {
+		keys[i] = datastore.IncompleteKey("Graphic", parentKey)
+	}
+
+	putKeys, err := r.putMulti(ctx, keys, graphics)
+	if err != nil {
+		return err
+	}
+
+	for i, graphic := range graphics {
+		graphic.ID = putKeys[i].ID
+		graphic.UserID = userID
+	}
+
+	return nil
+}
+
+func (r datastoreGraphicRepository) Delete(ctx context.Context, userID int64, id int64) error {
+	ancestor := datastore.IDKey("User", userID, nil)
+	key := datastore.IDKey("Graphic", id, ancestor)
+	return r.delete(ctx, key)
+}
@@ -2,12 +2,9 @@ package domain
 
 import (
 	"context"
-	"reflect"
 	"time"
 
 	"cloud.google.com/go/datastore"
-	"github.com/imdario/mergo"
-	"github.com/super-dog-human/teraconnectgo/infrastructure"
 )
 
 // Lesson is the lesson infomation type.
@@ -15,14 +12,17 @@ type Lesson struct {
 	ID                   int64              `json:"id" datastore:"-"`
 	UserID               int64              `json:"userID"`
 	MaterialID           int64              `json:"materialID"`
+	SeriesID             int64              `json:"seriesID"` // 所属するシリーズ、前後の授業はシリーズの順番から設定される
 	PrevLessonID         int64              `json:"prevLessonID"`
 	PrevLessonTitle      string             `json:"prevLessonTitle" datastore:"-"`
 	NextLessonID         int64              `json:"nextLessonID"`
 	NextLessonTitle      string             `json:"nextLessonTitle" datastore:"-"`
-	NeedsRecording       bool               `json:"needsRecording"` // 収録画面での収録必要の有無
-	IsEdited             bool               `json:"isEdited"`       // 編集画面から保存されたことがある
-	IsIntroduction       bool               `json:"isIntroduction"` // 自己紹介用の授業
-	IsPacked             bool               `json:"isPacked"`       // 公開準備の完了
+	ForkedFromLessonID   int64              `json:"forkedFromLessonID"` // 複製元の授業
+	NeedsRecording       bool               `json:"needsRecording"`     // 収録画面での収録必要の有無
+	IsEdited             bool               `json:"isEdited"`           // 編集画面から保存されたことがある
+	IsIntroduction       bool               `json:"isIntroduction"`     // 自己紹介用の授業
+	IsPacked             bool               `json:"isPacked"`           // 公開準備の完了
+	IsDeleting           bool               `json:"-"`                  // 削除中、途中で失敗した削除は再度の削除で再開する
 	HasThumbnail         bool               `json:"hasThumbnail"`
 	ThumbnailURL         string             `json:"thumbnailURL" datastore:"-"`
 	Status               LessonStatus       `json:"status"`
@@ -36,64 +36,83 @@ type Lesson struct {
 	Description          string             `json:"description"`
 	DurationSec          float64            `json:"durationSec"`
 	ViewCount            int64              `json:"viewCount"`
-	ViewKey              string             `json:"-"`
+	ViewKey              string             `json:"-"` // 限定公開のURLに含めるキー
+	ViewKeyExpires       time.Time          `json:"-"` // 限定公開のURLの有効期限、ゼロ値は無期限
 	SizeInBytes          int64              `json:"sizeInBytes"`
+	Revision             int64              `json:"-"` // 更新ごとに増加、ETagに使う
 	Created              time.Time          `json:"created"`
 	Updated              time.Time          `json:"updated"`
 	Published            time.Time          `json:"published"`
+	PublishAt            time.Time          `json:"publishAt"`   // 公開する予定の日時、ゼロ値は予定なし
+	UnpublishAt          time.Time          `json:"unpublishAt"` // 非公開にする予定の日時、ゼロ値は予定なし
 }
 
 // LessonReferences is link to another web page.
 type LessonReferences struct {
-	Name string `json:"name"`
-	ISBN string `json:"isbn"` // ISBN13を想定
+	Name string `json:"name" validate:"max=200"`
+	ISBN string `json:"isbn" validate:"omitempty,isbn13"` // ISBN13を想定
 }
 
 // LessonReview is review status of lesson by other users.
 type LessonReview struct {
-	ReviewerUserID int64     `json:"userID"`
-	Comment        string    `json:"comment"`
-	Created        time.Time `json:"created"`
+	ReviewerUserID int64              `json:"userID"`
+	Status         LessonReviewStatus `json:"status"`
+	Comment        string             `json:"comment" datastore:",noindex"`
+	Created        time.Time          `json:"created"`
+	Updated        time.Time          `json:"updated"`
 }
 
+// GetLessonByID returns ErrNoSuchEntity for the lesson being deleted.
 func GetLessonByID(ctx context.Context, id int64) (Lesson, error) {
-	lesson := new(Lesson)
-
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+	lesson, err := repositories.Lessons.Get(ctx, id)
 	if err != nil {
-		return *lesson, err
+		return lesson, err
 	}
 
-	key := datastore.IDKey("Lesson", id, nil)
-	if err := client.Get(ctx, key, lesson); err != nil {
-		return *lesson, err
+	if lesson.IsDeleting {
+		return Lesson{}, ErrNoSuchEntity
 	}
 
-	lesson.ID = id
-
-	if err = SetLessonThumbnailURL(ctx, lesson); err != nil {
-		return *lesson, err
+	if err = SetLessonThumbnailURL(ctx, &lesson); err != nil {
+		return lesson, err
 	}
 
-	return *lesson, nil
+	return lesson, nil
 }
 
-func GetLessonsByUserID(ctx context.Context, userID int64) ([]Lesson, error) {
-	var lessons []Lesson
-
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+// GetLessonsByUserID returns the page of lessons belongs to user, and the cursor of next page.
+// the lessons being deleted are excluded, so the page may have less lessons than the limit.
+func GetLessonsByUserID(ctx context.Context, userID int64, page Page) ([]Lesson, string, error) {
+	lessons, nextCursor, err := repositories.Lessons.ListByUserID(ctx, userID, page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	query := datastore.NewQuery("Lesson").Filter("UserID =", userID).Order("-Created")
-	keys, err := client.GetAll(ctx, query, &lessons)
+	lessons = excludeDeletingLessons(lessons)
+
+	for i := range lessons {
+		if err := SetLessonThumbnailURL(ctx, &lessons[i]); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return lessons, nextCursor, nil
+}
+
+// GetLessonsByIDs returns lessons in order of ids. the lessons not found or being deleted are skipped.
+func GetLessonsByIDs(ctx context.Context, ids []int64) ([]Lesson, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	lessons, err := repositories.Lessons.GetMulti(ctx, ids)
 	if err != nil {
 		return nil, err
 	}
 
-	for i, key := range keys {
-		lessons[i].ID = key.ID
+	lessons = excludeDeletingLessons(lessons)
+
+	for i := range lessons {
 		if err := SetLessonThumbnailURL(ctx, &lessons[i]); err != nil {
 			return nil, err
 		}
@@ -102,99 +121,218 @@ func GetLessonsByUserID(ctx context.Context, userID int64) ([]Lesson, error) {
 	return lessons, nil
 }
 
-func CreateLesson(ctx context.Context, lesson *Lesson) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
+func excludeDeletingLessons(lessons []Lesson) []Lesson {
+	found := lessons[:0]
+	for _, lesson := range lessons {
+		if !lesson.IsDeleting {
+			found = append(found, lesson)
+		}
 	}
+	return found
+}
 
+func CreateLesson(ctx context.Context, lesson *Lesson) error {
 	currentTime := time.Now()
 	lesson.Status = LessonStatusDraft
 	lesson.Created = currentTime
 	lesson.Updated = currentTime
 
-	key, err := client.Put(ctx, datastore.IncompleteKey("Lesson", nil), lesson)
+	return repositories.Lessons.Create(ctx, lesson)
+}
 
-	if err != nil {
+func UpdateLesson(ctx context.Context, lesson *Lesson) error {
+	lesson.Updated = time.Now()
+	lesson.Revision++
+
+	return repositories.Lessons.Put(ctx, lesson)
+}
+
+// UpdateLessonAndMaterial applies the patches to the lesson and the material of the revisions, returns RevisionMismatch when either was updated after it.
+// the revision of lesson is increased even if only the material is updated, because they are edited together.
+// the material is updated first, so that the requirements of the status are checked with the new material.
+func UpdateLessonAndMaterial(ctx context.Context, lessonID int64, lessonMaterialID int64, revision int64, materialRevision int64, lessonPatch MergePatch, lessonMaterialPatch MergePatch) (Lesson, LessonMaterial, error) {
+	var lesson Lesson
+	var lessonMaterial LessonMaterial
+	err := repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		if len(lessonMaterialPatch) > 0 {
+			var err error
+			if lessonMaterial, err = updateLessonMaterialInTransaction(ctx, lessonMaterialID, lessonID, materialRevision, lessonMaterialPatch); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		lesson, err = updateLessonInTransaction(ctx, lessonID, revision, lessonPatch)
 		return err
+	})
+	if err != nil || len(lessonMaterialPatch) == 0 {
+		return lesson, lessonMaterial, err
 	}
 
-	lesson.ID = key.ID
-
-	return nil
+	return lesson, lessonMaterial, pruneLessonMaterialVersions(ctx, lessonID, lessonMaterialID)
 }
 
-func UpdateLesson(ctx context.Context, lesson *Lesson) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+func updateLessonInTransaction(ctx context.Context, id int64, revision int64, patch MergePatch) (Lesson, error) {
+	lesson, err := repositories.Lessons.Get(ctx, id)
 	if err != nil {
-		return err
+		return lesson, err
 	}
 
-	lesson.Updated = time.Now()
+	if err := checkRevision(revision, lesson.Revision); err != nil {
+		return lesson, err
+	}
 
-	key := datastore.IDKey("Lesson", lesson.ID, nil)
-	if _, err := client.Put(ctx, key, lesson); err != nil {
-		return err
+	newLesson := lesson
+	if err := patch.Apply(&newLesson); err != nil {
+		return lesson, err
 	}
 
-	return nil
+	if err := checkLessonSchedule(lesson, newLesson); err != nil {
+		return lesson, err
+	}
+
+	if err := checkLessonStatusTransition(ctx, lesson, newLesson); err != nil {
+		return lesson, err
+	}
+
+	if err := setLessonViewKey(lesson, &newLesson); err != nil {
+		return lesson, err
+	}
+
+	currentTime := time.Now()
+	if lesson.Status != LessonStatusPublic && newLesson.Status == LessonStatusPublic {
+		newLesson.Published = currentTime
+	} else {
+		newLesson.Published = lesson.Published
+	}
+	newLesson.ID = id
+	newLesson.Created = lesson.Created
+	newLesson.Updated = currentTime
+	newLesson.Revision = lesson.Revision + 1
+
+	return newLesson, repositories.Lessons.Put(ctx, &newLesson)
 }
 
-func UpdateLessonAndMaterial(ctx context.Context, lessonID int64, lessonMaterialID int64, lesson *Lesson, lessonMaterial *LessonMaterial) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
+type datastoreLessonRepository struct {
+	datastoreStore
+}
+
+func (r datastoreLessonRepository) Get(ctx context.Context, id int64) (Lesson, error) {
+	var lesson Lesson
+
+	key := datastore.IDKey("Lesson", id, nil)
+	if err := r.get(ctx, key, &lesson); err != nil {
+		return lesson, err
 	}
 
-	_, err = client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
-		var blankLesson Lesson
-		if !reflect.DeepEqual(*lesson, blankLesson) {
-			lesson.ID = lessonID
-			if err := updateLessonInTransaction(tx, lesson); err != nil {
-				return err
-			}
-		}
+	lesson.ID = id
 
-		var blankLessonMaterial LessonMaterial
-		if !reflect.DeepEqual(*lessonMaterial, blankLessonMaterial) {
-			if err := UpdateLessonMaterialInTransaction(tx, lessonMaterialID, lessonID, lessonMaterial); err != nil {
-				return err
+	return lesson, nil
+}
+
+func (r datastoreLessonRepository) GetMulti(ctx context.Context, ids []int64) ([]Lesson, error) {
+	keys := make([]*datastore.Key, len(ids))
+	for i, id := range ids {
+		keys[i] = datastore.IDKey("Lesson", id, nil)
+	}
+
+	found := make([]Lesson, len(ids))
+	err := r.getMulti(ctx, keys, found)
+	multiErr, isMultiErr := err.(datastore.MultiError)
+	if err != nil && !isMultiErr {
+		return nil, err
+	}
+
+	var lessons []Lesson
+	for i := range found {
+		if isMultiErr && multiErr[i] != nil {
+			if multiErr[i] == datastore.ErrNoSuchEntity {
+				continue
 			}
+			return nil, multiErr[i]
 		}
 
-		return nil
-	})
+		found[i].ID = ids[i]
+		lessons = append(lessons, found[i])
+	}
 
+	return lessons, nil
+}
+
+func (r datastoreLessonRepository) ListByUserID(ctx context.Context, userID int64, page Page) ([]Lesson, string, error) {
+	var lessons []Lesson
+
+	query := datastore.NewQuery("Lesson").Filter("UserID =", userID).Order("-Created")
+	keys, nextCursor, err := getPage(ctx, r.client, query, page, &lessons)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 
-	return nil
+	for i, key := range keys {
+		lessons[i].ID = key.ID
+	}
+
+	return lessons, nextCursor, nil
 }
 
-func updateLessonInTransaction(tx *datastore.Transaction, newLesson *Lesson) error {
-	key := datastore.IDKey("Lesson", newLesson.ID, nil)
-	var lesson Lesson
-	if err := tx.Get(key, &lesson); err != nil {
-		return err
+func (r datastoreLessonRepository) ListByReviewerUserID(ctx context.Context, userID int64, page Page) ([]Lesson, string, error) {
+	var lessons []Lesson
+
+	query := datastore.NewQuery("Lesson").Filter("Reviews.ReviewerUserID =", userID).Order("-Created")
+	keys, nextCursor, err := getPage(ctx, r.client, query, page, &lessons)
+	if err != nil {
+		return nil, "", err
 	}
 
-	if err := mergo.Merge(newLesson, lesson); err != nil {
-		return err
+	for i, key := range keys {
+		lessons[i].ID = key.ID
 	}
 
-	currentTime := time.Now()
-	if lesson.Status != LessonStatusPublic && newLesson.Status == LessonStatusPublic {
-		newLesson.Published = currentTime
-	} else {
-		newLesson.Published = lesson.Published
+	return lessons, nextCursor, nil
+}
+
+func (r datastoreLessonRepository) ListScheduledIDs(ctx context.Context, now time.Time) ([]int64, error) {
+	var ids []int64
+	seen := make(map[int64]bool)
+
+	for _, property := range []string{"PublishAt", "UnpublishAt"} {
+		query := datastore.NewQuery("Lesson").Filter(property+" >", time.Time{}).Filter(property+" <=", now).KeysOnly()
+		keys, err := r.client.GetAll(ctx, query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			if !seen[key.ID] {
+				seen[key.ID] = true
+				ids = append(ids, key.ID)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+func (r datastoreLessonRepository) Create(ctx context.Context, lesson *Lesson) error {
+	key, err := r.put(ctx, datastore.IncompleteKey("Lesson", nil), lesson)
+	if err != nil {
+		return err
 	}
-	newLesson.Created = lesson.Created
-	newLesson.Updated = currentTime
 
-	if _, err := tx.Put(key, newLesson); err != nil {
+	lesson.ID = key.ID
+
+	return nil
+}
+
+func (r datastoreLessonRepository) Put(ctx context.Context, lesson *Lesson) error {
+	key := datastore.IDKey("Lesson", lesson.ID, nil)
+	if _, err := r.put(ctx, key, lesson); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (r datastoreLessonRepository) Delete(ctx context.Context, id int64) error {
+	return r.delete(ctx, datastore.IDKey("Lesson", id, nil))
+}
@@ -0,0 +1,207 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+// GetLessonToDelete returns the lesson even if it is being deleted, so that the failed deletion can be retried.
+func GetLessonToDelete(ctx context.Context, id int64) (Lesson, error) {
+	return repositories.Lessons.Get(ctx, id)
+}
+
+// DeleteLesson deletes the lesson with the materials and their versions, voices, graphics, daily views, review comments and files belongs to it.
+// the lesson is marked as deleting at first and deleted at last, and each step ignores the things already deleted,
+// so calling this again resumes the deletion failed on the way.
+func DeleteLesson(ctx context.Context, lesson Lesson) error {
+	if !lesson.IsDeleting {
+		lesson.IsDeleting = true
+		if err := UpdateLesson(ctx, &lesson); err != nil {
+			return err
+		}
+	}
+
+	if err := lessonIndex.Delete(ctx, lesson.ID); err != nil {
+		return err
+	}
+
+	if err := unlinkLesson(ctx, lesson); err != nil {
+		return err
+	}
+
+	if err := deleteLessonGraphics(ctx, lesson.ID); err != nil {
+		return err
+	}
+
+	if err := deleteLessonVoices(ctx, lesson.ID); err != nil {
+		return err
+	}
+
+	if err := deleteFilesWithPrefix(ctx, infrastructure.MaterialBucketName(), lessonMaterialVersionFilePrefix(lesson.ID)); err != nil {
+		return err
+	}
+
+	if err := repositories.LessonMaterialVersions.DeleteByLessonID(ctx, lesson.ID); err != nil {
+		return err
+	}
+
+	if err := repositories.LessonMaterials.DeleteByLessonID(ctx, lesson.ID); err != nil {
+		return err
+	}
+
+	if err := repositories.LessonViews.DeleteByLessonID(ctx, lesson.ID); err != nil {
+		return err
+	}
+
+	if err := repositories.LessonReviewComments.DeleteByLessonID(ctx, lesson.ID); err != nil {
+		return err
+	}
+
+	if err := deleteLessonFiles(ctx, lesson.ID); err != nil {
+		return err
+	}
+
+	return repositories.Lessons.Delete(ctx, lesson.ID)
+}
+
+// unlinkLesson removes the lesson from the series, and links the previous and next lessons each other.
+// the lesson not in the series any more was removed by the deletion failed on the way.
+func unlinkLesson(ctx context.Context, lesson Lesson) error {
+	if lesson.SeriesID == 0 {
+		return unlinkLessonWithoutSeries(ctx, lesson)
+	}
+
+	return repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		series, err := GetSeries(ctx, lesson.SeriesID)
+		if err == SeriesNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var lessonIDs []int64
+		for _, lessonID := range series.LessonIDs {
+			if lessonID != lesson.ID {
+				lessonIDs = append(lessonIDs, lessonID)
+			}
+		}
+
+		if len(lessonIDs) == len(series.LessonIDs) {
+			return nil
+		}
+
+		return setSeriesLessonsInTransaction(ctx, &series, lessonIDs)
+	})
+}
+
+// unlinkLessonWithoutSeries links the previous and next lessons each other instead of the lesson, for the lessons linked before series were added.
+// the neighbours no longer pointing to the lesson are left as they are.
+func unlinkLessonWithoutSeries(ctx context.Context, lesson Lesson) error {
+	return repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		if lesson.PrevLessonID != 0 {
+			prevLesson, err := repositories.Lessons.Get(ctx, lesson.PrevLessonID)
+			if err != nil && err != ErrNoSuchEntity {
+				return err
+			}
+			if err == nil && prevLesson.NextLessonID == lesson.ID {
+				prevLesson.NextLessonID = lesson.NextLessonID
+				if err := UpdateLesson(ctx, &prevLesson); err != nil {
+					return err
+				}
+			}
+		}
+
+		if lesson.NextLessonID != 0 {
+			nextLesson, err := repositories.Lessons.Get(ctx, lesson.NextLessonID)
+			if err != nil && err != ErrNoSuchEntity {
+				return err
+			}
+			if err == nil && nextLesson.PrevLessonID == lesson.ID {
+				nextLesson.PrevLessonID = lesson.PrevLessonID
+				if err := UpdateLesson(ctx, &nextLesson); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// deleteLessonGraphics deletes the file of each graphic before the entity, because the path of file needs the entity.
+func deleteLessonGraphics(ctx context.Context, lessonID int64) error {
+	for {
+		graphics, _, err := repositories.Graphics.ListByLessonID(ctx, lessonID, Page{})
+		if err != nil {
+			return err
+		}
+
+		if len(graphics) == 0 {
+			return nil
+		}
+
+		for _, graphic := range graphics {
+			if err := ignoreBlobNotExist(DeleteGraphicFileByID(ctx, *graphic)); err != nil {
+				return err
+			}
+			if err := repositories.Graphics.Delete(ctx, graphic.UserID, graphic.ID); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func deleteLessonVoices(ctx context.Context, lessonID int64) error {
+	if err := deleteFilesWithPrefix(ctx, infrastructure.MaterialBucketName(), fmt.Sprintf("voice/%d/", lessonID)); err != nil {
+		return err
+	}
+
+	return repositories.Voices.DeleteByLessonID(ctx, lessonID)
+}
+
+func deleteFilesWithPrefix(ctx context.Context, bucketName, prefix string) error {
+	files, err := infrastructure.ListFiles(ctx, bucketName, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := ignoreBlobNotExist(infrastructure.DeleteFile(ctx, bucketName, file.Name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteLessonFiles deletes the thumbnails in both buckets and the packed zip.
+func deleteLessonFiles(ctx context.Context, lessonID int64) error {
+	fileID := strconv.FormatInt(lessonID, 10)
+	thumbnailPath := infrastructure.StorageObjectFilePath("lesson_thumbnail", fileID, "png")
+	materialBucket := infrastructure.MaterialBucketName()
+
+	files := []struct{ bucketName, filePath string }{
+		{materialBucket, thumbnailPath},
+		{infrastructure.PublicBucketName(), thumbnailPath},
+		{materialBucket, fmt.Sprintf("lesson/%d.zip", lessonID)},
+	}
+
+	for _, file := range files {
+		if err := ignoreBlobNotExist(infrastructure.DeleteFile(ctx, file.bucketName, file.filePath)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ignoreBlobNotExist(err error) error {
+	if errors.Is(err, infrastructure.ErrBlobNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func createLessons(t *testing.T, n int) []Lesson {
+	lessons := make([]Lesson, n)
+	for i := range lessons {
+		lessons[i] = Lesson{UserID: 1}
+		if err := CreateLesson(context.Background(), &lessons[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return lessons
+}
+
+func getLesson(t *testing.T, id int64) Lesson {
+	lesson, err := repositories.Lessons.Get(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lesson
+}
+
+func createSeriesOf(t *testing.T, lessons []Lesson) Series {
+	series := Series{UserID: 1}
+	for _, lesson := range lessons {
+		series.LessonIDs = append(series.LessonIDs, lesson.ID)
+	}
+	if err := CreateSeries(context.Background(), &series); err != nil {
+		t.Fatal(err)
+	}
+	return series
+}
+
+func TestDeleteLessonRemovesItFromSeries(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lessons := createLessons(t, 3)
+	series := createSeriesOf(t, lessons)
+
+	if err := DeleteLesson(ctx, getLesson(t, lessons[1].ID)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetSeries(ctx, series.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{lessons[0].ID, lessons[2].ID}; !reflect.DeepEqual(got.LessonIDs, want) {
+		t.Errorf("got %v, want %v", got.LessonIDs, want)
+	}
+	if first := getLesson(t, lessons[0].ID); first.NextLessonID != lessons[2].ID {
+		t.Errorf("next of the first lesson = %d, want %d", first.NextLessonID, lessons[2].ID)
+	}
+	if last := getLesson(t, lessons[2].ID); last.PrevLessonID != lessons[0].ID {
+		t.Errorf("prev of the last lesson = %d, want %d", last.PrevLessonID, lessons[0].ID)
+	}
+}
+
+func TestDeleteLessonResumesAfterRemovedFromSeries(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lessons := createLessons(t, 2)
+	series := createSeriesOf(t, lessons)
+
+	// the deletion failed after the lesson was removed from the series, and the lesson read before it is retried.
+	lesson := getLesson(t, lessons[0].ID)
+	if _, err := RemoveSeriesLesson(ctx, series.ID, lesson.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteLesson(ctx, lesson); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repositories.Lessons.Get(ctx, lesson.ID); err != ErrNoSuchEntity {
+		t.Errorf("the lesson is not deleted: %v", err)
+	}
+}
+
+func TestDeleteLessonFailsWhenOtherSeriesLessonIsNotAvailable(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lessons := createLessons(t, 3)
+	series := createSeriesOf(t, lessons)
+
+	// the other lesson in the series is being deleted, the series can't be saved without it.
+	deleting := getLesson(t, lessons[2].ID)
+	deleting.IsDeleting = true
+	if err := repositories.Lessons.Put(ctx, &deleting); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteLesson(ctx, getLesson(t, lessons[0].ID)); err != SeriesLessonNotAvailable {
+		t.Fatalf("got %v, want SeriesLessonNotAvailable", err)
+	}
+
+	got, err := GetSeries(ctx, series.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.LessonIDs) != 3 {
+		t.Errorf("the series is changed: %v", got.LessonIDs)
+	}
+	if _, err := repositories.Lessons.Get(ctx, lessons[0].ID); err != nil {
+		t.Errorf("the lesson is deleted: %v", err)
+	}
+}
+
+func TestDeleteLessonLinksNeighboursWithoutSeries(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lessons := createLessons(t, 3)
+
+	// the lessons linked before series were added.
+	for i := range lessons {
+		if i > 0 {
+			lessons[i].PrevLessonID = lessons[i-1].ID
+		}
+		if i < len(lessons)-1 {
+			lessons[i].NextLessonID = lessons[i+1].ID
+		}
+		if err := repositories.Lessons.Put(ctx, &lessons[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := DeleteLesson(ctx, lessons[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if first := getLesson(t, lessons[0].ID); first.NextLessonID != lessons[2].ID {
+		t.Errorf("next of the first lesson = %d, want %d", first.NextLessonID, lessons[2].ID)
+	}
+	if last := getLesson(t, lessons[2].ID); last.PrevLessonID != lessons[0].ID {
+		t.Errorf("prev of the last lesson = %d, want %d", last.PrevLessonID, lessons[0].ID)
+	}
+}
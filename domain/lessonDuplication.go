@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+// DuplicateLesson creates the new draft of user copied from the lesson with the current material.
+// the graphics and voices referenced from the material are copied with their files, and the IDs in the material are remapped to them.
+// the private avatar and background musics of the author are copied too when user is not the author, the public ones are shared.
+// the assets are copied outside of the transaction same as ImportLesson, and the things copied are deleted when it fails.
+func DuplicateLesson(ctx context.Context, user User, original Lesson) (Lesson, error) {
+	lesson := importedLesson(original, user.ID)
+	lesson.ForkedFromLessonID = original.ID
+
+	if original.MaterialID == 0 {
+		if err := CreateLesson(ctx, &lesson); err != nil {
+			return Lesson{}, err
+		}
+		return lesson, nil
+	}
+
+	material, err := repositories.LessonMaterials.Get(ctx, original.ID, original.MaterialID)
+	if err != nil {
+		return Lesson{}, err
+	}
+
+	lesson.IsDeleting = true
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		return Lesson{}, err
+	}
+
+	idMaps := newLessonAssetIDMaps()
+	if err := duplicateLessonAssets(ctx, user, original, lesson.ID, material, idMaps); err != nil {
+		deleteImportedLesson(ctx, user, lesson, idMaps)
+		return Lesson{}, err
+	}
+
+	material.UserID = user.ID
+	remapLessonMaterialIDs(&material, idMaps.duplicated)
+
+	var duplicated Lesson
+	err = repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		material.ID = 0
+		if err := CreateLessonMaterial(ctx, lesson.ID, &material); err != nil {
+			return err
+		}
+
+		duplicated = lesson
+		duplicated.MaterialID = material.ID
+		duplicated.IsDeleting = false
+		return UpdateLesson(ctx, &duplicated)
+	})
+	if err != nil {
+		deleteImportedLesson(ctx, user, lesson, idMaps)
+		return Lesson{}, err
+	}
+
+	return duplicated, nil
+}
+
+// duplicateLessonAssets copies the graphics owned by the owner of lesson and the voices of lesson, and the private avatar and musics for the other user.
+// the assets not found are left as they are, same as the material before duplication. idMaps has the assets copied even if it fails on the way.
+func duplicateLessonAssets(ctx context.Context, user User, original Lesson, lessonID int64, material LessonMaterial, idMaps lessonAssetIDMaps) error {
+	bucketName := infrastructure.MaterialBucketName()
+
+	for _, lessonGraphic := range material.Graphics {
+		if _, ok := idMaps[LessonAssetGraphic][lessonGraphic.GraphicID]; ok || lessonGraphic.GraphicID == 0 {
+			continue
+		}
+
+		graphic, err := repositories.Graphics.Get(ctx, original.UserID, lessonGraphic.GraphicID)
+		if err == ErrNoSuchEntity {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		newGraphic := &Graphic{LessonID: lessonID, FileType: graphic.FileType}
+		if err := CreateGraphics(ctx, user.ID, []*Graphic{newGraphic}); err != nil {
+			return err
+		}
+		idMaps[LessonAssetGraphic][graphic.ID] = newGraphic.ID
+
+		srcPath := infrastructure.StorageObjectFilePath("Graphic", strconv.FormatInt(graphic.ID, 10), graphic.FileType)
+		dstPath := infrastructure.StorageObjectFilePath("Graphic", strconv.FormatInt(newGraphic.ID, 10), graphic.FileType)
+		if err := copyFile(ctx, bucketName, srcPath, dstPath); err != nil {
+			return err
+		}
+	}
+
+	for _, speech := range material.Speeches {
+		if _, ok := idMaps[LessonAssetVoice][speech.VoiceID]; ok || speech.VoiceID == 0 {
+			continue
+		}
+
+		voice, err := repositories.Voices.Get(ctx, original.ID, speech.VoiceID)
+		if err == ErrNoSuchEntity {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		newVoice := voice
+		newVoice.ID = 0
+		newVoice.UserID = user.ID
+		if err := CreateVoice(ctx, lessonID, &newVoice); err != nil {
+			return err
+		}
+		idMaps[LessonAssetVoice][voice.ID] = newVoice.ID
+
+		srcPath := fmt.Sprintf("voice/%d/%d.mp3", original.ID, voice.ID)
+		dstPath := fmt.Sprintf("voice/%d/%d.mp3", lessonID, newVoice.ID)
+		if err := copyFile(ctx, bucketName, srcPath, dstPath); err != nil {
+			return err
+		}
+	}
+
+	if user.ID == original.UserID {
+		return nil // the avatar and musics of the author are available to the author.
+	}
+
+	for _, music := range material.Musics {
+		if _, ok := idMaps[LessonAssetBGM][music.BackgroundMusicID]; ok || music.BackgroundMusicID == 0 {
+			continue
+		}
+
+		backgroundMusic, err := repositories.BackgroundMusics.Get(ctx, original.UserID, music.BackgroundMusicID)
+		if err == ErrNoSuchEntity || (err == nil && backgroundMusic.IsPublic) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		newMusic := BackgroundMusic{Name: backgroundMusic.Name}
+		if err := CreateBackgroundMusic(ctx, user.ID, &newMusic); err != nil {
+			return err
+		}
+		idMaps[LessonAssetBGM][backgroundMusic.ID] = newMusic.ID
+
+		srcPath := infrastructure.StorageObjectFilePath("bgm", strconv.FormatInt(backgroundMusic.ID, 10), "mp3")
+		dstPath := infrastructure.StorageObjectFilePath("bgm", strconv.FormatInt(newMusic.ID, 10), "mp3")
+		if err := copyFile(ctx, bucketName, srcPath, dstPath); err != nil {
+			return err
+		}
+	}
+
+	if material.AvatarID == 0 {
+		return nil
+	}
+
+	avatar, err := repositories.Avatars.Get(ctx, original.UserID, material.AvatarID)
+	if err == ErrNoSuchEntity {
+		return nil // the public avatar.
+	} else if err != nil {
+		return err
+	}
+
+	newAvatar := Avatar{Name: avatar.Name, Config: avatar.Config, Version: avatar.Version}
+	if err := CreateAvatar(ctx, &newAvatar, &user); err != nil {
+		return err
+	}
+	idMaps[LessonAssetAvatar][avatar.ID] = newAvatar.ID
+
+	srcPath := infrastructure.StorageObjectFilePath("Avatar", strconv.FormatInt(avatar.ID, 10), "vrm")
+	dstPath := infrastructure.StorageObjectFilePath("Avatar", strconv.FormatInt(newAvatar.ID, 10), "vrm")
+	return copyFile(ctx, bucketName, srcPath, dstPath)
+}
+
+// copyFile copies the object in the bucket on the storage, the object not found is skipped.
+func copyFile(ctx context.Context, bucketName, srcPath, dstPath string) error {
+	return ignoreBlobNotExist(infrastructure.CopyFile(ctx, bucketName, srcPath, dstPath))
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+func TestDuplicateLessonCopiesPrivateAssetsForOtherUser(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	original, _ := createPackedLesson(t)
+	originalMaterial, err := repositories.LessonMaterials.Get(ctx, original.ID, original.MaterialID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lesson, err := DuplicateLesson(ctx, User{ID: 2}, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetLessonByID(ctx, lesson.ID)
+	if err != nil {
+		t.Fatalf("the duplicated lesson is not found: %v", err)
+	}
+	if got.ForkedFromLessonID != original.ID || got.UserID != 2 {
+		t.Errorf("unexpected lesson: %+v", got)
+	}
+
+	material, err := repositories.LessonMaterials.Get(ctx, lesson.ID, got.MaterialID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repositories.Avatars.Get(ctx, 2, material.AvatarID); err != nil {
+		t.Errorf("the private avatar is not copied: %v", err)
+	}
+	musicID := material.Musics[0].BackgroundMusicID
+	if _, err := repositories.BackgroundMusics.Get(ctx, 2, musicID); err != nil {
+		t.Errorf("the private BGM is not copied: %v", err)
+	}
+	if material.Graphics[0].GraphicID == originalMaterial.Graphics[0].GraphicID {
+		t.Error("the graphic is not copied")
+	}
+
+	bucketName := infrastructure.MaterialBucketName()
+	musicPath := infrastructure.StorageObjectFilePath("bgm", strconv.FormatInt(musicID, 10), "mp3")
+	if contents, err := infrastructure.GetFile(ctx, bucketName, musicPath); err != nil || string(contents) != "bgm" {
+		t.Errorf("the BGM file is not copied: %q %v", contents, err)
+	}
+}
+
+func TestDuplicateLessonSharesAssetsNotCopied(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	original, _ := createPackedLesson(t)
+	originalMaterial, err := repositories.LessonMaterials.Get(ctx, original.ID, original.MaterialID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the author's own avatar and BGM are available to the author, so they are kept.
+	lesson, err := DuplicateLesson(ctx, User{ID: original.UserID}, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	material, err := repositories.LessonMaterials.Get(ctx, lesson.ID, lesson.MaterialID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if material.AvatarID != originalMaterial.AvatarID || material.Musics[0].BackgroundMusicID != originalMaterial.Musics[0].BackgroundMusicID {
+		t.Errorf("the shared assets are remapped: avatar %d, BGM %d", material.AvatarID, material.Musics[0].BackgroundMusicID)
+	}
+	if material.Speeches[0].VoiceID == originalMaterial.Speeches[0].VoiceID {
+		t.Error("the voice is not copied")
+	}
+}
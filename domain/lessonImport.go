@@ -0,0 +1,405 @@
+package domain
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+// maxLessonPackFileSize limits the size of each file extracted from the package.
+const maxLessonPackFileSize = 200 << 20
+
+// maxLessonPackTotalSize limits the total size of the files extracted from the package, so that the small zip can't expand without limit.
+// the sizes in the zip are trusted, because the reader of zip fails when the file is larger than its size.
+const maxLessonPackTotalSize = 1 << 30
+
+type LessonImportErrorCode uint
+
+const (
+	InvalidLessonPack            LessonImportErrorCode = 1
+	UnsupportedLessonPackVersion LessonImportErrorCode = 2
+)
+
+func (e LessonImportErrorCode) Error() string {
+	switch e {
+	case InvalidLessonPack:
+		return "invalid lesson package"
+	case UnsupportedLessonPackVersion:
+		return "unsupported version of lesson package"
+	default:
+		return "unknown lesson import error"
+	}
+}
+
+// lessonPack is the package read from the zip, the assets are read when they are uploaded.
+type lessonPack struct {
+	manifest LessonPackManifest
+	content  LessonPackContent
+	files    map[string]*zip.File // by the path of asset.
+}
+
+// ImportLesson creates the new lesson owned by user from the package created by PackLesson, r is the zip of size bytes.
+// the assets are created as user's entities, and all IDs in the material are remapped to them.
+// the lesson is created as being deleted at first and the assets are uploaded one by one outside of the transaction,
+// then the material and the lesson are saved in the transaction. when the import fails, the things created are deleted,
+// and the lesson left by the failed cleanup is hidden and deleted same as the failed deletion.
+func ImportLesson(ctx context.Context, user User, r io.ReaderAt, size int64) (Lesson, error) {
+	pack, err := readLessonPack(r, size)
+	if err != nil {
+		return Lesson{}, err
+	}
+
+	lesson := importedLesson(pack.content.Lesson, user.ID)
+	lesson.IsDeleting = true
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		return Lesson{}, err
+	}
+
+	idMaps := newLessonAssetIDMaps()
+	if err := createImportedAssets(ctx, user, lesson.ID, pack, idMaps); err != nil {
+		deleteImportedLesson(ctx, user, lesson, idMaps)
+		return Lesson{}, err
+	}
+
+	material := pack.content.Material
+	material.UserID = user.ID
+	remapLessonMaterialIDs(&material, idMaps.imported)
+
+	var imported Lesson
+	err = repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		material.ID = 0
+		if err := CreateLessonMaterial(ctx, lesson.ID, &material); err != nil {
+			return err
+		}
+
+		imported = lesson
+		imported.MaterialID = material.ID
+		imported.IsDeleting = false
+		return UpdateLesson(ctx, &imported)
+	})
+	if err != nil {
+		deleteImportedLesson(ctx, user, lesson, idMaps)
+		return Lesson{}, err
+	}
+
+	return imported, nil
+}
+
+// deleteImportedLesson deletes the lesson with its children and the assets of user created by the failed import.
+// the errors are ignored, so that the error of import is returned.
+func deleteImportedLesson(ctx context.Context, user User, lesson Lesson, idMaps lessonAssetIDMaps) {
+	bucketName := infrastructure.MaterialBucketName()
+
+	for _, id := range idMaps[LessonAssetBGM] {
+		infrastructure.DeleteFile(ctx, bucketName, infrastructure.StorageObjectFilePath("bgm", fmt.Sprint(id), "mp3"))
+		repositories.BackgroundMusics.Delete(ctx, user.ID, id)
+	}
+
+	for _, id := range idMaps[LessonAssetAvatar] {
+		infrastructure.DeleteFile(ctx, bucketName, infrastructure.StorageObjectFilePath("Avatar", fmt.Sprint(id), "vrm"))
+		repositories.Avatars.Delete(ctx, user.ID, id)
+	}
+
+	DeleteLesson(ctx, lesson)
+}
+
+// readLessonPack reads the manifest and the lesson, and checks the assets are in the zip within the size limits.
+func readLessonPack(r io.ReaderAt, size int64) (lessonPack, error) {
+	var pack lessonPack
+
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return pack, InvalidLessonPack
+	}
+
+	zipFiles := make(map[string]*zip.File)
+	for _, f := range zipReader.File {
+		zipFiles[f.Name] = f
+	}
+
+	var totalSize uint64
+	addSize := func(f *zip.File) error {
+		if f == nil || f.UncompressedSize64 > maxLessonPackFileSize {
+			return InvalidLessonPack
+		}
+		if totalSize += f.UncompressedSize64; totalSize > maxLessonPackTotalSize {
+			return InvalidLessonPack
+		}
+		return nil
+	}
+
+	if err := readZipJSON(zipFiles[lessonPackManifestPath], &pack.manifest); err != nil {
+		return pack, err
+	}
+
+	if pack.manifest.Version < 1 || pack.manifest.Version > LessonPackVersion {
+		return pack, UnsupportedLessonPackVersion
+	}
+
+	if err := readZipJSON(zipFiles[lessonPackLessonPath], &pack.content); err != nil {
+		return pack, err
+	}
+
+	for _, path := range []string{lessonPackManifestPath, lessonPackLessonPath} {
+		if err := addSize(zipFiles[path]); err != nil {
+			return pack, err
+		}
+	}
+
+	pack.files = make(map[string]*zip.File)
+	for _, asset := range pack.manifest.Assets {
+		f := zipFiles[asset.Path]
+		if err := addSize(f); err != nil {
+			return pack, err
+		}
+		pack.files[asset.Path] = f
+	}
+
+	if !pack.hasAllAssets() {
+		return pack, InvalidLessonPack
+	}
+
+	return pack, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f == nil || f.UncompressedSize64 > maxLessonPackFileSize {
+		return nil, InvalidLessonPack
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, InvalidLessonPack
+	}
+	defer rc.Close()
+
+	contents, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, InvalidLessonPack
+	}
+
+	return contents, nil
+}
+
+func readZipJSON(f *zip.File, v interface{}) error {
+	contents, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(contents, v); err != nil {
+		return InvalidLessonPack
+	}
+
+	return nil
+}
+
+// lessonPackAssetReader reads the asset from the zip, and returns InvalidLessonPack instead of EOF when the contents don't match the hash in the manifest.
+// so the file is not created from the broken asset.
+type lessonPackAssetReader struct {
+	r      io.Reader
+	hash   hash.Hash
+	sha256 string
+}
+
+func (r *lessonPackAssetReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.hash.Write(p[:n])
+
+	if err == io.EOF {
+		if r.sha256 != "" && hex.EncodeToString(r.hash.Sum(nil)) != r.sha256 {
+			return n, InvalidLessonPack
+		}
+		return n, io.EOF
+	}
+	if err != nil {
+		return n, InvalidLessonPack
+	}
+
+	return n, nil
+}
+
+func uploadLessonPackAsset(ctx context.Context, bucketName, filePath, contentType string, f *zip.File, asset LessonAsset) error {
+	rc, err := f.Open()
+	if err != nil {
+		return InvalidLessonPack
+	}
+	defer rc.Close()
+
+	r := &lessonPackAssetReader{r: rc, hash: sha256.New(), sha256: asset.SHA256}
+	return infrastructure.CreateFileFromReader(ctx, bucketName, filePath, contentType, r)
+}
+
+// hasAllAssets returns true when all IDs referenced from the material are in the manifest.
+func (p lessonPack) hasAllAssets() bool {
+	included := make(map[string]bool)
+	for _, asset := range p.manifest.Assets {
+		included[fmt.Sprintf("%s/%d", asset.Kind, asset.ID)] = true
+	}
+
+	has := func(kind string, id int64) bool {
+		return id == 0 || included[fmt.Sprintf("%s/%d", kind, id)]
+	}
+
+	material := p.content.Material
+	for _, graphic := range material.Graphics {
+		if !has(LessonAssetGraphic, graphic.GraphicID) {
+			return false
+		}
+	}
+	for _, speech := range material.Speeches {
+		if !has(LessonAssetVoice, speech.VoiceID) {
+			return false
+		}
+	}
+	for _, music := range material.Musics {
+		if !has(LessonAssetBGM, music.BackgroundMusicID) {
+			return false
+		}
+	}
+
+	return has(LessonAssetAvatar, material.AvatarID)
+}
+
+// importedLesson returns the copy of lesson as the new draft, the relations to the other lessons and the stats are not copied.
+func importedLesson(original Lesson, userID int64) Lesson {
+	return Lesson{
+		UserID:               userID,
+		NeedsRecording:       original.NeedsRecording,
+		IsEdited:             original.IsEdited,
+		IsIntroduction:       original.IsIntroduction,
+		References:           original.References,
+		SubjectID:            original.SubjectID,
+		SubjectName:          original.SubjectName,
+		JapaneseCategoryID:   original.JapaneseCategoryID,
+		JapaneseCategoryName: original.JapaneseCategoryName,
+		Title:                original.Title,
+		Description:          original.Description,
+		DurationSec:          original.DurationSec,
+	}
+}
+
+// lessonAssetIDMaps maps the IDs in the package to the IDs of the created entities by kind of asset.
+type lessonAssetIDMaps map[string]map[int64]int64
+
+func newLessonAssetIDMaps() lessonAssetIDMaps {
+	return lessonAssetIDMaps{
+		LessonAssetGraphic: make(map[int64]int64),
+		LessonAssetVoice:   make(map[int64]int64),
+		LessonAssetBGM:     make(map[int64]int64),
+		LessonAssetAvatar:  make(map[int64]int64),
+	}
+}
+
+// createImportedAssets creates the entity and uploads the file of each asset, idMaps has the entities created even if it fails on the way.
+func createImportedAssets(ctx context.Context, user User, lessonID int64, pack lessonPack, idMaps lessonAssetIDMaps) error {
+	speeches := make(map[int64]LessonSpeech)
+	for _, speech := range pack.content.Material.Speeches {
+		if _, ok := speeches[speech.VoiceID]; !ok {
+			speeches[speech.VoiceID] = speech
+		}
+	}
+
+	bucketName := infrastructure.MaterialBucketName()
+
+	for _, asset := range pack.manifest.Assets {
+		var filePath, contentType string
+
+		switch asset.Kind {
+		case LessonAssetGraphic:
+			extension := strings.ToLower(strings.TrimPrefix(path.Ext(asset.Path), "."))
+			if contentType = fileContentType(extension); !strings.HasPrefix(contentType, "image/") {
+				return InvalidLessonPack
+			}
+			graphic := &Graphic{LessonID: lessonID, FileType: extension}
+			if err := CreateGraphics(ctx, user.ID, []*Graphic{graphic}); err != nil {
+				return err
+			}
+			idMaps[asset.Kind][asset.ID] = graphic.ID
+			filePath = infrastructure.StorageObjectFilePath("Graphic", fmt.Sprint(graphic.ID), extension)
+
+		case LessonAssetVoice:
+			speech := speeches[asset.ID]
+			voice := Voice{
+				UserID:      user.ID,
+				ElapsedTime: speech.ElapsedTime,
+				DurationSec: speech.DurationSec,
+				Text:        speech.Subtitle,
+				IsTexted:    speech.Subtitle != "",
+				IsSynthesis: speech.IsSynthesis,
+			}
+			if err := CreateVoice(ctx, lessonID, &voice); err != nil {
+				return err
+			}
+			idMaps[asset.Kind][asset.ID] = voice.ID
+			filePath = fmt.Sprintf("voice/%d/%d.mp3", lessonID, voice.ID)
+			contentType = fileContentType("mp3")
+
+		case LessonAssetBGM:
+			music := BackgroundMusic{Name: fmt.Sprintf("BGM %d", asset.ID)}
+			if err := CreateBackgroundMusic(ctx, user.ID, &music); err != nil {
+				return err
+			}
+			idMaps[asset.Kind][asset.ID] = music.ID
+			filePath = infrastructure.StorageObjectFilePath("bgm", fmt.Sprint(music.ID), "mp3")
+			contentType = fileContentType("mp3")
+
+		case LessonAssetAvatar:
+			avatar := Avatar{Name: fmt.Sprintf("Avatar %d", asset.ID)}
+			if err := CreateAvatar(ctx, &avatar, &user); err != nil {
+				return err
+			}
+			idMaps[asset.Kind][asset.ID] = avatar.ID
+			filePath = infrastructure.StorageObjectFilePath("Avatar", fmt.Sprint(avatar.ID), "vrm")
+			contentType = fileContentType("vrm")
+
+		default:
+			continue // the kind of asset added in the future, it is not referenced from the material of this version.
+		}
+
+		if err := uploadLessonPackAsset(ctx, bucketName, filePath, contentType, pack.files[asset.Path], asset); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// remapLessonMaterialIDs replaces IDs in the material with the IDs returned from remap by kind of asset.
+func remapLessonMaterialIDs(material *LessonMaterial, remap func(kind string, id int64) int64) {
+	material.AvatarID = remap(LessonAssetAvatar, material.AvatarID)
+	material.Avatar = Avatar{}
+
+	for i := range material.Graphics {
+		material.Graphics[i].GraphicID = remap(LessonAssetGraphic, material.Graphics[i].GraphicID)
+	}
+	for i := range material.Speeches {
+		material.Speeches[i].VoiceID = remap(LessonAssetVoice, material.Speeches[i].VoiceID)
+	}
+	for i := range material.Musics {
+		material.Musics[i].BackgroundMusicID = remap(LessonAssetBGM, material.Musics[i].BackgroundMusicID)
+	}
+}
+
+// imported returns the ID of the asset created from the package, the IDs not in the package become 0, because they are of the other service.
+func (m lessonAssetIDMaps) imported(kind string, id int64) int64 {
+	return m[kind][id]
+}
+
+// duplicated returns the ID of the asset copied, the IDs not copied are kept, because they reference the assets shared with the original, e.g. public BGMs.
+func (m lessonAssetIDMaps) duplicated(kind string, id int64) int64 {
+	if newID, ok := m[kind][id]; ok {
+		return newID
+	}
+	return id
+}
@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strconv"
+	"testing"
+
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+// createPackedLesson creates the lesson of user 1 with a graphic, a voice, a private BGM and a private avatar, and returns the zip of it.
+func createPackedLesson(t *testing.T) (Lesson, []byte) {
+	ctx := context.Background()
+	bucketName := infrastructure.MaterialBucketName()
+	user := User{ID: 1}
+
+	lesson := Lesson{UserID: user.ID, Title: "packed"}
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+
+	graphic := &Graphic{LessonID: lesson.ID, FileType: "png"}
+	voice := Voice{UserID: user.ID}
+	music := BackgroundMusic{Name: "private"}
+	avatar := Avatar{Name: "private"}
+	if err := CreateGraphics(ctx, user.ID, []*Graphic{graphic}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateVoice(ctx, lesson.ID, &voice); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateBackgroundMusic(ctx, user.ID, &music); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateAvatar(ctx, &avatar, &user); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		infrastructure.StorageObjectFilePath("Graphic", strconv.FormatInt(graphic.ID, 10), "png"):    "graphic",
+		"voice/" + strconv.FormatInt(lesson.ID, 10) + "/" + strconv.FormatInt(voice.ID, 10) + ".mp3": "voice",
+		infrastructure.StorageObjectFilePath("bgm", strconv.FormatInt(music.ID, 10), "mp3"):          "bgm",
+		infrastructure.StorageObjectFilePath("Avatar", strconv.FormatInt(avatar.ID, 10), "vrm"):      "avatar",
+	}
+	for filePath, contents := range files {
+		if err := infrastructure.CreateFile(ctx, bucketName, filePath, "", []byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	material := LessonMaterial{
+		UserID:   user.ID,
+		AvatarID: avatar.ID,
+		Graphics: []LessonGraphic{{GraphicID: graphic.ID, Action: GraphicActionShow}},
+		Speeches: []LessonSpeech{{VoiceID: voice.ID, Subtitle: "hello"}},
+		Musics:   []LessonMusic{{BackgroundMusicID: music.ID, Action: MusicActionStart}},
+	}
+	if err := CreateLessonMaterial(ctx, lesson.ID, &material); err != nil {
+		t.Fatal(err)
+	}
+	lesson.MaterialID = material.ID
+
+	var zipBuffer bytes.Buffer
+	if err := WriteLessonZip(ctx, &zipBuffer, lesson, material); err != nil {
+		t.Fatal(err)
+	}
+
+	return lesson, zipBuffer.Bytes()
+}
+
+// replaceZipFile returns the copy of zip whose file at the path is replaced with the contents.
+func replaceZipFile(t *testing.T, data []byte, filePath string, contents []byte) []byte {
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	for _, f := range zipReader.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		original, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w, err := zipWriter.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == filePath {
+			original = contents
+		}
+		if _, err := w.Write(original); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestImportLesson(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	original, data := createPackedLesson(t)
+
+	lesson, err := ImportLesson(ctx, User{ID: 2}, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetLessonByID(ctx, lesson.ID)
+	if err != nil {
+		t.Fatalf("the imported lesson is not found: %v", err)
+	}
+	if got.ID == original.ID || got.UserID != 2 || got.Title != "packed" || got.MaterialID == 0 {
+		t.Errorf("unexpected lesson: %+v", got)
+	}
+
+	material, err := repositories.LessonMaterials.Get(ctx, lesson.ID, got.MaterialID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bucketName := infrastructure.MaterialBucketName()
+	voicePath := "voice/" + strconv.FormatInt(lesson.ID, 10) + "/" + strconv.FormatInt(material.Speeches[0].VoiceID, 10) + ".mp3"
+	if contents, err := infrastructure.GetFile(ctx, bucketName, voicePath); err != nil || string(contents) != "voice" {
+		t.Errorf("the voice is not uploaded: %q %v", contents, err)
+	}
+	if _, err := repositories.Avatars.Get(ctx, 2, material.AvatarID); err != nil {
+		t.Errorf("the avatar is not created for the user: %v", err)
+	}
+	if _, err := repositories.Graphics.Get(ctx, 2, material.Graphics[0].GraphicID); err != nil {
+		t.Errorf("the graphic is not created for the user: %v", err)
+	}
+}
+
+func TestImportLessonDeletesAssetsOnFailure(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	_, data := createPackedLesson(t)
+
+	bucketName := infrastructure.MaterialBucketName()
+	before, err := infrastructure.ListFiles(ctx, bucketName, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the avatar is created last, so the other assets are created before the hash mismatch.
+	var manifest LessonPackManifest
+	zipReader, _ := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	for _, f := range zipReader.File {
+		if f.Name == lessonPackManifestPath {
+			if err := readZipJSON(f, &manifest); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	avatarPath := manifest.Assets[len(manifest.Assets)-1].Path
+	broken := replaceZipFile(t, data, avatarPath, []byte("broken"))
+
+	if _, err := ImportLesson(ctx, User{ID: 2}, bytes.NewReader(broken), int64(len(broken))); err != InvalidLessonPack {
+		t.Fatalf("got %v, want InvalidLessonPack", err)
+	}
+
+	if lessons, _, _ := repositories.Lessons.ListByUserID(ctx, 2, Page{}); len(lessons) != 0 {
+		t.Errorf("the lesson is left: %v", lessons)
+	}
+	if musics, _, _ := repositories.BackgroundMusics.ListByUserID(ctx, 2, Page{}); len(musics) != 0 {
+		t.Errorf("the BGM is left: %v", musics)
+	}
+	if avatars, _, _ := repositories.Avatars.ListByUserID(ctx, 2, Page{}); len(avatars) != 0 {
+		t.Errorf("the avatar is left: %v", avatars)
+	}
+	if after, _ := infrastructure.ListFiles(ctx, bucketName, ""); len(after) != len(before) {
+		t.Errorf("the files are left: %v", after)
+	}
+}
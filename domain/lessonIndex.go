@@ -0,0 +1,303 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"cloud.google.com/go/datastore"
+)
+
+const (
+	defaultLessonSearchLimit = 20
+	maxLessonSearchLimit     = 100
+	maxLessonDocumentTokens  = 2000 // Datastore allows up to 20,000 index entries per entity.
+	maxLessonQueryTokens     = 10
+)
+
+// LessonIndex is the search index of public lessons.
+type LessonIndex interface {
+	Put(ctx context.Context, document LessonDocument) error
+	Delete(ctx context.Context, lessonID int64) error
+	// Search returns IDs of lessons matched to conditions in sort order from the cursor, and the cursor of next page.
+	Search(ctx context.Context, conditions LessonSearchConditions) ([]int64, string, error)
+}
+
+// LessonDocument is the searchable fields of public lesson.
+type LessonDocument struct {
+	LessonID           int64
+	UserID             int64
+	SubjectID          int64
+	JapaneseCategoryID int64
+	Title              string
+	Description        string
+	Subtitles          []string
+	ViewCount          int64
+	Published          time.Time
+}
+
+// LessonSearchConditions is used for searching lessons. zero value fields are ignored.
+type LessonSearchConditions struct {
+	SubjectID          int64
+	JapaneseCategoryID int64
+	UserID             int64
+	Keywords           string
+	SortOrder          LessonSortOrder
+	Limit              int
+	Cursor             string // the next cursor of the previous page, blank for the first page.
+}
+
+var lessonIndex LessonIndex
+
+// SetLessonIndex replaces the index used for searching lessons. this must be called before handling requests.
+func SetLessonIndex(index LessonIndex) {
+	lessonIndex = index
+}
+
+// SearchLessonIDs returns the page of IDs of public lessons matched to conditions, and the cursor of next page.
+func SearchLessonIDs(ctx context.Context, conditions LessonSearchConditions) ([]int64, string, error) {
+	conditions.Limit = lessonSearchLimit(conditions.Limit)
+
+	return lessonIndex.Search(ctx, conditions)
+}
+
+// SearchPublicLessons returns the page of public lessons matched to conditions, and the cursor of next page.
+// the index may be older than the lessons, so the lessons not public any more are skipped and the page is filled with the following ones.
+func SearchPublicLessons(ctx context.Context, conditions LessonSearchConditions) ([]Lesson, string, error) {
+	limit := lessonSearchLimit(conditions.Limit)
+	lessons := []Lesson{}
+
+	for {
+		conditions.Limit = limit - len(lessons)
+		ids, nextCursor, err := SearchLessonIDs(ctx, conditions)
+		if err != nil {
+			return nil, "", err
+		}
+
+		found, err := GetLessonsByIDs(ctx, ids)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, lesson := range found {
+			if lesson.Status == LessonStatusPublic {
+				lessons = append(lessons, lesson)
+			}
+		}
+
+		if nextCursor == "" || len(lessons) >= limit {
+			return lessons, nextCursor, nil
+		}
+		conditions.Cursor = nextCursor
+	}
+}
+
+func lessonSearchLimit(limit int) int {
+	if limit <= 0 {
+		return defaultLessonSearchLimit
+	} else if limit > maxLessonSearchLimit {
+		return maxLessonSearchLimit
+	}
+	return limit
+}
+
+// IndexLesson puts the lesson to the search index when it is public, otherwise removes it from the index.
+func IndexLesson(ctx context.Context, lesson Lesson) error {
+	if lesson.Status != LessonStatusPublic {
+		return lessonIndex.Delete(ctx, lesson.ID)
+	}
+
+	document := LessonDocument{
+		LessonID:           lesson.ID,
+		UserID:             lesson.UserID,
+		SubjectID:          lesson.SubjectID,
+		JapaneseCategoryID: lesson.JapaneseCategoryID,
+		Title:              lesson.Title,
+		Description:        lesson.Description,
+		ViewCount:          lesson.ViewCount,
+		Published:          lesson.Published,
+	}
+
+	if lesson.MaterialID != 0 {
+		var lessonMaterial LessonMaterial
+		if err := GetLessonMaterial(ctx, lesson.MaterialID, lesson.ID, &lessonMaterial); err != nil && err != ErrNoSuchEntity {
+			return err
+		}
+
+		for _, speech := range lessonMaterial.Speeches {
+			if speech.Subtitle != "" {
+				document.Subtitles = append(document.Subtitles, speech.Subtitle)
+			}
+		}
+	}
+
+	return lessonIndex.Put(ctx, document)
+}
+
+// searchTokens splits text to words for the index.
+// Japanese text has no spaces between words, so the runs of non latin characters are split to bi-grams.
+func searchTokens(texts ...string) []string {
+	var tokens []string
+	found := make(map[string]bool)
+
+	add := func(token string) {
+		if token == "" || found[token] {
+			return
+		}
+		found[token] = true
+		tokens = append(tokens, token)
+	}
+
+	for _, text := range texts {
+		for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		}) {
+			for _, run := range splitByScript(word) {
+				if isLatinWord(run) || utf8.RuneCountInString(run) == 1 {
+					add(run)
+					continue
+				}
+
+				runes := []rune(run)
+				for i := 0; i < len(runes)-1; i++ {
+					add(string(runes[i : i+2]))
+				}
+			}
+		}
+	}
+
+	return tokens
+}
+
+// splitByScript splits word to runs of latin characters and the others. e.g. "go言語" to "go" and "言語".
+func splitByScript(word string) []string {
+	var runs []string
+	var current []rune
+	for _, r := range word {
+		if len(current) > 0 && isLatinRune(current[0]) != isLatinRune(r) {
+			runs = append(runs, string(current))
+			current = nil
+		}
+		current = append(current, r)
+	}
+
+	if len(current) > 0 {
+		runs = append(runs, string(current))
+	}
+
+	return runs
+}
+
+func isLatinWord(word string) bool {
+	for _, r := range word {
+		if !isLatinRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isLatinRune(r rune) bool {
+	return r < utf8.RuneSelf || unicode.In(r, unicode.Latin)
+}
+
+func (d LessonDocument) tokens() []string {
+	texts := append([]string{d.Title, d.Description}, d.Subtitles...)
+	tokens := searchTokens(texts...)
+	if len(tokens) > maxLessonDocumentTokens {
+		tokens = tokens[:maxLessonDocumentTokens]
+	}
+	return tokens
+}
+
+// lessonIndexEntity is stored in Datastore as the inverted index.
+type lessonIndexEntity struct {
+	UserID             int64
+	SubjectID          int64
+	JapaneseCategoryID int64
+	Tokens             []string
+	ViewCount          int64
+	Published          time.Time
+}
+
+// datastoreLessonIndex searches lessons with the list property of words.
+// composite indexes in index.yaml are required for sorting.
+type datastoreLessonIndex struct {
+	client *datastore.Client
+}
+
+// NewDatastoreLessonIndex returns the LessonIndex stored in Datastore.
+func NewDatastoreLessonIndex(client *datastore.Client) LessonIndex {
+	return datastoreLessonIndex{client: client}
+}
+
+func (i datastoreLessonIndex) Put(ctx context.Context, document LessonDocument) error {
+	entity := lessonIndexEntity{
+		UserID:             document.UserID,
+		SubjectID:          document.SubjectID,
+		JapaneseCategoryID: document.JapaneseCategoryID,
+		Tokens:             document.tokens(),
+		ViewCount:          document.ViewCount,
+		Published:          document.Published,
+	}
+
+	key := datastore.IDKey("LessonIndex", document.LessonID, nil)
+	if _, err := i.client.Put(ctx, key, &entity); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (i datastoreLessonIndex) Delete(ctx context.Context, lessonID int64) error {
+	key := datastore.IDKey("LessonIndex", lessonID, nil)
+	if err := i.client.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (i datastoreLessonIndex) Search(ctx context.Context, conditions LessonSearchConditions) ([]int64, string, error) {
+	query := datastore.NewQuery("LessonIndex").KeysOnly()
+
+	if conditions.SubjectID != 0 {
+		query = query.Filter("SubjectID =", conditions.SubjectID)
+	}
+	if conditions.JapaneseCategoryID != 0 {
+		query = query.Filter("JapaneseCategoryID =", conditions.JapaneseCategoryID)
+	}
+	if conditions.UserID != 0 {
+		query = query.Filter("UserID =", conditions.UserID)
+	}
+
+	tokens := searchTokens(conditions.Keywords)
+	if len(tokens) > maxLessonQueryTokens {
+		tokens = tokens[:maxLessonQueryTokens]
+	}
+	for _, token := range tokens {
+		query = query.Filter("Tokens =", token)
+	}
+
+	switch conditions.SortOrder {
+	case LessonSortOrderPopular:
+		query = query.Order("-ViewCount")
+	default:
+		query = query.Order("-Published")
+	}
+
+	var entities []lessonIndexEntity // not loaded, because the query is keys only.
+	keys, nextCursor, err := getPage(ctx, i.client, query, Page{Limit: conditions.Limit, Cursor: conditions.Cursor}, &entities)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ids := make([]int64, len(keys))
+	for n, key := range keys {
+		ids[n] = key.ID
+	}
+
+	return ids, nextCursor, nil
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryLessonIndex is the LessonIndex on memory for tests and local development.
+type MemoryLessonIndex struct {
+	mu        sync.RWMutex
+	documents map[int64]memoryLessonDocument
+}
+
+type memoryLessonDocument struct {
+	LessonDocument
+	tokens map[string]bool
+}
+
+// NewMemoryLessonIndex returns the empty index.
+func NewMemoryLessonIndex() *MemoryLessonIndex {
+	return &MemoryLessonIndex{documents: make(map[int64]memoryLessonDocument)}
+}
+
+func (m *MemoryLessonIndex) Put(ctx context.Context, document LessonDocument) error {
+	tokens := make(map[string]bool)
+	for _, token := range document.tokens() {
+		tokens[token] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.documents[document.LessonID] = memoryLessonDocument{LessonDocument: document, tokens: tokens}
+
+	return nil
+}
+
+func (m *MemoryLessonIndex) Delete(ctx context.Context, lessonID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.documents, lessonID)
+
+	return nil
+}
+
+func (m *MemoryLessonIndex) Search(ctx context.Context, conditions LessonSearchConditions) ([]int64, string, error) {
+	queryTokens := searchTokens(conditions.Keywords)
+
+	m.mu.RLock()
+	var matched []LessonDocument
+	for _, document := range m.documents {
+		if document.matches(conditions, queryTokens) {
+			matched = append(matched, document.LessonDocument)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		if conditions.SortOrder == LessonSortOrderPopular && matched[i].ViewCount != matched[j].ViewCount {
+			return matched[i].ViewCount > matched[j].ViewCount
+		}
+		if !matched[i].Published.Equal(matched[j].Published) {
+			return matched[i].Published.After(matched[j].Published)
+		}
+		return matched[i].LessonID > matched[j].LessonID
+	})
+
+	start, end, nextCursor, err := memoryPage(len(matched), Page{Limit: conditions.Limit, Cursor: conditions.Cursor})
+	if err != nil {
+		return nil, "", err
+	}
+
+	ids := make([]int64, 0, end-start)
+	for _, document := range matched[start:end] {
+		ids = append(ids, document.LessonID)
+	}
+
+	return ids, nextCursor, nil
+}
+
+func (d memoryLessonDocument) matches(conditions LessonSearchConditions, queryTokens []string) bool {
+	if conditions.SubjectID != 0 && d.SubjectID != conditions.SubjectID {
+		return false
+	}
+	if conditions.JapaneseCategoryID != 0 && d.JapaneseCategoryID != conditions.JapaneseCategoryID {
+		return false
+	}
+	if conditions.UserID != 0 && d.UserID != conditions.UserID {
+		return false
+	}
+
+	for _, token := range queryTokens {
+		if !d.tokens[token] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSearchTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Go Language", []string{"go", "language"}},
+		{"go言語入門", []string{"go", "言語", "語入", "入門"}},
+		{"数", []string{"数"}},
+		{"a, a. b!", []string{"a", "b"}},
+	}
+
+	for _, test := range tests {
+		if got := searchTokens(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("searchTokens(%q) = %v, want %v", test.text, got, test.want)
+		}
+	}
+}
+
+func TestMemoryLessonIndexSearch(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryLessonIndex()
+	SetLessonIndex(index)
+
+	published := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	documents := []LessonDocument{
+		{LessonID: 1, SubjectID: 1, Title: "二次関数の基礎", ViewCount: 10, Published: published},
+		{LessonID: 2, SubjectID: 1, Title: "一次関数", ViewCount: 30, Published: published.Add(time.Hour)},
+		{LessonID: 3, SubjectID: 2, Title: "英文法", Subtitles: []string{"関数ではない"}, ViewCount: 20, Published: published.Add(2 * time.Hour)},
+	}
+	for _, document := range documents {
+		if err := index.Put(ctx, document); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		conditions LessonSearchConditions
+		want       []int64
+	}{
+		{"newest first", LessonSearchConditions{}, []int64{3, 2, 1}},
+		{"popular", LessonSearchConditions{SortOrder: LessonSortOrderPopular}, []int64{2, 3, 1}},
+		{"subject", LessonSearchConditions{SubjectID: 1}, []int64{2, 1}},
+		{"keywords in subtitles", LessonSearchConditions{Keywords: "関数"}, []int64{3, 2, 1}},
+		{"all keywords", LessonSearchConditions{Keywords: "二次 関数"}, []int64{1}},
+		{"no match", LessonSearchConditions{Keywords: "物理"}, []int64{}},
+	}
+
+	for _, test := range tests {
+		ids, nextCursor, err := SearchLessonIDs(ctx, test.conditions)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(ids, test.want) || nextCursor != "" {
+			t.Errorf("%s: got %v %q, want %v", test.name, ids, nextCursor, test.want)
+		}
+	}
+
+	if err := index.Delete(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _, _ := SearchLessonIDs(ctx, LessonSearchConditions{SubjectID: 1}); !reflect.DeepEqual(ids, []int64{1}) {
+		t.Errorf("deleted lesson found: %v", ids)
+	}
+}
+
+func TestMemoryLessonIndexSearchPages(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryLessonIndex()
+	SetLessonIndex(index)
+
+	published := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	for id := int64(1); id <= 5; id++ {
+		if err := index.Put(ctx, LessonDocument{LessonID: id, Published: published.Add(time.Duration(id) * time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []int64
+	conditions := LessonSearchConditions{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("too many pages")
+		}
+
+		ids, nextCursor, err := SearchLessonIDs(ctx, conditions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ids...)
+
+		if nextCursor == "" {
+			break
+		}
+		conditions.Cursor = nextCursor
+	}
+
+	if want := []int64{5, 4, 3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, _, err := SearchLessonIDs(ctx, LessonSearchConditions{Cursor: "!"}); err != InvalidCursor {
+		t.Errorf("got %v, want InvalidCursor", err)
+	}
+}
+
+func TestSearchPublicLessonsFillsPage(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+
+	// the index has 5 lessons, and the 2nd and 3rd newest were unpublished after they were indexed.
+	published := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		lesson := Lesson{UserID: 1}
+		if err := CreateLesson(ctx, &lesson); err != nil {
+			t.Fatal(err)
+		}
+		lesson.Status = LessonStatusPublic
+		if i == 2 || i == 3 {
+			lesson.Status = LessonStatusDraft
+		}
+		if err := UpdateLesson(ctx, &lesson); err != nil {
+			t.Fatal(err)
+		}
+		if err := lessonIndex.Put(ctx, LessonDocument{LessonID: lesson.ID, Published: published.Add(time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, lesson.ID)
+	}
+
+	lessons, nextCursor, err := SearchPublicLessons(ctx, LessonSearchConditions{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, lesson := range lessons {
+		got = append(got, lesson.ID)
+	}
+	if want := []int64{ids[4], ids[1]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if nextCursor == "" {
+		t.Fatal("the cursor of the last lesson is not returned")
+	}
+
+	lessons, nextCursor, err = SearchPublicLessons(ctx, LessonSearchConditions{Limit: 2, Cursor: nextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lessons) != 1 || lessons[0].ID != ids[0] || nextCursor != "" {
+		t.Errorf("got %v %q, want the oldest lesson", lessons, nextCursor)
+	}
+
+	// no lesson matched is the empty page.
+	lessons, _, err = SearchPublicLessons(ctx, LessonSearchConditions{SubjectID: 100})
+	if err != nil || lessons == nil || len(lessons) != 0 {
+		t.Errorf("got %v %v, want the empty page", lessons, err)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"cloud.google.com/go/datastore"
-	"github.com/imdario/mergo"
 	"github.com/super-dog-human/teraconnectgo/infrastructure"
 )
 
@@ -26,26 +25,27 @@ type LessonMaterial struct {
 	Embeddings           []LessonEmbedding    `json:"embeddings" datastore:",noindex"`
 	Musics               []LessonMusic        `json:"musics" datastore:",noindex"`
 	Speeches             []LessonSpeech       `json:"speeches" datastore:",noindex"`
+	Revision             int64                `json:"-" datastore:",noindex"` // 更新ごとに増加、ETagに使う
 	Created              time.Time            `json:"created" datastore:",noindex"`
 	Updated              time.Time            `json:"updated" datastore:",noindex"`
 }
 
 type LessonAvatar struct {
-	ElapsedTime float32    `json:"elapsedTime"`
-	DurationSec float32    `json:"durationSec"`
+	ElapsedTime float32    `json:"elapsedTime" validate:"gte=0"`
+	DurationSec float32    `json:"durationSec" validate:"gte=0"`
 	Moving      Position3D `json:"moving,omitempty"`
 }
 
 type LessonDrawing struct {
-	ElapsedTime float32             `json:"elapsedTime"`
-	DurationSec float32             `json:"durationSec"`
+	ElapsedTime float32             `json:"elapsedTime" validate:"gte=0"`
+	DurationSec float32             `json:"durationSec" validate:"gte=0"`
 	Action      DrawingAction       `json:"action"`
-	Units       []LessonDrawingUnit `json:"units"`
+	Units       []LessonDrawingUnit `json:"units" validate:"dive"`
 }
 
 type LessonDrawingUnit struct {
-	ElapsedTime float32             `json:"elapsedTime"`
-	DurationSec float32             `json:"durationSec"`
+	ElapsedTime float32             `json:"elapsedTime" validate:"gte=0"`
+	DurationSec float32             `json:"durationSec" validate:"gte=0"`
 	Action      DrawingUnitAction   `json:"action"`
 	Stroke      LessonDrawingStroke `json:"stroke"`
 }
@@ -58,20 +58,20 @@ type LessonDrawingStroke struct {
 }
 
 type LessonEmbedding struct {
-	ElapsedTime float32         `json:"elapsedTime"`
+	ElapsedTime float32         `json:"elapsedTime" validate:"gte=0"`
 	Action      EmbeddingAction `json:"action"`
 	ContentID   string          `json:"contentID"`
 	ServiceName string          `json:"type"`
 }
 
 type LessonGraphic struct {
-	ElapsedTime float64        `json:"elapsedTime"`
+	ElapsedTime float64        `json:"elapsedTime" validate:"gte=0"`
 	GraphicID   int64          `json:"graphicID"`
 	Action      GraphicActrion `json:"action"`
 }
 
 type LessonMusic struct {
-	ElapsedTime       float32     `json:"elapsedTime"`
+	ElapsedTime       float32     `json:"elapsedTime" validate:"gte=0"`
 	Action            MusicAction `json:"action"`
 	BackgroundMusicID int64       `json:"backgroundMusicID"`
 	Volume            float32     `json:"volume"`
@@ -80,10 +80,10 @@ type LessonMusic struct {
 }
 
 type LessonSpeech struct {
-	ElapsedTime     float32              `json:"elapsedTime"`
-	DurationSec     float32              `json:"durationSec"`
+	ElapsedTime     float32              `json:"elapsedTime" validate:"gte=0"`
+	DurationSec     float32              `json:"durationSec" validate:"gte=0"`
 	VoiceID         int64                `json:"voiceID"`
-	Subtitle        string               `json:"subtitle"`
+	Subtitle        string               `json:"subtitle" validate:"max=500"`
 	Caption         Caption              `json:"caption"`
 	IsSynthesis     bool                 `json:"isSynthesis"`
 	SynthesisConfig VoiceSynthesisConfig `json:"synthesisConfig"`
@@ -99,80 +99,115 @@ type Caption struct {
 }
 
 func GetLessonMaterial(ctx context.Context, id int64, lessonID int64, lessonMaterial *LessonMaterial) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+	found, err := repositories.LessonMaterials.Get(ctx, lessonID, id)
 	if err != nil {
 		return err
 	}
 
-	ancestor := datastore.IDKey("Lesson", lessonID, nil)
-	key := datastore.IDKey("LessonMaterial", id, ancestor)
-	if err := client.Get(ctx, key, lessonMaterial); err != nil {
-		return err
-	}
-
-	lessonMaterial.ID = id
+	*lessonMaterial = found
 	lessonMaterial.BackgroundImageURL = infrastructure.GetPublicBackgroundImageURL(strconv.FormatInt(lessonMaterial.BackgroundImageID, 10))
 
 	return nil
 }
 
 func CreateLessonMaterial(ctx context.Context, lessonID int64, lessonMaterial *LessonMaterial) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
-	}
-
 	currentTime := time.Now()
 	lessonMaterial.Created = currentTime
 	lessonMaterial.Updated = currentTime
 
-	ancestor := datastore.IDKey("Lesson", lessonID, nil)
-	key := datastore.IncompleteKey("LessonMaterial", ancestor)
-	putKey, err := client.Put(ctx, key, lessonMaterial)
-	if err != nil {
+	return repositories.LessonMaterials.Create(ctx, lessonID, lessonMaterial)
+}
+
+// UpdateLessonMaterial applies the patch to the material of the revision, the previous material is kept as the version.
+// returns RevisionMismatch when the material was updated after the revision.
+// returns LessonMaterialLintIssues when the patched material has the issues in strict mode.
+func UpdateLessonMaterial(ctx context.Context, id int64, lessonID int64, revision int64, patch MergePatch) (LessonMaterial, error) {
+	var lessonMaterial LessonMaterial
+	err := repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		lessonMaterial, err = updateLessonMaterialInTransaction(ctx, id, lessonID, revision, patch)
 		return err
+	})
+	if err != nil {
+		return lessonMaterial, err
 	}
 
-	lessonMaterial.ID = putKey.ID
-
-	return nil
+	return lessonMaterial, pruneLessonMaterialVersions(ctx, lessonID, id)
 }
 
-func UpdateLessonMaterial(ctx context.Context, id int64, lessonID int64, newLessonMaterial *LessonMaterial) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+func updateLessonMaterialInTransaction(ctx context.Context, id int64, lessonID int64, revision int64, patch MergePatch) (LessonMaterial, error) {
+	lessonMaterial, err := repositories.LessonMaterials.Get(ctx, lessonID, id)
 	if err != nil {
-		return err
+		return lessonMaterial, err
 	}
 
-	_, err = client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
-		return UpdateLessonMaterialInTransaction(tx, id, lessonID, newLessonMaterial)
-	})
+	if err := checkRevision(revision, lessonMaterial.Revision); err != nil {
+		return lessonMaterial, err
+	}
 
-	if err != nil {
-		return err
+	newLessonMaterial := lessonMaterial
+	if err := patch.Apply(&newLessonMaterial); err != nil {
+		return lessonMaterial, err
 	}
 
-	return nil
+	if err := CheckLessonMaterialLint(ctx, lessonID, newLessonMaterial); err != nil {
+		return lessonMaterial, err
+	}
+
+	lessonMaterial.ID = id
+	if err := snapshotLessonMaterial(ctx, lessonID, lessonMaterial); err != nil {
+		return lessonMaterial, err
+	}
+
+	newLessonMaterial.ID = id
+	newLessonMaterial.Created = lessonMaterial.Created
+	newLessonMaterial.Updated = time.Now()
+	newLessonMaterial.Revision = lessonMaterial.Revision + 1
+
+	return newLessonMaterial, repositories.LessonMaterials.Put(ctx, lessonID, &newLessonMaterial)
+}
+
+type datastoreLessonMaterialRepository struct {
+	datastoreStore
 }
 
-func UpdateLessonMaterialInTransaction(tx *datastore.Transaction, id int64, lessonID int64, newLessonMaterial *LessonMaterial) error {
+func (r datastoreLessonMaterialRepository) Get(ctx context.Context, lessonID int64, id int64) (LessonMaterial, error) {
+	var lessonMaterial LessonMaterial
+
 	ancestor := datastore.IDKey("Lesson", lessonID, nil)
 	key := datastore.IDKey("LessonMaterial", id, ancestor)
-	var lessonMaterial LessonMaterial
-	if err := tx.Get(key, &lessonMaterial); err != nil {
-		return err
+	if err := r.get(ctx, key, &lessonMaterial); err != nil {
+		return lessonMaterial, err
 	}
 
-	if err := mergo.Merge(newLessonMaterial, lessonMaterial); err != nil {
+	lessonMaterial.ID = id
+
+	return lessonMaterial, nil
+}
+
+func (r datastoreLessonMaterialRepository) Create(ctx context.Context, lessonID int64, lessonMaterial *LessonMaterial) error {
+	ancestor := datastore.IDKey("Lesson", lessonID, nil)
+	key := datastore.IncompleteKey("LessonMaterial", ancestor)
+	putKey, err := r.put(ctx, key, lessonMaterial)
+	if err != nil {
 		return err
 	}
 
-	newLessonMaterial.Created = lessonMaterial.Created
-	newLessonMaterial.Updated = time.Now()
+	lessonMaterial.ID = putKey.ID
+
+	return nil
+}
 
-	if _, err := tx.Put(key, newLessonMaterial); err != nil {
+func (r datastoreLessonMaterialRepository) Put(ctx context.Context, lessonID int64, lessonMaterial *LessonMaterial) error {
+	ancestor := datastore.IDKey("Lesson", lessonID, nil)
+	key := datastore.IDKey("LessonMaterial", lessonMaterial.ID, ancestor)
+	if _, err := r.put(ctx, key, lessonMaterial); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (r datastoreLessonMaterialRepository) DeleteByLessonID(ctx context.Context, lessonID int64) error {
+	return r.deleteChildren(ctx, "LessonMaterial", datastore.IDKey("Lesson", lessonID, nil))
+}
@@ -0,0 +1,288 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	LessonMaterialLintVoiceNotFound           = "voiceNotFound"
+	LessonMaterialLintGraphicNotOwned         = "graphicNotOwned"
+	LessonMaterialLintBackgroundMusicNotFound = "backgroundMusicNotFound"
+	LessonMaterialLintGraphicAlreadyShown     = "graphicAlreadyShown"
+	LessonMaterialLintGraphicNotShown         = "graphicNotShown"
+	LessonMaterialLintEmbeddingAlreadyShown   = "embeddingAlreadyShown"
+	LessonMaterialLintEmbeddingNotShown       = "embeddingNotShown"
+	LessonMaterialLintMusicNotStarted         = "musicNotStarted"
+	LessonMaterialLintAfterDuration           = "afterDuration"
+)
+
+// LessonMaterialLintIssue is the event of the timeline breaking the rule.
+type LessonMaterialLintIssue struct {
+	Rule        string  `json:"rule"`
+	Field       string  `json:"field"` // the field of the material, e.g. "speeches".
+	Index       int     `json:"index"` // the index of the event in the field.
+	ElapsedTime float64 `json:"elapsedTime"`
+	ID          int64   `json:"id,omitempty"` // the asset referenced from the event.
+}
+
+// LessonMaterialLintIssues is returned when the material with the issues is saved in strict mode.
+type LessonMaterialLintIssues []LessonMaterialLintIssue
+
+func (e LessonMaterialLintIssues) Error() string {
+	issues := make([]string, len(e))
+	for i, issue := range e {
+		issues[i] = fmt.Sprintf("%s %s[%d]", issue.Rule, issue.Field, issue.Index)
+	}
+	return "lint issues of lesson material: " + strings.Join(issues, ", ")
+}
+
+type lessonMaterialLintConfig struct {
+	mu     sync.RWMutex
+	strict bool
+}
+
+var materialLint = &lessonMaterialLintConfig{}
+
+// SetLessonMaterialStrictLint rejects saving the materials with the issues when strict is set.
+func SetLessonMaterialStrictLint(strict bool) {
+	materialLint.mu.Lock()
+	defer materialLint.mu.Unlock()
+
+	materialLint.strict = strict
+}
+
+func isLessonMaterialLintStrict() bool {
+	materialLint.mu.RLock()
+	defer materialLint.mu.RUnlock()
+
+	return materialLint.strict
+}
+
+// LintLessonMaterial returns the issues of the saved material, empty when it has no issue.
+func LintLessonMaterial(ctx context.Context, lessonID int64, id int64) (LessonMaterialLintIssues, error) {
+	material, err := repositories.LessonMaterials.Get(ctx, lessonID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return lintLessonMaterial(ctx, lessonID, material)
+}
+
+// CheckLessonMaterialLint returns the issues as the error only in strict mode.
+// the materials imported or duplicated are not checked, because they are copies of the materials already saved by the author.
+func CheckLessonMaterialLint(ctx context.Context, lessonID int64, material LessonMaterial) error {
+	if !isLessonMaterialLintStrict() {
+		return nil
+	}
+
+	issues, err := lintLessonMaterial(ctx, lessonID, material)
+	if err != nil {
+		return err
+	}
+
+	if len(issues) > 0 {
+		return issues
+	}
+
+	return nil
+}
+
+// lintLessonMaterial checks the assets referenced from the material belong to the author, and the events of the timeline are consistent.
+func lintLessonMaterial(ctx context.Context, lessonID int64, material LessonMaterial) (LessonMaterialLintIssues, error) {
+	issues := LessonMaterialLintIssues{}
+
+	referenceIssues, err := lintLessonMaterialReferences(ctx, lessonID, material)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, referenceIssues...)
+	issues = append(issues, lintLessonMaterialTimeline(material)...)
+	issues = append(issues, lintLessonMaterialDuration(material)...)
+
+	return issues, nil
+}
+
+// lintLessonMaterialReferences gets the voices and graphics referenced from the material at once for each kind, because it runs in the transaction of the update.
+func lintLessonMaterialReferences(ctx context.Context, lessonID int64, material LessonMaterial) (LessonMaterialLintIssues, error) {
+	var issues LessonMaterialLintIssues
+
+	var voiceIDs []int64
+	for _, speech := range material.Speeches {
+		if speech.VoiceID != 0 { // the speech without voice only shows the subtitle.
+			voiceIDs = append(voiceIDs, speech.VoiceID)
+		}
+	}
+
+	voiceExists := make(map[int64]bool)
+	if len(voiceIDs) > 0 {
+		voices, err := repositories.Voices.GetMulti(ctx, lessonID, uniqueIDs(voiceIDs))
+		if err != nil {
+			return nil, err
+		}
+		for _, voice := range voices {
+			voiceExists[voice.ID] = true
+		}
+	}
+
+	for i, speech := range material.Speeches {
+		if speech.VoiceID != 0 && !voiceExists[speech.VoiceID] {
+			issues = append(issues, LessonMaterialLintIssue{Rule: LessonMaterialLintVoiceNotFound, Field: "speeches", Index: i, ElapsedTime: float64(speech.ElapsedTime), ID: speech.VoiceID})
+		}
+	}
+
+	var graphicIDs []int64
+	for _, graphic := range material.Graphics {
+		if graphic.GraphicID != 0 {
+			graphicIDs = append(graphicIDs, graphic.GraphicID)
+		}
+	}
+
+	graphicOwned := make(map[int64]bool)
+	if len(graphicIDs) > 0 {
+		graphics, err := repositories.Graphics.GetMulti(ctx, material.UserID, uniqueIDs(graphicIDs))
+		if err != nil {
+			return nil, err
+		}
+		for _, graphic := range graphics {
+			graphicOwned[graphic.ID] = true
+		}
+	}
+
+	for i, graphic := range material.Graphics {
+		if !graphicOwned[graphic.GraphicID] {
+			issues = append(issues, LessonMaterialLintIssue{Rule: LessonMaterialLintGraphicNotOwned, Field: "graphics", Index: i, ElapsedTime: graphic.ElapsedTime, ID: graphic.GraphicID})
+		}
+	}
+
+	if len(material.Musics) > 0 {
+		ids, err := repositories.BackgroundMusics.ListAvailableIDs(ctx, material.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		available := make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			available[id] = true
+		}
+
+		for i, music := range material.Musics {
+			if music.Action == MusicActionStart && !available[music.BackgroundMusicID] {
+				issues = append(issues, LessonMaterialLintIssue{Rule: LessonMaterialLintBackgroundMusicNotFound, Field: "musics", Index: i, ElapsedTime: float64(music.ElapsedTime), ID: music.BackgroundMusicID})
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+// lintLessonMaterialTimeline replays the events in order of ElapsedTime, the graphics and embeddings left shown at the end are allowed.
+func lintLessonMaterialTimeline(material LessonMaterial) LessonMaterialLintIssues {
+	var issues LessonMaterialLintIssues
+
+	shownGraphics := make(map[int64]bool)
+	for _, i := range timelineOrder(len(material.Graphics), func(i int) float64 { return material.Graphics[i].ElapsedTime }) {
+		graphic := material.Graphics[i]
+		isShow := graphic.Action == GraphicActionShow
+		if shownGraphics[graphic.GraphicID] == isShow {
+			rule := LessonMaterialLintGraphicNotShown
+			if isShow {
+				rule = LessonMaterialLintGraphicAlreadyShown
+			}
+			issues = append(issues, LessonMaterialLintIssue{Rule: rule, Field: "graphics", Index: i, ElapsedTime: graphic.ElapsedTime, ID: graphic.GraphicID})
+		}
+		shownGraphics[graphic.GraphicID] = isShow
+	}
+
+	shownEmbeddings := make(map[string]bool)
+	for _, i := range timelineOrder(len(material.Embeddings), func(i int) float64 { return float64(material.Embeddings[i].ElapsedTime) }) {
+		embedding := material.Embeddings[i]
+		key := embedding.ServiceName + "/" + embedding.ContentID
+		isShow := embedding.Action == EmbeddingActionShow
+		if shownEmbeddings[key] == isShow {
+			rule := LessonMaterialLintEmbeddingNotShown
+			if isShow {
+				rule = LessonMaterialLintEmbeddingAlreadyShown
+			}
+			issues = append(issues, LessonMaterialLintIssue{Rule: rule, Field: "embeddings", Index: i, ElapsedTime: float64(embedding.ElapsedTime)})
+		}
+		shownEmbeddings[key] = isShow
+	}
+
+	isPlaying := false
+	for _, i := range timelineOrder(len(material.Musics), func(i int) float64 { return float64(material.Musics[i].ElapsedTime) }) {
+		music := material.Musics[i]
+		if music.Action == MusicActionStop && !isPlaying {
+			issues = append(issues, LessonMaterialLintIssue{Rule: LessonMaterialLintMusicNotStarted, Field: "musics", Index: i, ElapsedTime: float64(music.ElapsedTime)})
+		}
+		isPlaying = music.Action == MusicActionStart
+	}
+
+	return issues
+}
+
+// lintLessonMaterialDuration returns the events ending after DurationSec of the material, unless the duration is not set yet.
+func lintLessonMaterialDuration(material LessonMaterial) LessonMaterialLintIssues {
+	var issues LessonMaterialLintIssues
+	if material.DurationSec <= 0 {
+		return issues
+	}
+
+	check := func(field string, index int, elapsedTime float64, durationSec float32) {
+		if elapsedTime+float64(durationSec) > float64(material.DurationSec) {
+			issues = append(issues, LessonMaterialLintIssue{Rule: LessonMaterialLintAfterDuration, Field: field, Index: index, ElapsedTime: elapsedTime})
+		}
+	}
+
+	for i, avatar := range material.Avatars {
+		check("avatars", i, float64(avatar.ElapsedTime), avatar.DurationSec)
+	}
+	for i, drawing := range material.Drawings {
+		check("drawings", i, float64(drawing.ElapsedTime), drawing.DurationSec)
+	}
+	for i, embedding := range material.Embeddings {
+		check("embeddings", i, float64(embedding.ElapsedTime), 0)
+	}
+	for i, graphic := range material.Graphics {
+		check("graphics", i, graphic.ElapsedTime, 0)
+	}
+	for i, music := range material.Musics {
+		check("musics", i, float64(music.ElapsedTime), 0)
+	}
+	for i, speech := range material.Speeches {
+		check("speeches", i, float64(speech.ElapsedTime), speech.DurationSec)
+	}
+
+	return issues
+}
+
+// timelineOrder returns the indexes of events in order of ElapsedTime, the events at the same time keep the order of the field.
+func timelineOrder(n int, elapsedTime func(i int) float64) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return elapsedTime(indexes[a]) < elapsedTime(indexes[b])
+	})
+
+	return indexes
+}
+
+// uniqueIDs returns the ids without duplicates in order of first appearance.
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	var unique []int64
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
+}
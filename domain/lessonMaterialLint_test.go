@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// lintRules returns the rule and index of each issue in the field.
+func lintRules(issues LessonMaterialLintIssues) []string {
+	rules := []string{}
+	for _, issue := range issues {
+		rules = append(rules, fmt.Sprintf("%s %s[%d]", issue.Rule, issue.Field, issue.Index))
+	}
+	return rules
+}
+
+func TestLintLessonMaterialTimeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		material LessonMaterial
+		want     []string
+	}{
+		{
+			"graphics in order of elapsed time",
+			LessonMaterial{Graphics: []LessonGraphic{
+				{ElapsedTime: 2, GraphicID: 1, Action: GraphicActionHide},
+				{ElapsedTime: 1, GraphicID: 1, Action: GraphicActionShow},
+				{ElapsedTime: 3, GraphicID: 2, Action: GraphicActionShow}, // left shown at the end.
+			}},
+			[]string{},
+		},
+		{
+			"graphic shown twice and hidden before shown",
+			LessonMaterial{Graphics: []LessonGraphic{
+				{ElapsedTime: 0, GraphicID: 1, Action: GraphicActionHide},
+				{ElapsedTime: 1, GraphicID: 2, Action: GraphicActionShow},
+				{ElapsedTime: 2, GraphicID: 2, Action: GraphicActionShow},
+			}},
+			[]string{"graphicNotShown graphics[0]", "graphicAlreadyShown graphics[2]"},
+		},
+		{
+			"embeddings are distinguished by the service and content",
+			LessonMaterial{Embeddings: []LessonEmbedding{
+				{ElapsedTime: 0, ServiceName: "youtube", ContentID: "a", Action: EmbeddingActionShow},
+				{ElapsedTime: 1, ServiceName: "youtube", ContentID: "b", Action: EmbeddingActionShow},
+				{ElapsedTime: 2, ServiceName: "youtube", ContentID: "a", Action: EmbeddingActionShow},
+				{ElapsedTime: 3, ServiceName: "youtube", ContentID: "c", Action: EmbeddingActionHide},
+			}},
+			[]string{"embeddingAlreadyShown embeddings[2]", "embeddingNotShown embeddings[3]"},
+		},
+		{
+			"music stopped before started",
+			LessonMaterial{Musics: []LessonMusic{
+				{ElapsedTime: 0, Action: MusicActionStop},
+				{ElapsedTime: 1, Action: MusicActionStart},
+				{ElapsedTime: 2, Action: MusicActionStop},
+				{ElapsedTime: 3, Action: MusicActionStop},
+			}},
+			[]string{"musicNotStarted musics[0]", "musicNotStarted musics[3]"},
+		},
+	}
+
+	for _, test := range tests {
+		if got := lintRules(lintLessonMaterialTimeline(test.material)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLintLessonMaterialDuration(t *testing.T) {
+	material := LessonMaterial{
+		DurationSec: 10,
+		Speeches:    []LessonSpeech{{ElapsedTime: 5, DurationSec: 5}, {ElapsedTime: 8, DurationSec: 3}},
+		Graphics:    []LessonGraphic{{ElapsedTime: 10}, {ElapsedTime: 10.5}},
+	}
+
+	want := []string{"afterDuration graphics[1]", "afterDuration speeches[1]"}
+	if got := lintRules(lintLessonMaterialDuration(material)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// the duration not set yet is not checked.
+	material.DurationSec = 0
+	if issues := lintLessonMaterialDuration(material); len(issues) != 0 {
+		t.Errorf("got %v, want no issue", issues)
+	}
+}
+
+func TestLintLessonMaterialReferences(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+
+	lesson := Lesson{UserID: 1}
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+	voice := Voice{UserID: 1}
+	if err := CreateVoice(ctx, lesson.ID, &voice); err != nil {
+		t.Fatal(err)
+	}
+	graphic := &Graphic{LessonID: lesson.ID, FileType: "png"}
+	if err := CreateGraphics(ctx, 1, []*Graphic{graphic}); err != nil {
+		t.Fatal(err)
+	}
+	othersGraphic := &Graphic{FileType: "png"}
+	if err := CreateGraphics(ctx, 2, []*Graphic{othersGraphic}); err != nil {
+		t.Fatal(err)
+	}
+	music := BackgroundMusic{Name: "private"}
+	if err := CreateBackgroundMusic(ctx, 2, &music); err != nil {
+		t.Fatal(err)
+	}
+
+	material := LessonMaterial{
+		UserID: 1,
+		Speeches: []LessonSpeech{
+			{VoiceID: voice.ID},
+			{VoiceID: 0}, // only the subtitle.
+			{VoiceID: voice.ID + 100},
+			{VoiceID: voice.ID + 100},
+		},
+		Graphics: []LessonGraphic{
+			{GraphicID: graphic.ID, Action: GraphicActionShow},
+			{GraphicID: othersGraphic.ID, Action: GraphicActionShow},
+		},
+		Musics: []LessonMusic{
+			{BackgroundMusicID: music.ID, Action: MusicActionStart},
+		},
+	}
+
+	issues, err := lintLessonMaterialReferences(ctx, lesson.ID, material)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"voiceNotFound speeches[2]", "voiceNotFound speeches[3]", "graphicNotOwned graphics[1]", "backgroundMusicNotFound musics[0]"}
+	if got := lintRules(issues); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCheckLessonMaterialLintOnlyInStrictMode(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	strict := isLessonMaterialLintStrict()
+	t.Cleanup(func() { SetLessonMaterialStrictLint(strict) })
+
+	material := LessonMaterial{Musics: []LessonMusic{{Action: MusicActionStop}}}
+
+	SetLessonMaterialStrictLint(false)
+	if err := CheckLessonMaterialLint(ctx, 1, material); err != nil {
+		t.Errorf("got %v in non-strict mode", err)
+	}
+
+	SetLessonMaterialStrictLint(true)
+	issues, ok := CheckLessonMaterialLint(ctx, 1, material).(LessonMaterialLintIssues)
+	if !ok || len(issues) != 1 || issues[0].Rule != LessonMaterialLintMusicNotStarted {
+		t.Errorf("got %v, want musicNotStarted", issues)
+	}
+}
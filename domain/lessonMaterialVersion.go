@@ -0,0 +1,266 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+// maxInlineLessonMaterialVersionSize is the size of snapshot stored in the entity,
+// the larger ones are stored in GCS because Datastore limits the size of entity to 1MiB.
+const maxInlineLessonMaterialVersionSize = 900 << 10
+
+type LessonMaterialVersionErrorCode uint
+
+const (
+	LessonMaterialVersionNotFound LessonMaterialVersionErrorCode = 1
+)
+
+func (e LessonMaterialVersionErrorCode) Error() string {
+	switch e {
+	case LessonMaterialVersionNotFound:
+		return "lesson material version not found"
+	default:
+		return "unknown lesson material version error"
+	}
+}
+
+// LessonMaterialVersion is the snapshot of the material before it was updated.
+type LessonMaterialVersion struct {
+	ID              int64     `json:"id" datastore:"-"`
+	LessonID        int64     `json:"lessonID"`
+	MaterialID      int64     `json:"materialID"`
+	MaterialUpdated time.Time `json:"materialUpdated" datastore:",noindex"` // the time the snapshot was saved as the material.
+	SizeInBytes     int64     `json:"sizeInBytes" datastore:",noindex"`
+	IsBlob          bool      `json:"-" datastore:",noindex"` // the snapshot is stored in GCS instead of Data.
+	Data            []byte    `json:"-" datastore:",noindex"` // the material encoded in JSON.
+	Created         time.Time `json:"created"`
+}
+
+// lessonMaterialRetention keeps the last versions, and the last version of each day in the days.
+type lessonMaterialRetention struct {
+	mu       sync.RWMutex
+	keepLast int
+	keepDays int
+}
+
+var materialRetention = &lessonMaterialRetention{keepLast: 20, keepDays: 30}
+
+// SetLessonMaterialRetention replaces the number of versions kept for each material.
+func SetLessonMaterialRetention(keepLast int, keepDays int) {
+	materialRetention.mu.Lock()
+	defer materialRetention.mu.Unlock()
+
+	materialRetention.keepLast = keepLast
+	materialRetention.keepDays = keepDays
+}
+
+// GetLessonMaterialVersions returns versions of the material in order of newest first.
+func GetLessonMaterialVersions(ctx context.Context, lessonID int64, materialID int64) ([]LessonMaterialVersion, error) {
+	return repositories.LessonMaterialVersions.ListByMaterialID(ctx, lessonID, materialID)
+}
+
+// GetLessonMaterialVersion returns the material of the version.
+func GetLessonMaterialVersion(ctx context.Context, lessonID int64, materialID int64, id int64) (LessonMaterial, error) {
+	var material LessonMaterial
+
+	version, err := repositories.LessonMaterialVersions.Get(ctx, lessonID, materialID, id)
+	if err != nil {
+		if err == ErrNoSuchEntity {
+			return material, LessonMaterialVersionNotFound
+		}
+		return material, err
+	}
+
+	data := version.Data
+	if version.IsBlob {
+		if data, err = infrastructure.GetFile(ctx, infrastructure.MaterialBucketName(), lessonMaterialVersionFilePath(version)); err != nil {
+			return material, err
+		}
+	}
+
+	if err := json.Unmarshal(data, &material); err != nil {
+		return material, err
+	}
+
+	material.ID = materialID
+
+	return material, nil
+}
+
+// RestoreLessonMaterialVersion replaces the material with the version, the current material is also kept as the new version.
+func RestoreLessonMaterialVersion(ctx context.Context, lessonID int64, materialID int64, id int64) (LessonMaterial, error) {
+	restored, err := GetLessonMaterialVersion(ctx, lessonID, materialID, id)
+	if err != nil {
+		return restored, err
+	}
+
+	err = repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		current, err := repositories.LessonMaterials.Get(ctx, lessonID, materialID)
+		if err != nil {
+			return err
+		}
+
+		if err := snapshotLessonMaterial(ctx, lessonID, current); err != nil {
+			return err
+		}
+
+		restored.UserID = current.UserID
+		restored.Created = current.Created
+		restored.Updated = time.Now()
+		restored.Revision = current.Revision + 1
+
+		return repositories.LessonMaterials.Put(ctx, lessonID, &restored)
+	})
+	if err != nil {
+		return restored, err
+	}
+
+	return restored, pruneLessonMaterialVersions(ctx, lessonID, materialID)
+}
+
+// snapshotLessonMaterial creates the version of the material, this is called in the transaction updating the material.
+func snapshotLessonMaterial(ctx context.Context, lessonID int64, material LessonMaterial) error {
+	data, err := json.Marshal(material)
+	if err != nil {
+		return err
+	}
+
+	version := LessonMaterialVersion{
+		LessonID:        lessonID,
+		MaterialID:      material.ID,
+		MaterialUpdated: material.Updated,
+		SizeInBytes:     int64(len(data)),
+		IsBlob:          len(data) > maxInlineLessonMaterialVersionSize,
+		Created:         time.Now(),
+	}
+	if !version.IsBlob {
+		version.Data = data
+	}
+
+	if err := repositories.LessonMaterialVersions.Create(ctx, lessonID, material.ID, &version); err != nil {
+		return err
+	}
+
+	if version.IsBlob {
+		return infrastructure.CreateFile(ctx, infrastructure.MaterialBucketName(), lessonMaterialVersionFilePath(version), "application/json", data)
+	}
+
+	return nil
+}
+
+// pruneLessonMaterialVersions deletes the versions out of the retention.
+func pruneLessonMaterialVersions(ctx context.Context, lessonID int64, materialID int64) error {
+	versions, err := repositories.LessonMaterialVersions.ListByMaterialID(ctx, lessonID, materialID)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range expiredLessonMaterialVersions(versions, time.Now()) {
+		if version.IsBlob {
+			if err := ignoreBlobNotExist(infrastructure.DeleteFile(ctx, infrastructure.MaterialBucketName(), lessonMaterialVersionFilePath(version))); err != nil {
+				return err
+			}
+		}
+
+		if err := repositories.LessonMaterialVersions.Delete(ctx, lessonID, materialID, version.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expiredLessonMaterialVersions returns the versions out of the retention from versions in order of newest first.
+func expiredLessonMaterialVersions(versions []LessonMaterialVersion, now time.Time) []LessonMaterialVersion {
+	materialRetention.mu.RLock()
+	keepLast, keepDays := materialRetention.keepLast, materialRetention.keepDays
+	materialRetention.mu.RUnlock()
+
+	oldest := now.AddDate(0, 0, -keepDays)
+	keptDays := make(map[string]bool)
+
+	var expired []LessonMaterialVersion
+	for i, version := range versions {
+		day := version.Created.Format("2006-01-02")
+		if i < keepLast || (!keptDays[day] && version.Created.After(oldest)) {
+			keptDays[day] = true
+			continue
+		}
+		expired = append(expired, version)
+	}
+
+	return expired
+}
+
+// lessonMaterialVersionFilePrefix is the prefix of the snapshots stored in GCS of all materials of the lesson.
+func lessonMaterialVersionFilePrefix(lessonID int64) string {
+	return fmt.Sprintf("lesson_material_version/%d/", lessonID)
+}
+
+func lessonMaterialVersionFilePath(version LessonMaterialVersion) string {
+	return fmt.Sprintf("%s%d/%d.json", lessonMaterialVersionFilePrefix(version.LessonID), version.MaterialID, version.ID)
+}
+
+type datastoreLessonMaterialVersionRepository struct {
+	datastoreStore
+}
+
+func lessonMaterialVersionAncestor(lessonID int64, materialID int64) *datastore.Key {
+	return datastore.IDKey("LessonMaterial", materialID, datastore.IDKey("Lesson", lessonID, nil))
+}
+
+func (r datastoreLessonMaterialVersionRepository) Get(ctx context.Context, lessonID int64, materialID int64, id int64) (LessonMaterialVersion, error) {
+	var version LessonMaterialVersion
+
+	key := datastore.IDKey("LessonMaterialVersion", id, lessonMaterialVersionAncestor(lessonID, materialID))
+	if err := r.get(ctx, key, &version); err != nil {
+		return version, err
+	}
+
+	version.ID = id
+
+	return version, nil
+}
+
+func (r datastoreLessonMaterialVersionRepository) ListByMaterialID(ctx context.Context, lessonID int64, materialID int64) ([]LessonMaterialVersion, error) {
+	var versions []LessonMaterialVersion
+
+	query := datastore.NewQuery("LessonMaterialVersion").Ancestor(lessonMaterialVersionAncestor(lessonID, materialID)).Order("-Created")
+	keys, err := r.client.GetAll(ctx, query, &versions)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, key := range keys {
+		versions[i].ID = key.ID
+	}
+
+	return versions, nil
+}
+
+func (r datastoreLessonMaterialVersionRepository) Create(ctx context.Context, lessonID int64, materialID int64, version *LessonMaterialVersion) error {
+	key := datastore.IncompleteKey("LessonMaterialVersion", lessonMaterialVersionAncestor(lessonID, materialID))
+	putKey, err := r.put(ctx, key, version)
+	if err != nil {
+		return err
+	}
+
+	version.ID = putKey.ID
+
+	return nil
+}
+
+func (r datastoreLessonMaterialVersionRepository) Delete(ctx context.Context, lessonID int64, materialID int64, id int64) error {
+	key := datastore.IDKey("LessonMaterialVersion", id, lessonMaterialVersionAncestor(lessonID, materialID))
+	return r.delete(ctx, key)
+}
+
+func (r datastoreLessonMaterialVersionRepository) DeleteByLessonID(ctx context.Context, lessonID int64) error {
+	return r.deleteChildren(ctx, "LessonMaterialVersion", datastore.IDKey("Lesson", lessonID, nil))
+}
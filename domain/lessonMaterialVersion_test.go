@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// setLessonMaterialRetention replaces the retention until the end of test.
+func setLessonMaterialRetention(t *testing.T, keepLast int, keepDays int) {
+	materialRetention.mu.RLock()
+	previousLast, previousDays := materialRetention.keepLast, materialRetention.keepDays
+	materialRetention.mu.RUnlock()
+
+	SetLessonMaterialRetention(keepLast, keepDays)
+	t.Cleanup(func() { SetLessonMaterialRetention(previousLast, previousDays) })
+}
+
+func TestExpiredLessonMaterialVersions(t *testing.T) {
+	setLessonMaterialRetention(t, 2, 3)
+
+	now := time.Date(2021, 4, 10, 12, 0, 0, 0, time.UTC)
+	versions := []LessonMaterialVersion{
+		{ID: 1, Created: now.Add(-1 * time.Hour)},
+		{ID: 2, Created: now.Add(-2 * time.Hour)},
+		{ID: 3, Created: now.Add(-3 * time.Hour)}, // the same day as the last versions.
+		{ID: 4, Created: now.AddDate(0, 0, -1)},   // the last version of the day.
+		{ID: 5, Created: now.AddDate(0, 0, -1).Add(-time.Hour)},
+		{ID: 6, Created: now.AddDate(0, 0, -2)}, // the last version of the day.
+		{ID: 7, Created: now.AddDate(0, 0, -4)}, // out of the days.
+	}
+
+	var got []int64
+	for _, version := range expiredLessonMaterialVersions(versions, now) {
+		got = append(got, version.ID)
+	}
+
+	if want := []int64{3, 5, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestUpdateLessonMaterialPrunesVersions(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	setLessonMaterialRetention(t, 2, 0)
+
+	lesson := Lesson{UserID: 1}
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+	material := LessonMaterial{UserID: 1, AvatarLightColor: "0"}
+	if err := CreateLessonMaterial(ctx, lesson.ID, &material); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, color := range []string{"1", "2", "3", "4"} {
+		patch := MergePatch{}
+		if err := patch.Set("avatarLightColor", color); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := UpdateLessonMaterial(ctx, material.ID, lesson.ID, AnyRevision, patch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := GetLessonMaterialVersions(ctx, lesson.ID, material.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("got %d versions, want 2 kept", len(versions))
+	}
+
+	// the newest version is the material before the last update.
+	restored, err := RestoreLessonMaterialVersion(ctx, lesson.ID, material.ID, versions[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.AvatarLightColor != "3" {
+		t.Errorf("restored %q, want %q", restored.AvatarLightColor, "3")
+	}
+
+	// the material replaced by the restore is kept as the version, and the oldest one is pruned.
+	versions, err = GetLessonMaterialVersions(ctx, lesson.ID, material.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("got %d versions after restore, want 2 kept", len(versions))
+	}
+}
@@ -2,118 +2,374 @@ package domain
 
 import (
 	"archive/zip"
-	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/super-dog-human/teraconnectgo/infrastructure"
 )
 
-/*
-func CreateLessonZip(ctx context.Context, lesson Lesson, graphicFileTypes map[int64]string, voiceTexts []RawVoiceText) (*bytes.Buffer, error) {
-	zipBuffer := new(bytes.Buffer)
-	zipWriter := zip.NewWriter(zipBuffer)
+// LessonPackVersion is the format version of the lesson package, it is increased when the format is changed incompatibly.
+const LessonPackVersion = 1
 
-	var err error
-	if err = addGraphicsToZip(ctx, lesson.GraphicIDs, graphicFileTypes, zipWriter); err != nil {
-		return zipBuffer, err
-	}
+const (
+	lessonPackManifestPath = "manifest.json"
+	lessonPackLessonPath   = "lesson.json"
+)
 
-	if err = addVoiceToZip(ctx, voiceTexts, lesson.ID, zipWriter); err != nil {
-		return zipBuffer, err
-	}
+const (
+	LessonAssetGraphic = "graphic"
+	LessonAssetVoice   = "voice"
+	LessonAssetBGM     = "bgm"
+	LessonAssetAvatar  = "avatar"
+)
 
-	if err = addLessonJSONToZip(ctx, lesson.ID, zipWriter); err != nil {
-		return zipBuffer, err
-	}
+// LessonAsset is the file referenced from the lesson material.
+type LessonAsset struct {
+	Kind        string `json:"kind"`
+	ID          int64  `json:"id"`
+	Path        string `json:"path"` // the path in the package.
+	ContentType string `json:"contentType,omitempty"`
+	SizeInBytes int64  `json:"sizeInBytes,omitempty"`
+	SHA256      string `json:"sha256,omitempty"`
+}
+
+// LessonPackManifest is written as manifest.json in the package.
+type LessonPackManifest struct {
+	Version    int           `json:"version"`
+	LessonID   int64         `json:"lessonID"`
+	MaterialID int64         `json:"materialID"`
+	Assets     []LessonAsset `json:"assets"`
+	Created    time.Time     `json:"created"`
+}
+
+// LessonPackContent is written as lesson.json in the package.
+type LessonPackContent struct {
+	Lesson   Lesson         `json:"lesson"`
+	Material LessonMaterial `json:"material"`
+}
 
-	zipWriter.Close()
+// MissingLessonAssets is returned when the files referenced from the lesson are not found.
+type MissingLessonAssets []LessonAsset
 
-	return zipBuffer, nil
+func (e MissingLessonAssets) Error() string {
+	assets := make([]string, len(e))
+	for i, asset := range e {
+		assets[i] = fmt.Sprintf("%s %d", asset.Kind, asset.ID)
+	}
+	return "missing assets of lesson: " + strings.Join(assets, ", ")
 }
-*/
 
-func UploadLessonZipToGCS(ctx context.Context, lessonID int64, zip *bytes.Buffer) error {
-	zipFilePath := fmt.Sprintf("lesson/%d.zip", lessonID)
-	contentType := "application/zip"
-	bucketName := infrastructure.MaterialBucketName()
-	if err := infrastructure.CreateFileToGCS(ctx, bucketName, zipFilePath, contentType, zip.Bytes()); err != nil {
+// PackLesson creates the zip of the lesson with all assets referenced from the material, and uploads it.
+// the zip is streamed to the storage while it is written, so the assets are not held in memory.
+func PackLesson(ctx context.Context, lesson *Lesson, material LessonMaterial) error {
+	pr, pw := io.Pipe()
+	written := make(chan int64, 1)
+	writeErr := make(chan error, 1)
+	go func() {
+		w := &countingWriter{w: pw}
+		err := WriteLessonZip(ctx, w, *lesson, material)
+		pw.CloseWithError(err) // the upload is aborted when writing fails.
+		written <- w.n
+		writeErr <- err
+	}()
+
+	uploadErr := uploadLessonZip(ctx, lesson.ID, pr)
+	pr.CloseWithError(uploadErr) // the writer stops when the upload fails.
+
+	if err := <-writeErr; err != nil {
 		return err
 	}
+	if uploadErr != nil {
+		return uploadErr
+	}
 
-	return nil
+	return setLessonPacked(ctx, lesson, <-written)
 }
 
-func addGraphicsToZip(ctx context.Context, usedGraphicIDs []int64, graphicFileTypes map[int64]string, zipWriter *zip.Writer) error {
-	for _, graphicID := range usedGraphicIDs {
-		fileType := graphicFileTypes[graphicID]
-		filePathInGCS := fmt.Sprintf("graphic/%d.%s", graphicID, fileType)
-		bucketName := infrastructure.MaterialBucketName()
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
 
-		objectBytes, err := infrastructure.GetFileFromGCS(ctx, bucketName, filePathInGCS)
+// setLessonPacked records the size of the uploaded package to the lesson read again, so the edits while packing are kept.
+// the revision is kept, because the package is not edited by the editor.
+func setLessonPacked(ctx context.Context, lesson *Lesson, sizeInBytes int64) error {
+	return repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		current, err := repositories.Lessons.Get(ctx, lesson.ID)
 		if err != nil {
 			return err
 		}
 
-		filePathInZip := fmt.Sprintf("graphics/%d.%s", graphicID, fileType)
-		var f io.Writer
-		f, err = zipWriter.Create(filePathInZip)
-		if err != nil {
+		current.IsPacked = true
+		current.SizeInBytes = sizeInBytes
+		if err := repositories.Lessons.Put(ctx, &current); err != nil {
 			return err
 		}
 
-		if _, err = f.Write(objectBytes); err != nil {
+		*lesson = current
+		return nil
+	})
+}
+
+// WriteLessonZip writes the zip of the lesson to w, all missing assets are returned as MissingLessonAssets before writing.
+// the manifest is written last with the sizes and hashes of the assets computed while they are copied.
+func WriteLessonZip(ctx context.Context, w io.Writer, lesson Lesson, material LessonMaterial) error {
+	assets, missing, err := lessonAssets(ctx, lesson, material)
+	if err != nil {
+		return err
+	}
+
+	missingFiles, err := missingLessonAssetFiles(ctx, lesson, assets)
+	if err != nil {
+		return err
+	}
+	if missing = append(missing, missingFiles...); len(missing) > 0 {
+		return missing
+	}
+
+	zipWriter := zip.NewWriter(w)
+
+	if err := addJSONToZip(zipWriter, lessonPackLessonPath, LessonPackContent{Lesson: lesson, Material: material}); err != nil {
+		return err
+	}
+
+	for i := range assets {
+		if err := addLessonAssetToZip(ctx, zipWriter, lesson, &assets[i]); errors.Is(err, infrastructure.ErrBlobNotExist) {
+			return MissingLessonAssets{assets[i]} // deleted while writing.
+		} else if err != nil {
 			return err
 		}
 	}
 
+	manifest := LessonPackManifest{
+		Version:    LessonPackVersion,
+		LessonID:   lesson.ID,
+		MaterialID: material.ID,
+		Assets:     assets,
+		Created:    time.Now(),
+	}
+	if err := addJSONToZip(zipWriter, lessonPackManifestPath, manifest); err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
+}
+
+// addLessonAssetToZip copies the asset file to the zip, and sets the size and the hash of it to the asset.
+func addLessonAssetToZip(ctx context.Context, zipWriter *zip.Writer, lesson Lesson, asset *LessonAsset) error {
+	r, err := openLessonAssetFile(ctx, lesson, *asset)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	f, err := zipWriter.Create(asset.Path)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), r)
+	if err != nil {
+		return err
+	}
+
+	asset.SizeInBytes = size
+	asset.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
 	return nil
 }
 
-/*
-func addVoiceToZip(ctx context.Context, voiceTexts []RawVoiceText, id int64, zipWriter *zip.Writer) error {
-	for _, voiceText := range voiceTexts {
-		filePathInGCS := fmt.Sprintf("voice/%d/%s.ogg", id, voiceText.FileID)
-		bucketName := infrastructure.MaterialBucketName()
+func uploadLessonZip(ctx context.Context, lessonID int64, r io.Reader) error {
+	zipFilePath := fmt.Sprintf("lesson/%d.zip", lessonID)
+	contentType := "application/zip"
+	bucketName := infrastructure.MaterialBucketName()
 
-		objectBytes, err := infrastructure.GetFileFromGCS(ctx, bucketName, filePathInGCS)
-		if err != nil {
-			return err
+	return infrastructure.CreateFileFromReader(ctx, bucketName, zipFilePath, contentType, r)
+}
+
+// lessonAssets returns the assets referenced from the material without duplication.
+// the graphics not found in the datastore are returned as missing, because the file types of them are unknown.
+func lessonAssets(ctx context.Context, lesson Lesson, material LessonMaterial) ([]LessonAsset, MissingLessonAssets, error) {
+	var assets []LessonAsset
+	var missing MissingLessonAssets
+
+	seen := make(map[string]bool)
+	isNew := func(kind string, id int64) bool {
+		key := fmt.Sprintf("%s/%d", kind, id)
+		if id == 0 || seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	}
+
+	for _, lessonGraphic := range material.Graphics {
+		if !isNew(LessonAssetGraphic, lessonGraphic.GraphicID) {
+			continue
+		}
+
+		graphic, err := GetGraphicByID(ctx, lessonGraphic.GraphicID, lesson.UserID)
+		if err == GraphicNotFound {
+			missing = append(missing, LessonAsset{Kind: LessonAssetGraphic, ID: lessonGraphic.GraphicID})
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		assets = append(assets, LessonAsset{
+			Kind:        LessonAssetGraphic,
+			ID:          graphic.ID,
+			Path:        fmt.Sprintf("graphics/%d.%s", graphic.ID, graphic.FileType),
+			ContentType: fileContentType(graphic.FileType),
+		})
+	}
+
+	for _, speech := range material.Speeches {
+		if isNew(LessonAssetVoice, speech.VoiceID) {
+			assets = append(assets, LessonAsset{Kind: LessonAssetVoice, ID: speech.VoiceID, Path: fmt.Sprintf("voices/%d.mp3", speech.VoiceID), ContentType: "audio/mpeg"})
+		}
+	}
+
+	for _, music := range material.Musics {
+		if isNew(LessonAssetBGM, music.BackgroundMusicID) {
+			assets = append(assets, LessonAsset{Kind: LessonAssetBGM, ID: music.BackgroundMusicID, Path: fmt.Sprintf("bgms/%d.mp3", music.BackgroundMusicID), ContentType: "audio/mpeg"})
+		}
+	}
+
+	if isNew(LessonAssetAvatar, material.AvatarID) {
+		assets = append(assets, LessonAsset{Kind: LessonAssetAvatar, ID: material.AvatarID, Path: fmt.Sprintf("avatars/%d.vrm", material.AvatarID), ContentType: "model/gltf-binary"})
+	}
+
+	return assets, missing, nil
+}
+
+// lessonAssetLocation is the bucket and the path of the asset file.
+type lessonAssetLocation struct {
+	bucketName string
+	filePath   string
+}
+
+// lessonAssetLocations returns the locations of the asset file in order of priority, the public files are used when the user's files are not found.
+func lessonAssetLocations(lesson Lesson, asset LessonAsset) ([]lessonAssetLocation, error) {
+	materialBucket := infrastructure.MaterialBucketName()
+	publicBucket := infrastructure.PublicBucketName()
+	id := fmt.Sprint(asset.ID)
+
+	switch asset.Kind {
+	case LessonAssetGraphic:
+		extension := strings.TrimPrefix(path.Ext(asset.Path), ".")
+		return []lessonAssetLocation{{materialBucket, infrastructure.StorageObjectFilePath("Graphic", id, extension)}}, nil
+	case LessonAssetVoice:
+		return []lessonAssetLocation{{materialBucket, fmt.Sprintf("voice/%d/%d.mp3", lesson.ID, asset.ID)}}, nil
+	case LessonAssetBGM:
+		filePath := infrastructure.StorageObjectFilePath("bgm", id, "mp3")
+		return []lessonAssetLocation{{materialBucket, filePath}, {publicBucket, filePath}}, nil
+	case LessonAssetAvatar:
+		filePath := infrastructure.StorageObjectFilePath("Avatar", id, "vrm")
+		return []lessonAssetLocation{{materialBucket, filePath}, {publicBucket, filePath}}, nil
+	default:
+		return nil, fmt.Errorf("unknown kind of asset %s", asset.Kind)
+	}
+}
+
+// openLessonAssetFile returns the reader of the asset from the first location found.
+func openLessonAssetFile(ctx context.Context, lesson Lesson, asset LessonAsset) (io.ReadCloser, error) {
+	locations, err := lessonAssetLocations(lesson, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, location := range locations {
+		r, err := infrastructure.OpenFile(ctx, location.bucketName, location.filePath)
+		if !errors.Is(err, infrastructure.ErrBlobNotExist) {
+			return r, err
 		}
+	}
+	return nil, infrastructure.ErrBlobNotExist
+}
+
+// findMissingLessonAssets returns the assets referenced from the material whose files are not found, without reading the files.
+func findMissingLessonAssets(ctx context.Context, lesson Lesson, material LessonMaterial) (MissingLessonAssets, error) {
+	assets, missing, err := lessonAssets(ctx, lesson, material)
+	if err != nil {
+		return nil, err
+	}
+
+	missingFiles, err := missingLessonAssetFiles(ctx, lesson, assets)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(missing, missingFiles...), nil
+}
 
-		filePathInZip := fmt.Sprintf("voices/%d/%s.ogg", id, voiceText.FileID)
-		var f io.Writer
-		f, err = zipWriter.Create(filePathInZip)
+// missingLessonAssetFiles returns the assets whose files are found in none of the locations.
+func missingLessonAssetFiles(ctx context.Context, lesson Lesson, assets []LessonAsset) (MissingLessonAssets, error) {
+	var missing MissingLessonAssets
+	for _, asset := range assets {
+		locations, err := lessonAssetLocations(lesson, asset)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		if _, err = f.Write(objectBytes); err != nil {
-			return err
+		found := false
+		for _, location := range locations {
+			_, err := infrastructure.StatFile(ctx, location.bucketName, location.filePath)
+			if err == nil {
+				found = true
+				break
+			} else if !errors.Is(err, infrastructure.ErrBlobNotExist) {
+				return nil, err
+			}
+		}
+
+		if !found {
+			missing = append(missing, asset)
 		}
 	}
 
-	return nil
+	return missing, nil
 }
-*/
 
-func addLessonJSONToZip(ctx context.Context, id int64, zipWriter *zip.Writer) error {
-	filePathInGCS := fmt.Sprintf("lesson/%d.json", id)
-	bucketName := infrastructure.MaterialBucketName()
-	jsonBytes, err := infrastructure.GetFileFromGCS(ctx, bucketName, filePathInGCS)
+// fileContentType returns the content type of the extension allowed to upload.
+func fileContentType(extension string) string {
+	if contentTypes, ok := contentTypesByExtension[strings.ToLower(extension)]; ok {
+		return contentTypes[0]
+	}
+	return "application/octet-stream"
+}
+
+func addJSONToZip(zipWriter *zip.Writer, filePath string, v interface{}) error {
+	contents, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
+	return addFileToZip(zipWriter, filePath, contents)
+}
 
-	filePathInZip := "lesson.json"
-	var f io.Writer
-	f, err = zipWriter.Create(filePathInZip)
+func addFileToZip(zipWriter *zip.Writer, filePath string, contents []byte) error {
+	f, err := zipWriter.Create(filePath)
 	if err != nil {
 		return err
 	}
 
-	if _, err = f.Write(jsonBytes); err != nil {
+	if _, err := f.Write(contents); err != nil {
 		return err
 	}
 
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+func TestPackLessonKeepsConcurrentEdits(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+
+	lesson := Lesson{UserID: 1, Title: "before"}
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+	material := LessonMaterial{UserID: 1}
+	if err := CreateLessonMaterial(ctx, lesson.ID, &material); err != nil {
+		t.Fatal(err)
+	}
+
+	// the lesson is edited after it was read for packing.
+	stale := lesson
+	edited := lesson
+	edited.Title = "edited"
+	if err := UpdateLesson(ctx, &edited); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := PackLesson(ctx, &stale, material); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetLessonByID(ctx, lesson.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "edited" {
+		t.Errorf("the edit while packing is lost: %q", got.Title)
+	}
+	if got.Revision != edited.Revision {
+		t.Errorf("revision = %d, want %d kept by packing", got.Revision, edited.Revision)
+	}
+	if !got.IsPacked || got.SizeInBytes == 0 {
+		t.Errorf("the package is not recorded: %v %d", got.IsPacked, got.SizeInBytes)
+	}
+}
+
+func TestPackLessonUploadsZip(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lesson, _ := createPackedLesson(t)
+	material, err := repositories.LessonMaterials.Get(ctx, lesson.ID, lesson.MaterialID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := PackLesson(ctx, &lesson, material); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath := fmt.Sprintf("lesson/%d.zip", lesson.ID)
+	uploaded, err := infrastructure.GetFile(ctx, infrastructure.MaterialBucketName(), zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lesson.SizeInBytes != int64(len(uploaded)) {
+		t.Errorf("size %d, want %d uploaded", lesson.SizeInBytes, len(uploaded))
+	}
+
+	if _, err := ImportLesson(ctx, User{ID: 2}, bytes.NewReader(uploaded), int64(len(uploaded))); err != nil {
+		t.Errorf("the uploaded zip is not importable: %v", err)
+	}
+}
+
+func TestPackLessonMissingAssets(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+
+	lesson := Lesson{UserID: 1}
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+	material := LessonMaterial{UserID: 1, Speeches: []LessonSpeech{{VoiceID: 5}}}
+	if err := CreateLessonMaterial(ctx, lesson.ID, &material); err != nil {
+		t.Fatal(err)
+	}
+
+	err := PackLesson(ctx, &lesson, material)
+	if missing, ok := err.(MissingLessonAssets); !ok || len(missing) != 1 || missing[0].ID != 5 {
+		t.Fatalf("got %v, want the missing voice", err)
+	}
+
+	zipPath := fmt.Sprintf("lesson/%d.zip", lesson.ID)
+	if _, err := infrastructure.StatFile(ctx, infrastructure.MaterialBucketName(), zipPath); !errors.Is(err, infrastructure.ErrBlobNotExist) {
+		t.Errorf("the zip is uploaded: %v", err)
+	}
+}
@@ -0,0 +1,280 @@
+package domain
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+const maxLessonReviewers = 10
+
+type LessonReviewErrorCode uint
+
+const (
+	SelfReviewNotAllowed        LessonReviewErrorCode = 1
+	TooManyLessonReviewers      LessonReviewErrorCode = 2
+	LessonReviewNotRequested    LessonReviewErrorCode = 3
+	InvalidLessonReviewStatus   LessonReviewErrorCode = 4
+	LessonReviewCommentNotFound LessonReviewErrorCode = 5
+)
+
+func (e LessonReviewErrorCode) Error() string {
+	switch e {
+	case SelfReviewNotAllowed:
+		return "self review not allowed"
+	case TooManyLessonReviewers:
+		return "too many reviewers of lesson"
+	case LessonReviewNotRequested:
+		return "lesson review not requested"
+	case InvalidLessonReviewStatus:
+		return "invalid status of lesson review"
+	case LessonReviewCommentNotFound:
+		return "lesson review comment not found"
+	default:
+		return "unknown lesson review error"
+	}
+}
+
+// LessonReviewComment is the comment by the reviewer or the author at the position of the timeline.
+type LessonReviewComment struct {
+	ID          int64     `json:"id" datastore:"-"`
+	UserID      int64     `json:"userID"`
+	ElapsedTime float32   `json:"elapsedTime"`
+	Body        string    `json:"body" datastore:",noindex"`
+	Created     time.Time `json:"created"`
+}
+
+// IsReviewer returns true when the review of the lesson is requested to the user, the reviewers can read the draft.
+func (l Lesson) IsReviewer(userID int64) bool {
+	_, ok := l.reviewIndex(userID)
+	return ok
+}
+
+// HasApprovedReview returns true when one of the reviewers approves the lesson.
+func (l Lesson) HasApprovedReview() bool {
+	for _, review := range l.Reviews {
+		if review.Status == LessonReviewStatusApproved {
+			return true
+		}
+	}
+	return false
+}
+
+func (l Lesson) reviewIndex(userID int64) (int, bool) {
+	for i, review := range l.Reviews {
+		if review.ReviewerUserID == userID {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// RequestLessonReview requests the review to the user, the review requested again goes back to requested.
+// the lesson is updated without increasing the revision, so that the editor can save the lesson being reviewed.
+func RequestLessonReview(ctx context.Context, lessonID int64, reviewerUserID int64) (Lesson, error) {
+	var lesson Lesson
+	err := repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if lesson, err = repositories.Lessons.Get(ctx, lessonID); err != nil {
+			return err
+		}
+
+		if lesson.UserID == reviewerUserID {
+			return SelfReviewNotAllowed
+		}
+
+		if _, err := GetUserByID(ctx, reviewerUserID); err != nil {
+			return err
+		}
+
+		currentTime := time.Now()
+		if i, ok := lesson.reviewIndex(reviewerUserID); ok {
+			lesson.Reviews[i].Status = LessonReviewStatusRequested
+			lesson.Reviews[i].Comment = ""
+			lesson.Reviews[i].Updated = currentTime
+		} else if len(lesson.Reviews) >= maxLessonReviewers {
+			return TooManyLessonReviewers
+		} else {
+			lesson.Reviews = append(lesson.Reviews, LessonReview{
+				ReviewerUserID: reviewerUserID,
+				Status:         LessonReviewStatusRequested,
+				Created:        currentTime,
+				Updated:        currentTime,
+			})
+		}
+
+		return repositories.Lessons.Put(ctx, &lesson)
+	})
+
+	return lesson, err
+}
+
+// CancelLessonReview removes the reviewer from the lesson, the reviewer can't read the draft any more.
+func CancelLessonReview(ctx context.Context, lessonID int64, reviewerUserID int64) (Lesson, error) {
+	var lesson Lesson
+	err := repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if lesson, err = repositories.Lessons.Get(ctx, lessonID); err != nil {
+			return err
+		}
+
+		i, ok := lesson.reviewIndex(reviewerUserID)
+		if !ok {
+			return LessonReviewNotRequested
+		}
+		lesson.Reviews = append(lesson.Reviews[:i], lesson.Reviews[i+1:]...)
+
+		return repositories.Lessons.Put(ctx, &lesson)
+	})
+
+	return lesson, err
+}
+
+// DecideLessonReview sets the lesson approved or changes requested by the reviewer with the comment.
+func DecideLessonReview(ctx context.Context, lessonID int64, reviewerUserID int64, status LessonReviewStatus, comment string) (LessonReview, error) {
+	if status != LessonReviewStatusApproved && status != LessonReviewStatusChangesRequested {
+		return LessonReview{}, InvalidLessonReviewStatus
+	}
+
+	var review LessonReview
+	err := repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		lesson, err := repositories.Lessons.Get(ctx, lessonID)
+		if err != nil {
+			return err
+		}
+
+		i, ok := lesson.reviewIndex(reviewerUserID)
+		if !ok {
+			return LessonReviewNotRequested
+		}
+
+		lesson.Reviews[i].Status = status
+		lesson.Reviews[i].Comment = comment
+		lesson.Reviews[i].Updated = time.Now()
+		review = lesson.Reviews[i]
+
+		return repositories.Lessons.Put(ctx, &lesson)
+	})
+
+	return review, err
+}
+
+// isLessonReviewApproved returns false when the author requires the approval and no reviewer approves the lesson.
+func isLessonReviewApproved(ctx context.Context, lesson Lesson) (bool, error) {
+	author, err := repositories.Users.Get(ctx, lesson.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	return !author.RequiresReviewApproval || lesson.HasApprovedReview(), nil
+}
+
+// GetLessonReviewComments returns the comments in order of the timeline, and the comments at the same position in order of oldest first.
+func GetLessonReviewComments(ctx context.Context, lessonID int64) ([]LessonReviewComment, error) {
+	comments, err := repositories.LessonReviewComments.ListByLessonID(ctx, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		if comments[i].ElapsedTime != comments[j].ElapsedTime {
+			return comments[i].ElapsedTime < comments[j].ElapsedTime
+		}
+		return comments[i].Created.Before(comments[j].Created)
+	})
+
+	return comments, nil
+}
+
+func CreateLessonReviewComment(ctx context.Context, lessonID int64, comment *LessonReviewComment) error {
+	comment.Created = time.Now()
+
+	return repositories.LessonReviewComments.Create(ctx, lessonID, comment)
+}
+
+// DeleteLessonReviewComment deletes the comment written by the user.
+func DeleteLessonReviewComment(ctx context.Context, lessonID int64, id int64, userID int64) error {
+	return repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		comment, err := repositories.LessonReviewComments.Get(ctx, lessonID, id)
+		if err == ErrNoSuchEntity || (err == nil && comment.UserID != userID) {
+			return LessonReviewCommentNotFound
+		} else if err != nil {
+			return err
+		}
+
+		return repositories.LessonReviewComments.Delete(ctx, lessonID, id)
+	})
+}
+
+// GetLessonsByReviewerUserID returns the page of lessons whose review is requested to the user, and the cursor of next page.
+func GetLessonsByReviewerUserID(ctx context.Context, userID int64, page Page) ([]Lesson, string, error) {
+	lessons, nextCursor, err := repositories.Lessons.ListByReviewerUserID(ctx, userID, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	lessons = excludeDeletingLessons(lessons)
+
+	for i := range lessons {
+		if err := SetLessonThumbnailURL(ctx, &lessons[i]); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return lessons, nextCursor, nil
+}
+
+type datastoreLessonReviewCommentRepository struct {
+	datastoreStore
+}
+
+func (r datastoreLessonReviewCommentRepository) Get(ctx context.Context, lessonID int64, id int64) (LessonReviewComment, error) {
+	var comment LessonReviewComment
+
+	key := datastore.IDKey("LessonReviewComment", id, datastore.IDKey("Lesson", lessonID, nil))
+	if err := r.get(ctx, key, &comment); err != nil {
+		return comment, err
+	}
+
+	comment.ID = id
+
+	return comment, nil
+}
+
+func (r datastoreLessonReviewCommentRepository) ListByLessonID(ctx context.Context, lessonID int64) ([]LessonReviewComment, error) {
+	var comments []LessonReviewComment
+
+	query := datastore.NewQuery("LessonReviewComment").Ancestor(datastore.IDKey("Lesson", lessonID, nil))
+	keys, err := r.client.GetAll(ctx, query, &comments)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, key := range keys {
+		comments[i].ID = key.ID
+	}
+
+	return comments, nil
+}
+
+func (r datastoreLessonReviewCommentRepository) Create(ctx context.Context, lessonID int64, comment *LessonReviewComment) error {
+	key := datastore.IncompleteKey("LessonReviewComment", datastore.IDKey("Lesson", lessonID, nil))
+	putKey, err := r.put(ctx, key, comment)
+	if err != nil {
+		return err
+	}
+
+	comment.ID = putKey.ID
+
+	return nil
+}
+
+func (r datastoreLessonReviewCommentRepository) Delete(ctx context.Context, lessonID int64, id int64) error {
+	return r.delete(ctx, datastore.IDKey("LessonReviewComment", id, datastore.IDKey("Lesson", lessonID, nil)))
+}
+
+func (r datastoreLessonReviewCommentRepository) DeleteByLessonID(ctx context.Context, lessonID int64) error {
+	return r.deleteChildren(ctx, "LessonReviewComment", datastore.IDKey("Lesson", lessonID, nil))
+}
@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func createReviewers(t *testing.T, n int) []User {
+	ctx := context.Background()
+
+	reviewers := make([]User, n)
+	for i := range reviewers {
+		reviewers[i].ProviderID = fmt.Sprintf("reviewer_%d", i)
+		if err := repositories.Users.Create(ctx, &reviewers[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return reviewers
+}
+
+func TestRequestLessonReview(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lesson := createPublishableLesson(t)
+	reviewers := createReviewers(t, maxLessonReviewers+1)
+
+	if _, err := RequestLessonReview(ctx, lesson.ID+1, reviewers[0].ID); err != ErrNoSuchEntity {
+		t.Errorf("lesson not found: got %v", err)
+	}
+	if _, err := RequestLessonReview(ctx, lesson.ID, reviewers[len(reviewers)-1].ID+1); err != UserNotFound {
+		t.Errorf("reviewer not found: got %v", err)
+	}
+	if _, err := RequestLessonReview(ctx, lesson.ID, lesson.UserID); err != SelfReviewNotAllowed {
+		t.Errorf("self review: got %v", err)
+	}
+
+	for _, reviewer := range reviewers[:maxLessonReviewers] {
+		if _, err := RequestLessonReview(ctx, lesson.ID, reviewer.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := RequestLessonReview(ctx, lesson.ID, reviewers[maxLessonReviewers].ID); err != TooManyLessonReviewers {
+		t.Errorf("too many reviewers: got %v", err)
+	}
+
+	// the review decided is requested again without adding the reviewer.
+	if _, err := DecideLessonReview(ctx, lesson.ID, reviewers[0].ID, LessonReviewStatusChangesRequested, "fix the title"); err != nil {
+		t.Fatal(err)
+	}
+	requested, err := RequestLessonReview(ctx, lesson.ID, reviewers[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requested.Reviews) != maxLessonReviewers {
+		t.Errorf("got %d reviewers, want %d", len(requested.Reviews), maxLessonReviewers)
+	}
+	if review := requested.Reviews[0]; review.Status != LessonReviewStatusRequested || review.Comment != "" {
+		t.Errorf("the review is not requested again: %+v", review)
+	}
+	if requested.Revision != lesson.Revision {
+		t.Errorf("the revision is changed from %d to %d", lesson.Revision, requested.Revision)
+	}
+}
+
+func TestDecideLessonReview(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lesson := createPublishableLesson(t)
+	reviewers := createReviewers(t, 2)
+
+	if _, err := RequestLessonReview(ctx, lesson.ID, reviewers[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecideLessonReview(ctx, lesson.ID, reviewers[1].ID, LessonReviewStatusApproved, ""); err != LessonReviewNotRequested {
+		t.Errorf("not reviewer: got %v", err)
+	}
+	if _, err := DecideLessonReview(ctx, lesson.ID, lesson.UserID, LessonReviewStatusApproved, ""); err != LessonReviewNotRequested {
+		t.Errorf("author: got %v", err)
+	}
+	if _, err := DecideLessonReview(ctx, lesson.ID, reviewers[0].ID, LessonReviewStatusRequested, ""); err != InvalidLessonReviewStatus {
+		t.Errorf("requested by reviewer: got %v", err)
+	}
+
+	if _, err := CancelLessonReview(ctx, lesson.ID, reviewers[1].ID); err != LessonReviewNotRequested {
+		t.Errorf("cancel not requested: got %v", err)
+	}
+	if _, err := CancelLessonReview(ctx, lesson.ID, reviewers[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecideLessonReview(ctx, lesson.ID, reviewers[0].ID, LessonReviewStatusApproved, ""); err != LessonReviewNotRequested {
+		t.Errorf("canceled reviewer: got %v", err)
+	}
+
+	canceled, err := repositories.Lessons.Get(ctx, lesson.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canceled.IsReviewer(reviewers[0].ID) {
+		t.Error("the canceled reviewer can still read the draft")
+	}
+}
+
+func TestLessonReviewApprovalToPublish(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lesson := createPublishableLesson(t)
+	reviewers := createReviewers(t, 2)
+
+	author, err := repositories.Users.Get(ctx, lesson.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	author.RequiresReviewApproval = true
+	if err := UpdateUser(ctx, &author); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, reviewer := range reviewers {
+		if _, err := RequestLessonReview(ctx, lesson.ID, reviewer.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := updateLessonStatus(ctx, lesson, LessonStatusLimited); err != nil {
+		t.Fatal(err)
+	}
+
+	unapproved := UnmetLessonRequirements{{Requirement: LessonRequirementReviewApproval}}
+	if _, err := updateLessonStatus(ctx, lesson, LessonStatusPublic); !reflect.DeepEqual(err, unapproved) {
+		t.Errorf("no decision: got %v", err)
+	}
+
+	if _, err := DecideLessonReview(ctx, lesson.ID, reviewers[0].ID, LessonReviewStatusChangesRequested, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := updateLessonStatus(ctx, lesson, LessonStatusPublic); !reflect.DeepEqual(err, unapproved) {
+		t.Errorf("changes requested: got %v", err)
+	}
+
+	// one approval is enough even if another reviewer requests changes.
+	if _, err := DecideLessonReview(ctx, lesson.ID, reviewers[1].ID, LessonReviewStatusApproved, "LGTM"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := updateLessonStatus(ctx, lesson, LessonStatusPublic); err != nil {
+		t.Errorf("approved: got %v", err)
+	}
+}
+
+func TestLessonReviewComments(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lesson := createPublishableLesson(t)
+	reviewer := createReviewers(t, 1)[0]
+
+	comments := []LessonReviewComment{
+		{UserID: reviewer.ID, ElapsedTime: 10, Body: "later"},
+		{UserID: lesson.UserID, ElapsedTime: 2, Body: "first"},
+		{UserID: reviewer.ID, ElapsedTime: 2, Body: "second"},
+	}
+	for i := range comments {
+		if err := CreateLessonReviewComment(ctx, lesson.ID, &comments[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := DeleteLessonReviewComment(ctx, lesson.ID, comments[0].ID, lesson.UserID); err != LessonReviewCommentNotFound {
+		t.Errorf("comment of another user: got %v", err)
+	}
+	if err := DeleteLessonReviewComment(ctx, lesson.ID, comments[2].ID+100, reviewer.ID); err != LessonReviewCommentNotFound {
+		t.Errorf("comment not found: got %v", err)
+	}
+	if err := DeleteLessonReviewComment(ctx, lesson.ID+1, comments[0].ID, reviewer.ID); err != LessonReviewCommentNotFound {
+		t.Errorf("comment of another lesson: got %v", err)
+	}
+	if err := DeleteLessonReviewComment(ctx, lesson.ID, comments[0].ID, reviewer.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetLessonReviewComments(ctx, lesson.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bodies []string
+	for _, comment := range got {
+		bodies = append(bodies, comment.Body)
+	}
+	if want := []string{"first", "second"}; !reflect.DeepEqual(bodies, want) {
+		t.Errorf("got %v, want %v", bodies, want)
+	}
+}
@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type LessonScheduleErrorCode uint
+
+const (
+	InvalidLessonSchedule      LessonScheduleErrorCode = 1
+	LessonScheduleNotAvailable LessonScheduleErrorCode = 2
+)
+
+func (e LessonScheduleErrorCode) Error() string {
+	switch e {
+	case InvalidLessonSchedule:
+		return "invalid schedule of lesson"
+	case LessonScheduleNotAvailable:
+		return "schedule not available for the status of lesson"
+	default:
+		return "unknown lesson schedule error"
+	}
+}
+
+// LessonScheduleResult is the lessons changed by the schedules, and the lessons failed to change with the reasons.
+type LessonScheduleResult struct {
+	Published   []int64                 `json:"published"`
+	Unpublished []int64                 `json:"unpublished"`
+	Failed      []LessonScheduleFailure `json:"failed"`
+}
+
+type LessonScheduleFailure struct {
+	LessonID int64  `json:"lessonID"`
+	Error    string `json:"error"`
+}
+
+// checkLessonSchedule returns InvalidLessonSchedule when the lesson is unpublished before it is published,
+// and LessonScheduleNotAvailable when the schedules can't change the status, e.g. publishing the draft.
+// the schedules are checked only when they or the status are changed, so the lessons saved before are still editable.
+func checkLessonSchedule(oldLesson Lesson, newLesson Lesson) error {
+	if oldLesson.Status == newLesson.Status && oldLesson.PublishAt.Equal(newLesson.PublishAt) && oldLesson.UnpublishAt.Equal(newLesson.UnpublishAt) {
+		return nil
+	}
+
+	if !newLesson.PublishAt.IsZero() && !newLesson.UnpublishAt.IsZero() && !newLesson.UnpublishAt.After(newLesson.PublishAt) {
+		return InvalidLessonSchedule
+	}
+
+	status := newLesson.Status
+	if !newLesson.PublishAt.IsZero() {
+		if !isLessonStatusTransitionAllowed(status, LessonStatusPublic) {
+			return LessonScheduleNotAvailable
+		}
+		status = LessonStatusPublic
+	}
+	if !newLesson.UnpublishAt.IsZero() && !isLessonStatusTransitionAllowed(status, LessonStatusDraft) {
+		return LessonScheduleNotAvailable
+	}
+
+	return nil
+}
+
+// ApplyLessonSchedules makes the lessons public or draft when PublishAt or UnpublishAt is due at now.
+// each schedule is cleared in the transaction applying it, so this is safe to run again or on multiple instances at the same time.
+// the due schedules of the lessons not meeting the requirements are cleared and reported in Failed, so they are not retried until the author sets them again.
+func ApplyLessonSchedules(ctx context.Context, now time.Time) (LessonScheduleResult, error) {
+	result := LessonScheduleResult{Published: []int64{}, Unpublished: []int64{}, Failed: []LessonScheduleFailure{}}
+
+	ids, err := repositories.Lessons.ListScheduledIDs(ctx, now)
+	if err != nil {
+		return result, err
+	}
+
+	for _, id := range ids {
+		lesson, changed, err := applyLessonSchedule(ctx, id, now)
+		if _, ok := err.(UnmetLessonRequirements); ok || err == InvalidLessonStatusTransition {
+			if err := clearLessonSchedule(ctx, id, now); err != nil {
+				return result, err
+			}
+			result.Failed = append(result.Failed, LessonScheduleFailure{LessonID: id, Error: err.Error()})
+			continue
+		} else if err != nil {
+			return result, err
+		}
+
+		if !changed {
+			continue // applied by another instance.
+		}
+
+		if lesson.Status == LessonStatusPublic {
+			result.Published = append(result.Published, id)
+		} else {
+			result.Unpublished = append(result.Unpublished, id)
+		}
+
+		if err := IndexLesson(ctx, lesson); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// applyLessonSchedule applies the due schedules of the lesson by the same update as the editor, so the requirements and Published are handled in the same way.
+// when both are due, the later one decides the status.
+func applyLessonSchedule(ctx context.Context, id int64, now time.Time) (Lesson, bool, error) {
+	var lesson Lesson
+	var changed bool
+
+	err := repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		changed = false
+
+		current, err := repositories.Lessons.Get(ctx, id)
+		if err == ErrNoSuchEntity || (err == nil && current.IsDeleting) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		isPublishDue := isLessonScheduleDue(current.PublishAt, now)
+		isUnpublishDue := isLessonScheduleDue(current.UnpublishAt, now)
+		if !isPublishDue && !isUnpublishDue {
+			return nil
+		}
+
+		patch := MergePatch{}
+		status := LessonStatusPublic
+		if isPublishDue {
+			if err := patch.Set("publishAt", nil); err != nil {
+				return err
+			}
+		}
+		if isUnpublishDue {
+			if err := patch.Set("unpublishAt", nil); err != nil {
+				return err
+			}
+			if !isPublishDue || current.UnpublishAt.After(current.PublishAt) {
+				status = LessonStatusDraft
+			}
+		}
+		if err := patch.Set("status", status); err != nil {
+			return err
+		}
+
+		if lesson, err = updateLessonInTransaction(ctx, id, AnyRevision, patch); err != nil {
+			return err
+		}
+		changed = true
+
+		return nil
+	})
+
+	return lesson, changed, err
+}
+
+// clearLessonSchedule clears the due schedules of the lesson without changing the status.
+func clearLessonSchedule(ctx context.Context, id int64, now time.Time) error {
+	return repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		current, err := repositories.Lessons.Get(ctx, id)
+		if err == ErrNoSuchEntity || (err == nil && current.IsDeleting) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		patch := MergePatch{}
+		if isLessonScheduleDue(current.PublishAt, now) {
+			if err := patch.Set("publishAt", nil); err != nil {
+				return err
+			}
+		}
+		if isLessonScheduleDue(current.UnpublishAt, now) {
+			if err := patch.Set("unpublishAt", nil); err != nil {
+				return err
+			}
+		}
+		if len(patch) == 0 {
+			return nil // cleared by another instance.
+		}
+
+		_, err = updateLessonInTransaction(ctx, id, AnyRevision, patch)
+		return err
+	})
+}
+
+func isLessonScheduleDue(at time.Time, now time.Time) bool {
+	return !at.IsZero() && !at.After(now)
+}
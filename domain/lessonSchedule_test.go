@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// scheduleLesson shares the lesson as limited and sets the schedules by the same update as the editor, the zero time is not set.
+func scheduleLesson(t *testing.T, lesson Lesson, publishAt time.Time, unpublishAt time.Time) {
+	ctx := context.Background()
+
+	if _, err := updateLessonStatus(ctx, lesson, LessonStatusLimited); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := updateLessonSchedule(ctx, lesson, publishAt, unpublishAt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func updateLessonSchedule(ctx context.Context, lesson Lesson, publishAt time.Time, unpublishAt time.Time) (Lesson, error) {
+	patch := MergePatch{}
+	for name, at := range map[string]time.Time{"publishAt": publishAt, "unpublishAt": unpublishAt} {
+		if at.IsZero() {
+			continue
+		}
+		if err := patch.Set(name, at); err != nil {
+			return lesson, err
+		}
+	}
+	updated, _, err := UpdateLessonAndMaterial(ctx, lesson.ID, lesson.MaterialID, AnyRevision, AnyRevision, patch, nil)
+	return updated, err
+}
+
+func TestApplyLessonSchedules(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	now := time.Date(2021, 4, 10, 12, 0, 0, 0, time.UTC)
+
+	publishable := createPublishableLesson(t)
+	scheduleLesson(t, publishable, now.Add(-time.Minute), time.Time{})
+
+	// the lesson without the title does not meet the requirements of public.
+	unmet := Lesson{UserID: publishable.UserID, SubjectID: 1, JapaneseCategoryID: 1}
+	if err := CreateLesson(ctx, &unmet); err != nil {
+		t.Fatal(err)
+	}
+	unpublishAt := now.Add(time.Hour)
+	scheduleLesson(t, unmet, now.Add(-time.Minute), unpublishAt)
+
+	result, err := ApplyLessonSchedules(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Published, []int64{publishable.ID}) {
+		t.Errorf("published %v, want %v", result.Published, []int64{publishable.ID})
+	}
+	if len(result.Failed) != 1 || result.Failed[0].LessonID != unmet.ID {
+		t.Fatalf("failed %v, want %d", result.Failed, unmet.ID)
+	}
+
+	got, err := repositories.Lessons.Get(ctx, unmet.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != LessonStatusLimited || !got.PublishAt.IsZero() {
+		t.Errorf("the failed schedule is not cleared: %s %v", got.Status, got.PublishAt)
+	}
+	if !got.UnpublishAt.Equal(unpublishAt) {
+		t.Errorf("the schedule not due is cleared: %v", got.UnpublishAt)
+	}
+
+	// the failed lesson is not retried.
+	result, err = ApplyLessonSchedules(ctx, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Published) != 0 || len(result.Failed) != 0 {
+		t.Errorf("the schedules are applied again: %+v", result)
+	}
+}
+
+func TestLessonScheduleRejectsUnavailableStatus(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lesson := createPublishableLesson(t)
+	at := time.Date(2021, 4, 10, 12, 0, 0, 0, time.UTC)
+
+	// the draft can't be public directly.
+	if _, err := updateLessonSchedule(ctx, lesson, at, time.Time{}); err != LessonScheduleNotAvailable {
+		t.Errorf("publishAt of draft: got %v, want LessonScheduleNotAvailable", err)
+	}
+	if _, err := updateLessonSchedule(ctx, lesson, time.Time{}, at); err != LessonScheduleNotAvailable {
+		t.Errorf("unpublishAt of draft: got %v, want LessonScheduleNotAvailable", err)
+	}
+
+	scheduleLesson(t, lesson, at, time.Time{})
+	if _, err := updateLessonSchedule(ctx, lesson, time.Time{}, at.Add(-time.Hour)); err != InvalidLessonSchedule {
+		t.Errorf("unpublishAt before publishAt: got %v, want InvalidLessonSchedule", err)
+	}
+	if _, err := updateLessonStatus(ctx, lesson, LessonStatusDraft); err != LessonScheduleNotAvailable {
+		t.Errorf("draft leaving publishAt: got %v, want LessonScheduleNotAvailable", err)
+	}
+
+	// the public lesson is only unpublished.
+	if _, err := updateLessonStatus(ctx, lesson, LessonStatusPublic); err != LessonScheduleNotAvailable {
+		t.Errorf("public leaving publishAt: got %v, want LessonScheduleNotAvailable", err)
+	}
+	patch := MergePatch{}
+	if err := patch.Set("publishAt", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := patch.Set("status", LessonStatusPublic); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := UpdateLessonAndMaterial(ctx, lesson.ID, lesson.MaterialID, AnyRevision, AnyRevision, patch, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := updateLessonSchedule(ctx, lesson, time.Time{}, at); err != nil {
+		t.Errorf("unpublishAt of public: %v", err)
+	}
+}
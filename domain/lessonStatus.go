@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+type LessonStatusErrorCode uint
+
+const (
+	InvalidLessonStatusTransition LessonStatusErrorCode = 1
+)
+
+func (e LessonStatusErrorCode) Error() string {
+	switch e {
+	case InvalidLessonStatusTransition:
+		return "invalid transition of lesson status"
+	default:
+		return "unknown lesson status error"
+	}
+}
+
+// lessonStatusTransitions is the statuses each status can change to, the lesson keeping the status is always allowed.
+// the draft is shared as limited before it becomes public, and the limited or public lesson goes back to be edited or shared again.
+var lessonStatusTransitions = map[LessonStatus][]LessonStatus{
+	LessonStatusDraft:   {LessonStatusLimited},
+	LessonStatusLimited: {LessonStatusPublic, LessonStatusDraft},
+	LessonStatusPublic:  {LessonStatusDraft, LessonStatusLimited},
+}
+
+const (
+	LessonRequirementMaterial       = "material"
+	LessonRequirementDuration       = "durationSec"
+	LessonRequirementAssets         = "assets"
+	LessonRequirementTitle          = "title"
+	LessonRequirementSubject        = "subject"
+	LessonRequirementCategory       = "japaneseCategory"
+	LessonRequirementThumbnail      = "thumbnail"
+	LessonRequirementReviewApproval = "reviewApproval"
+)
+
+// UnmetLessonRequirement is the requirement to make the lesson public, Assets is only for the missing assets.
+type UnmetLessonRequirement struct {
+	Requirement string        `json:"requirement"`
+	Assets      []LessonAsset `json:"assets,omitempty"`
+}
+
+// UnmetLessonRequirements is returned when the lesson can't be public, with all requirements not met.
+type UnmetLessonRequirements []UnmetLessonRequirement
+
+func (e UnmetLessonRequirements) Error() string {
+	requirements := make([]string, len(e))
+	for i, requirement := range e {
+		requirements[i] = requirement.Requirement
+	}
+	return "unmet requirements of lesson: " + strings.Join(requirements, ", ")
+}
+
+// checkLessonStatusTransition returns InvalidLessonStatusTransition for the transition not allowed,
+// and UnmetLessonRequirements when the lesson becomes public without meeting the requirements.
+func checkLessonStatusTransition(ctx context.Context, oldLesson Lesson, newLesson Lesson) error {
+	if oldLesson.Status == newLesson.Status {
+		return nil
+	}
+
+	if !isLessonStatusTransitionAllowed(oldLesson.Status, newLesson.Status) {
+		return InvalidLessonStatusTransition
+	}
+
+	if newLesson.Status != LessonStatusPublic {
+		return nil
+	}
+
+	unmet, err := unmetLessonRequirements(ctx, newLesson)
+	if err != nil {
+		return err
+	}
+
+	if len(unmet) > 0 {
+		return unmet
+	}
+
+	return nil
+}
+
+func isLessonStatusTransitionAllowed(from LessonStatus, to LessonStatus) bool {
+	for _, status := range lessonStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// unmetLessonRequirements returns the requirements to make the lesson public which it doesn't meet.
+func unmetLessonRequirements(ctx context.Context, lesson Lesson) (UnmetLessonRequirements, error) {
+	var unmet UnmetLessonRequirements
+	addUnmet := func(requirement string) {
+		unmet = append(unmet, UnmetLessonRequirement{Requirement: requirement})
+	}
+
+	// the duration is saved to the material by the editor.
+	var durationSec float32
+	if lesson.MaterialID == 0 {
+		addUnmet(LessonRequirementMaterial)
+	} else {
+		material, err := repositories.LessonMaterials.Get(ctx, lesson.ID, lesson.MaterialID)
+		if err == ErrNoSuchEntity {
+			addUnmet(LessonRequirementMaterial)
+		} else if err != nil {
+			return nil, err
+		} else {
+			material.ID = lesson.MaterialID
+			durationSec = material.DurationSec
+			missing, err := findMissingLessonAssets(ctx, lesson, material)
+			if err != nil {
+				return nil, err
+			}
+			if len(missing) > 0 {
+				unmet = append(unmet, UnmetLessonRequirement{Requirement: LessonRequirementAssets, Assets: missing})
+			}
+		}
+	}
+
+	if durationSec <= 0 {
+		addUnmet(LessonRequirementDuration)
+	}
+
+	if strings.TrimSpace(lesson.Title) == "" {
+		addUnmet(LessonRequirementTitle)
+	}
+
+	if lesson.SubjectID == 0 {
+		addUnmet(LessonRequirementSubject)
+	}
+
+	if lesson.JapaneseCategoryID == 0 {
+		addUnmet(LessonRequirementCategory)
+	}
+
+	if lesson.HasThumbnail {
+		exists, err := publicLessonThumbnailExists(ctx, lesson.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			addUnmet(LessonRequirementThumbnail)
+		}
+	}
+
+	approved, err := isLessonReviewApproved(ctx, lesson)
+	if err != nil {
+		return nil, err
+	}
+	if !approved {
+		addUnmet(LessonRequirementReviewApproval)
+	}
+
+	return unmet, nil
+}
+
+// publicLessonThumbnailExists returns true when the thumbnail is uploaded to the public bucket, which is shown for the public lesson.
+func publicLessonThumbnailExists(ctx context.Context, lessonID int64) (bool, error) {
+	filePath := infrastructure.StorageObjectFilePath("lesson_thumbnail", strconv.FormatInt(lessonID, 10), "png")
+	_, err := infrastructure.StatFile(ctx, infrastructure.PublicBucketName(), filePath)
+	if errors.Is(err, infrastructure.ErrBlobNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,216 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+func updateLessonStatus(ctx context.Context, lesson Lesson, status LessonStatus) (Lesson, error) {
+	patch := MergePatch{}
+	if err := patch.Set("status", status); err != nil {
+		return lesson, err
+	}
+	updated, _, err := UpdateLessonAndMaterial(ctx, lesson.ID, lesson.MaterialID, AnyRevision, AnyRevision, patch, nil)
+	return updated, err
+}
+
+// createPublishableLesson creates the draft meeting all requirements of public.
+func createPublishableLesson(t *testing.T) Lesson {
+	ctx := context.Background()
+
+	user := User{}
+	if err := repositories.Users.Create(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+
+	lesson := Lesson{UserID: user.ID, Title: "title", SubjectID: 1, JapaneseCategoryID: 1}
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+	material := LessonMaterial{UserID: user.ID, DurationSec: 60}
+	if err := CreateLessonMaterial(ctx, lesson.ID, &material); err != nil {
+		t.Fatal(err)
+	}
+	lesson.MaterialID = material.ID
+	if err := UpdateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+
+	return lesson
+}
+
+func TestLessonStatusTransitions(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lesson := createPublishableLesson(t)
+
+	steps := []struct {
+		status LessonStatus
+		err    error
+	}{
+		{LessonStatusPublic, InvalidLessonStatusTransition}, // the draft is shared as limited first.
+		{LessonStatusLimited, nil},
+		{LessonStatusDraft, nil},
+		{LessonStatusLimited, nil},
+		{LessonStatusPublic, nil},
+		{LessonStatusLimited, nil},
+		{LessonStatusPublic, nil},
+		{LessonStatusDraft, nil},
+	}
+
+	for _, step := range steps {
+		before, err := repositories.Lessons.Get(ctx, lesson.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = updateLessonStatus(ctx, lesson, step.status)
+		if err != step.err {
+			t.Fatalf("%s to %s: got %v, want %v", before.Status, step.status, err, step.err)
+		}
+
+		after, err := repositories.Lessons.Get(ctx, lesson.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.err != nil && (after.Status != before.Status || after.Revision != before.Revision) {
+			t.Errorf("%s to %s: the rejected transition is saved", before.Status, step.status)
+		}
+		if step.err == nil && after.Status != step.status {
+			t.Errorf("%s to %s: status = %s", before.Status, step.status, after.Status)
+		}
+	}
+}
+
+func TestLessonStatusPublicThroughUpdates(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+
+	user := User{}
+	if err := repositories.Users.Create(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+
+	// the lesson and the material are saved in the same way as the API.
+	lesson := Lesson{UserID: user.ID}
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+	material := LessonMaterial{UserID: user.ID}
+	if err := CreateLessonMaterial(ctx, lesson.ID, &material); err != nil {
+		t.Fatal(err)
+	}
+	lesson.MaterialID = material.ID
+	if err := UpdateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+
+	lessonPatch := MergePatch{}
+	for name, value := range map[string]interface{}{"title": "title", "subjectID": 1, "japaneseCategoryID": 1, "status": LessonStatusLimited} {
+		if err := lessonPatch.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := UpdateLessonAndMaterial(ctx, lesson.ID, material.ID, AnyRevision, AnyRevision, lessonPatch, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := updateLessonStatus(ctx, lesson, LessonStatusPublic)
+	if want := (UnmetLessonRequirements{{Requirement: LessonRequirementDuration}}); !reflect.DeepEqual(err, want) {
+		t.Fatalf("got %v, want %v", err, want)
+	}
+
+	materialPatch := MergePatch{}
+	if err := materialPatch.Set("durationSec", 60); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpdateLessonMaterial(ctx, material.ID, lesson.ID, AnyRevision, materialPatch); err != nil {
+		t.Fatal(err)
+	}
+
+	published, err := updateLessonStatus(ctx, lesson, LessonStatusPublic)
+	if err != nil {
+		t.Fatalf("the lesson with the duration of material is rejected: %v", err)
+	}
+	if published.Status != LessonStatusPublic || published.Published.IsZero() {
+		t.Errorf("the lesson is not published: %s %v", published.Status, published.Published)
+	}
+}
+
+func TestLessonStatusPublicRequirements(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+
+	user := User{RequiresReviewApproval: true}
+	if err := repositories.Users.Create(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+	lesson := Lesson{UserID: user.ID, HasThumbnail: true}
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := updateLessonStatus(ctx, lesson, LessonStatusLimited); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := updateLessonStatus(ctx, lesson, LessonStatusPublic)
+	unmet, ok := err.(UnmetLessonRequirements)
+	if !ok {
+		t.Fatalf("got %v, want UnmetLessonRequirements", err)
+	}
+
+	var got []string
+	for _, requirement := range unmet {
+		got = append(got, requirement.Requirement)
+	}
+	want := []string{
+		LessonRequirementMaterial,
+		LessonRequirementDuration,
+		LessonRequirementTitle,
+		LessonRequirementSubject,
+		LessonRequirementCategory,
+		LessonRequirementThumbnail,
+		LessonRequirementReviewApproval,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLessonStatusPublicRequiresAssets(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lesson := createPublishableLesson(t)
+
+	material, err := repositories.LessonMaterials.Get(ctx, lesson.ID, lesson.MaterialID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	material.Speeches = []LessonSpeech{{VoiceID: 5}}
+	if err := repositories.LessonMaterials.Put(ctx, lesson.ID, &material); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := updateLessonStatus(ctx, lesson, LessonStatusLimited); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = updateLessonStatus(ctx, lesson, LessonStatusPublic)
+	want := UnmetLessonRequirements{{Requirement: LessonRequirementAssets, Assets: []LessonAsset{{Kind: LessonAssetVoice, ID: 5, Path: "voices/5.mp3", ContentType: "audio/mpeg"}}}}
+	if !reflect.DeepEqual(err, want) {
+		t.Fatalf("got %#v, want %#v", err, want)
+	}
+
+	// the lesson becomes public after the voice is uploaded.
+	voicePath := fmt.Sprintf("voice/%d/5.mp3", lesson.ID)
+	if err := infrastructure.CreateFile(ctx, infrastructure.MaterialBucketName(), voicePath, "audio/mpeg", []byte("voice")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := updateLessonStatus(ctx, lesson, LessonStatusPublic); err != nil {
+		t.Errorf("the lesson meeting the requirements is rejected: %v", err)
+	}
+}
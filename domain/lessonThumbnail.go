@@ -38,12 +38,12 @@ func CreateLessonThumbnailBlankFile(ctx context.Context, id int64, isPublic bool
 	var err error
 
 	if isPublic {
-		url, err = infrastructure.CreateBlankFileToPublicGCS(ctx, fileID, "lesson_thumbnail", fileRequest)
+		url, err = infrastructure.CreateBlankPublicFile(ctx, fileID, "lesson_thumbnail", fileRequest)
 		if err != nil {
 			return "", err
 		}
 	} else {
-		url, err = infrastructure.CreateBlankFileToGCS(ctx, fileID, "lesson_thumbnail", fileRequest)
+		url, err = infrastructure.CreateBlankFile(ctx, fileID, "lesson_thumbnail", fileRequest)
 		if err != nil {
 			return "", err
 		}
@@ -54,7 +54,7 @@ func CreateLessonThumbnailBlankFile(ctx context.Context, id int64, isPublic bool
 
 func createPublicURL(id int64) string {
 	fileID := strconv.FormatInt(id, 10)
-	return "https://storage.googleapis.com/" + infrastructure.PublicBucketName() + "/lesson_thumbnail/" + fileID + ".png"
+	return infrastructure.GetPublicURL(infrastructure.PublicBucketName(), "lesson_thumbnail/"+fileID+".png")
 }
 
 func createSignedURL(ctx context.Context, id int64) (string, error) {
@@ -62,7 +62,7 @@ func createSignedURL(ctx context.Context, id int64) (string, error) {
 	filePath := "lesson_thumbnail/" + fileID + ".png"
 	fileType := "" // this is unnecessary when GET request
 	bucketName := infrastructure.MaterialBucketName()
-	url, err := infrastructure.GetGCSSignedURL(ctx, bucketName, filePath, "GET", fileType)
+	url, err := infrastructure.GetSignedURL(ctx, bucketName, filePath, "GET", fileType)
 
 	if err != nil {
 		return "", err
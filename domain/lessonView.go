@@ -0,0 +1,327 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+const (
+	// lessonViewShards is the number of counters for each lesson and day, views are recorded to one of them at random.
+	lessonViewShards = 20
+	// lessonViewAggregationBatchSize is the number of shards aggregated at once.
+	lessonViewAggregationBatchSize = 500
+	maxLessonViewDays              = 90
+	lessonViewDayLayout            = "2006-01-02"
+)
+
+type LessonViewErrorCode uint
+
+const (
+	InvalidLessonViewDays LessonViewErrorCode = 1
+)
+
+func (e LessonViewErrorCode) Error() string {
+	switch e {
+	case InvalidLessonViewDays:
+		return "invalid days of lesson views"
+	default:
+		return "unknown lesson view error"
+	}
+}
+
+// LessonViewShard is the counter of views not aggregated to the lesson yet, it is deleted by the aggregation.
+type LessonViewShard struct {
+	Name     string `datastore:"-"` // "<lessonID>-<day>-<shard>"
+	LessonID int64  `datastore:",noindex"`
+	Day      string `datastore:",noindex"`
+	Count    int64  `datastore:",noindex"`
+}
+
+// LessonDailyViews is the views of the lesson in the day of UTC.
+type LessonDailyViews struct {
+	Day   string `json:"day" datastore:"-"`
+	Count int64  `json:"count" datastore:",noindex"`
+}
+
+// LessonViewer is the last view of the viewer, used to count the views from the same viewer in the window only once.
+type LessonViewer struct {
+	ID     string `datastore:"-"` // the hash of lesson and viewer.
+	Viewed time.Time
+}
+
+// lessonViewConfig is the window of deduplication.
+type lessonViewConfig struct {
+	mu           sync.RWMutex
+	dedupeWindow time.Duration
+}
+
+var viewConfig = &lessonViewConfig{dedupeWindow: 30 * time.Minute}
+
+// SetLessonViewDedupeWindow replaces the window counting the views from the same viewer only once.
+func SetLessonViewDedupeWindow(window time.Duration) {
+	viewConfig.mu.Lock()
+	defer viewConfig.mu.Unlock()
+
+	viewConfig.dedupeWindow = window
+}
+
+func lessonViewDedupeWindow() time.Duration {
+	viewConfig.mu.RLock()
+	defer viewConfig.mu.RUnlock()
+
+	return viewConfig.dedupeWindow
+}
+
+// RecordLessonView counts the view of the lesson by viewerKey such as user ID or IP address, returns false when it is deduplicated.
+// viewerKey is stored as the hash, and the view is added to the lesson by AggregateLessonViews later.
+func RecordLessonView(ctx context.Context, lessonID int64, viewerKey string) (bool, error) {
+	now := time.Now()
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%s", lessonID, viewerKey)))
+	viewerID := hex.EncodeToString(sum[:])
+
+	var counted bool
+	err := repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		counted = false
+
+		viewer, err := repositories.LessonViews.GetViewer(ctx, viewerID)
+		if err == nil && now.Sub(viewer.Viewed) < lessonViewDedupeWindow() {
+			return nil
+		} else if err != nil && err != ErrNoSuchEntity {
+			return err
+		}
+
+		if err := repositories.LessonViews.PutViewer(ctx, &LessonViewer{ID: viewerID, Viewed: now}); err != nil {
+			return err
+		}
+
+		day := now.UTC().Format(lessonViewDayLayout)
+		name := fmt.Sprintf("%d-%s-%d", lessonID, day, rand.Intn(lessonViewShards))
+		shard, err := repositories.LessonViews.GetShard(ctx, name)
+		if err == ErrNoSuchEntity {
+			shard = LessonViewShard{Name: name, LessonID: lessonID, Day: day}
+		} else if err != nil {
+			return err
+		}
+
+		shard.Count++
+		counted = true
+
+		return repositories.LessonViews.PutShard(ctx, &shard)
+	})
+
+	return counted, err
+}
+
+// AggregateLessonViews adds the views in the shards to ViewCount of the lessons and the daily views, and deletes the expired viewers.
+// this is safe to run on multiple instances at the same time, because each shard is deleted in the transaction adding it.
+func AggregateLessonViews(ctx context.Context) error {
+	type lessonDay struct {
+		lessonID int64
+		day      string
+	}
+
+	for {
+		shards, err := repositories.LessonViews.ListShards(ctx, lessonViewAggregationBatchSize)
+		if err != nil {
+			return err
+		}
+
+		shardNames := make(map[lessonDay][]string)
+		for _, shard := range shards {
+			key := lessonDay{lessonID: shard.LessonID, day: shard.Day}
+			shardNames[key] = append(shardNames[key], shard.Name)
+		}
+
+		for key, names := range shardNames {
+			if err := aggregateLessonViewShards(ctx, key.lessonID, key.day, names); err != nil {
+				return err
+			}
+		}
+
+		if len(shards) < lessonViewAggregationBatchSize {
+			break
+		}
+	}
+
+	return repositories.LessonViews.DeleteViewersBefore(ctx, time.Now().Add(-lessonViewDedupeWindow()))
+}
+
+// aggregateLessonViewShards adds the shards of the lesson and the day in a transaction, so it has at most lessonViewShards root entities and the entity group of the lesson.
+// the lesson is updated without increasing the revision, so that the editor can save the lesson viewed.
+// the shards of the lesson deleted or being deleted are discarded.
+func aggregateLessonViewShards(ctx context.Context, lessonID int64, day string, names []string) error {
+	var lesson Lesson
+	var total int64
+
+	err := repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		total = 0
+
+		var err error
+		lesson, err = repositories.Lessons.Get(ctx, lessonID)
+		isDiscarded := err == ErrNoSuchEntity || (err == nil && lesson.IsDeleting)
+		if err != nil && !isDiscarded {
+			return err
+		}
+
+		for _, name := range names {
+			shard, err := repositories.LessonViews.GetShard(ctx, name)
+			if err == ErrNoSuchEntity {
+				continue // aggregated by another instance.
+			} else if err != nil {
+				return err
+			}
+
+			total += shard.Count
+
+			if err := repositories.LessonViews.DeleteShard(ctx, name); err != nil {
+				return err
+			}
+		}
+
+		if isDiscarded || total == 0 {
+			total = 0
+			return nil
+		}
+
+		views, err := repositories.LessonViews.GetDailyViews(ctx, lessonID, []string{day})
+		if err != nil {
+			return err
+		}
+
+		views[0].Count += total
+		if err := repositories.LessonViews.PutDailyViews(ctx, lessonID, &views[0]); err != nil {
+			return err
+		}
+
+		lesson.ViewCount += total
+
+		return repositories.Lessons.Put(ctx, &lesson)
+	})
+	if err != nil || total == 0 {
+		return err
+	}
+
+	return IndexLesson(ctx, lesson)
+}
+
+// GetLessonDailyViews returns the views of each day in the last days of UTC in order of oldest first.
+func GetLessonDailyViews(ctx context.Context, lessonID int64, days int, now time.Time) ([]LessonDailyViews, error) {
+	if days < 1 || days > maxLessonViewDays {
+		return nil, InvalidLessonViewDays
+	}
+
+	dayNames := make([]string, days)
+	for i := range dayNames {
+		dayNames[i] = now.UTC().AddDate(0, 0, i-days+1).Format(lessonViewDayLayout)
+	}
+
+	return repositories.LessonViews.GetDailyViews(ctx, lessonID, dayNames)
+}
+
+type datastoreLessonViewRepository struct {
+	datastoreStore
+}
+
+func (r datastoreLessonViewRepository) GetViewer(ctx context.Context, id string) (LessonViewer, error) {
+	var viewer LessonViewer
+
+	if err := r.get(ctx, datastore.NameKey("LessonViewer", id, nil), &viewer); err != nil {
+		return viewer, err
+	}
+
+	viewer.ID = id
+
+	return viewer, nil
+}
+
+func (r datastoreLessonViewRepository) PutViewer(ctx context.Context, viewer *LessonViewer) error {
+	_, err := r.put(ctx, datastore.NameKey("LessonViewer", viewer.ID, nil), viewer)
+	return err
+}
+
+func (r datastoreLessonViewRepository) DeleteViewersBefore(ctx context.Context, before time.Time) error {
+	query := datastore.NewQuery("LessonViewer").Filter("Viewed <", before).KeysOnly()
+	keys, err := r.client.GetAll(ctx, query, nil)
+	if err != nil {
+		return err
+	}
+
+	return r.deleteMulti(ctx, keys)
+}
+
+func (r datastoreLessonViewRepository) GetShard(ctx context.Context, name string) (LessonViewShard, error) {
+	var shard LessonViewShard
+
+	if err := r.get(ctx, datastore.NameKey("LessonViewShard", name, nil), &shard); err != nil {
+		return shard, err
+	}
+
+	shard.Name = name
+
+	return shard, nil
+}
+
+func (r datastoreLessonViewRepository) ListShards(ctx context.Context, limit int) ([]LessonViewShard, error) {
+	var shards []LessonViewShard
+
+	keys, err := r.client.GetAll(ctx, datastore.NewQuery("LessonViewShard").Limit(limit), &shards)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, key := range keys {
+		shards[i].Name = key.Name
+	}
+
+	return shards, nil
+}
+
+func (r datastoreLessonViewRepository) PutShard(ctx context.Context, shard *LessonViewShard) error {
+	_, err := r.put(ctx, datastore.NameKey("LessonViewShard", shard.Name, nil), shard)
+	return err
+}
+
+func (r datastoreLessonViewRepository) DeleteShard(ctx context.Context, name string) error {
+	return r.delete(ctx, datastore.NameKey("LessonViewShard", name, nil))
+}
+
+func (r datastoreLessonViewRepository) GetDailyViews(ctx context.Context, lessonID int64, days []string) ([]LessonDailyViews, error) {
+	ancestor := datastore.IDKey("Lesson", lessonID, nil)
+	keys := make([]*datastore.Key, len(days))
+	for i, day := range days {
+		keys[i] = datastore.NameKey("LessonDailyViews", day, ancestor)
+	}
+
+	views := make([]LessonDailyViews, len(days))
+	err := r.getMulti(ctx, keys, views)
+	multiErr, isMultiErr := err.(datastore.MultiError)
+	if err != nil && !isMultiErr {
+		return nil, err
+	}
+
+	for i := range views {
+		if isMultiErr && multiErr[i] != nil && multiErr[i] != datastore.ErrNoSuchEntity {
+			return nil, multiErr[i]
+		}
+		views[i].Day = days[i]
+	}
+
+	return views, nil
+}
+
+func (r datastoreLessonViewRepository) PutDailyViews(ctx context.Context, lessonID int64, views *LessonDailyViews) error {
+	key := datastore.NameKey("LessonDailyViews", views.Day, datastore.IDKey("Lesson", lessonID, nil))
+	_, err := r.put(ctx, key, views)
+	return err
+}
+
+func (r datastoreLessonViewRepository) DeleteByLessonID(ctx context.Context, lessonID int64) error {
+	return r.deleteChildren(ctx, "LessonDailyViews", datastore.IDKey("Lesson", lessonID, nil))
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"time"
+)
+
+// lessonViewKeySize is the random bytes of ViewKey, encoded to 32 characters.
+const lessonViewKeySize = 24
+
+type LessonViewKeyErrorCode uint
+
+const (
+	LessonNotLimited      LessonViewKeyErrorCode = 1
+	InvalidViewKeyExpires LessonViewKeyErrorCode = 2
+)
+
+func (e LessonViewKeyErrorCode) Error() string {
+	switch e {
+	case LessonNotLimited:
+		return "lesson not limited"
+	case InvalidViewKeyExpires:
+		return "invalid expiry of view key"
+	default:
+		return "unknown lesson view key error"
+	}
+}
+
+// LessonViewKey is the key of the link sharing the limited lesson.
+type LessonViewKey struct {
+	ViewKey string    `json:"viewKey"`
+	Expires time.Time `json:"expires"` // zero never expires.
+}
+
+// IsViewable returns true when the lesson is public, or limited and viewKey is valid at now.
+func (l Lesson) IsViewable(viewKey string, now time.Time) bool {
+	switch l.Status {
+	case LessonStatusPublic:
+		return true
+	case LessonStatusLimited:
+		if l.ViewKey == "" || subtle.ConstantTimeCompare([]byte(l.ViewKey), []byte(viewKey)) != 1 {
+			return false
+		}
+		return l.ViewKeyExpires.IsZero() || now.Before(l.ViewKeyExpires)
+	default:
+		return false
+	}
+}
+
+// RegenerateLessonViewKey replaces the key of the limited lesson, so the links with the old key are revoked.
+// the revision is kept, because the key is not edited with the other fields.
+func RegenerateLessonViewKey(ctx context.Context, id int64, expires time.Time) (LessonViewKey, error) {
+	if !expires.IsZero() && !expires.After(time.Now()) {
+		return LessonViewKey{}, InvalidViewKeyExpires
+	}
+
+	var lesson Lesson
+	err := repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if lesson, err = repositories.Lessons.Get(ctx, id); err != nil {
+			return err
+		}
+
+		if lesson.Status != LessonStatusLimited {
+			return LessonNotLimited
+		}
+
+		if lesson.ViewKey, err = newLessonViewKey(); err != nil {
+			return err
+		}
+		lesson.ViewKeyExpires = expires
+
+		return repositories.Lessons.Put(ctx, &lesson)
+	})
+	if err != nil {
+		return LessonViewKey{}, err
+	}
+
+	return LessonViewKey{ViewKey: lesson.ViewKey, Expires: lesson.ViewKeyExpires}, nil
+}
+
+// setLessonViewKey generates the key when the lesson becomes limited, the old links are not revived by limiting it again.
+func setLessonViewKey(oldLesson Lesson, newLesson *Lesson) error {
+	if oldLesson.Status == LessonStatusLimited || newLesson.Status != LessonStatusLimited {
+		return nil
+	}
+
+	viewKey, err := newLessonViewKey()
+	if err != nil {
+		return err
+	}
+
+	newLesson.ViewKey = viewKey
+	newLesson.ViewKeyExpires = time.Time{}
+
+	return nil
+}
+
+func newLessonViewKey() (string, error) {
+	key := make([]byte, lessonViewKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(key), nil
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestAggregateLessonViewsByDay(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+
+	lesson := Lesson{UserID: 1}
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+	revision := lesson.Revision
+
+	shards := []LessonViewShard{
+		{LessonID: lesson.ID, Day: "2021-04-09", Count: 2},
+		{LessonID: lesson.ID, Day: "2021-04-10", Count: 3},
+		{LessonID: lesson.ID, Day: "2021-04-10", Count: 4},
+		{LessonID: lesson.ID + 100, Day: "2021-04-10", Count: 5}, // the lesson deleted.
+	}
+	for i := range shards {
+		shards[i].Name = fmt.Sprintf("%d-%s-%d", shards[i].LessonID, shards[i].Day, i)
+		if err := repositories.LessonViews.PutShard(ctx, &shards[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := AggregateLessonViews(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repositories.Lessons.Get(ctx, lesson.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ViewCount != 9 || got.Revision != revision {
+		t.Errorf("view count %d revision %d, want 9 and %d", got.ViewCount, got.Revision, revision)
+	}
+
+	views, err := repositories.LessonViews.GetDailyViews(ctx, lesson.ID, []string{"2021-04-09", "2021-04-10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if views[0].Count != 2 || views[1].Count != 7 {
+		t.Errorf("daily views %+v, want 2 and 7", views)
+	}
+
+	if left, _ := repositories.LessonViews.ListShards(ctx, lessonViewAggregationBatchSize); len(left) != 0 {
+		t.Errorf("the shards are left: %+v", left)
+	}
+}
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// MergePatch is the members of JSON Merge Patch (RFC 7396).
+// the members set to null clear the fields to zero value, and the members not sent leave the fields as they are.
+type MergePatch map[string]json.RawMessage
+
+var nullJSON = []byte("null")
+
+// Only returns the members which are the JSON fields of params, the fields of embedded structs are included.
+func (p MergePatch) Only(params interface{}) MergePatch {
+	names := make(map[string]bool)
+	collectJSONFieldNames(reflect.TypeOf(params), names)
+
+	patch := make(MergePatch)
+	for name, value := range p {
+		if names[name] {
+			patch[name] = value
+		}
+	}
+
+	return patch
+}
+
+// Set replaces the member with value encoded in JSON.
+func (p MergePatch) Set(name string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	p[name] = data
+
+	return nil
+}
+
+// Has returns true when the member is sent, including null.
+func (p MergePatch) Has(name string) bool {
+	_, ok := p[name]
+	return ok
+}
+
+// Apply sets the members to the fields of the same JSON name in the struct pointed by dst.
+// the objects are merged into the nested structs recursively, and the other values replace the fields including slices.
+func (p MergePatch) Apply(dst interface{}) error {
+	return applyMergePatch(reflect.ValueOf(dst).Elem(), p)
+}
+
+func applyMergePatch(dst reflect.Value, patch MergePatch) error {
+	fields := make(map[string]reflect.Value)
+	collectJSONFields(dst, fields)
+
+	for name, value := range patch {
+		field, ok := fields[name]
+		if !ok {
+			continue
+		}
+
+		if bytes.Equal(bytes.TrimSpace(value), nullJSON) {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+
+		if isMergeableStruct(field.Type()) {
+			var nested MergePatch
+			if err := json.Unmarshal(value, &nested); err == nil {
+				if err := applyMergePatch(field, nested); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		replaced := reflect.New(field.Type())
+		if err := json.Unmarshal(value, replaced.Interface()); err != nil {
+			return err
+		}
+		field.Set(replaced.Elem())
+	}
+
+	return nil
+}
+
+// isMergeableStruct returns true for the structs encoded as JSON objects field by field.
+func isMergeableStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) {
+		return false
+	}
+	return !reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem())
+}
+
+func collectJSONFields(v reflect.Value, fields map[string]reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectJSONFields(v.Field(i), fields)
+			continue
+		}
+		if name := jsonFieldName(field); name != "" {
+			fields[name] = v.Field(i)
+		}
+	}
+}
+
+func collectJSONFieldNames(t reflect.Type, names map[string]bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectJSONFieldNames(field.Type, names)
+			continue
+		}
+		if name := jsonFieldName(field); name != "" {
+			names[name] = true
+		}
+	}
+}
+
+// jsonFieldName returns the name of the field in JSON, or empty for the fields not encoded.
+func jsonFieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
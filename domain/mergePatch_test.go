@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type mergePatchTestNested struct {
+	A int    `json:"a"`
+	B string `json:"b"`
+}
+
+type mergePatchTestEmbedded struct {
+	Embedded string `json:"embedded"`
+}
+
+type mergePatchTestTarget struct {
+	mergePatchTestEmbedded
+	Title   string               `json:"title"`
+	Count   int                  `json:"count"`
+	Tags    []string             `json:"tags"`
+	Nested  mergePatchTestNested `json:"nested"`
+	Updated time.Time            `json:"updated"`
+	Hidden  string               `json:"-"`
+}
+
+func TestMergePatchApply(t *testing.T) {
+	updated := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	target := mergePatchTestTarget{
+		mergePatchTestEmbedded: mergePatchTestEmbedded{Embedded: "embedded"},
+		Title:                  "title",
+		Count:                  3,
+		Tags:                   []string{"a", "b"},
+		Nested:                 mergePatchTestNested{A: 1, B: "b"},
+		Hidden:                 "hidden",
+	}
+
+	var patch MergePatch
+	body := `{"title": null, "tags": ["c"], "nested": {"a": 2}, "updated": "2021-04-01T00:00:00Z", "embedded": "patched", "Hidden": "patched", "unknown": 1}`
+	if err := json.Unmarshal([]byte(body), &patch); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := patch.Apply(&target); err != nil {
+		t.Fatal(err)
+	}
+
+	want := mergePatchTestTarget{
+		mergePatchTestEmbedded: mergePatchTestEmbedded{Embedded: "patched"},
+		Title:                  "",                                 // cleared by null.
+		Count:                  3,                                  // not sent.
+		Tags:                   []string{"c"},                      // the slice is replaced.
+		Nested:                 mergePatchTestNested{A: 2, B: "b"}, // the object is merged.
+		Updated:                updated,                            // the time is replaced as the value.
+		Hidden:                 "hidden",                           // not in JSON.
+	}
+	if !reflect.DeepEqual(target, want) {
+		t.Errorf("got %+v, want %+v", target, want)
+	}
+}
+
+func TestMergePatchApplyRejectsInvalidValue(t *testing.T) {
+	target := mergePatchTestTarget{Count: 3}
+	patch := MergePatch{"count": json.RawMessage(`"three"`)}
+
+	if err := patch.Apply(&target); err == nil {
+		t.Error("the string is applied to int")
+	}
+}
+
+func TestMergePatchOnly(t *testing.T) {
+	type params struct {
+		mergePatchTestEmbedded
+		Title string `json:"title"`
+	}
+
+	patch := MergePatch{
+		"title":    json.RawMessage(`"title"`),
+		"embedded": json.RawMessage(`null`),
+		"count":    json.RawMessage(`1`),
+	}
+
+	got := patch.Only(params{})
+	if len(got) != 2 || !got.Has("title") || !got.Has("embedded") {
+		t.Errorf("got %v, want title and embedded", got)
+	}
+}
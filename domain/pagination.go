@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+type PaginationErrorCode uint
+
+const (
+	InvalidPageLimit PaginationErrorCode = 1
+	InvalidCursor    PaginationErrorCode = 2
+)
+
+func (e PaginationErrorCode) Error() string {
+	switch e {
+	case InvalidPageLimit:
+		return "invalid page limit"
+	case InvalidCursor:
+		return "invalid cursor"
+	default:
+		return "unknown pagination error"
+	}
+}
+
+// Page is the range of the list to fetch.
+// Cursor is the opaque string returned as the next cursor of the previous page, and blank for the first page.
+type Page struct {
+	Limit  int
+	Cursor string
+}
+
+// NewPage returns the page with default limit when limit is 0.
+func NewPage(limit int, cursor string) (Page, error) {
+	if limit == 0 {
+		limit = DefaultPageLimit
+	}
+
+	if limit < 0 || limit > MaxPageLimit {
+		return Page{}, InvalidPageLimit
+	}
+
+	return Page{Limit: limit, Cursor: cursor}, nil
+}
+
+// IsFirst returns true when the page is the beginning of the list.
+func (p Page) IsFirst() bool {
+	return p.Cursor == ""
+}
+
+// getPage runs the query from the cursor of the page, and appends entities to dst that must be a pointer to slice.
+// the returned cursor is blank when no more entities exist.
+func getPage(ctx context.Context, client *datastore.Client, query *datastore.Query, page Page, dst interface{}) ([]*datastore.Key, string, error) {
+	if page.Limit <= 0 {
+		page.Limit = DefaultPageLimit
+	}
+
+	if page.Cursor != "" {
+		cursor, err := datastore.DecodeCursor(page.Cursor)
+		if err != nil {
+			return nil, "", InvalidCursor
+		}
+		query = query.Start(cursor)
+	}
+
+	// fetch one more entity to know whether the next page exists.
+	query = query.Limit(page.Limit + 1)
+
+	sliceValue := reflect.ValueOf(dst).Elem()
+	elemType := sliceValue.Type().Elem()
+	isPointer := elemType.Kind() == reflect.Ptr
+	if isPointer {
+		elemType = elemType.Elem()
+	}
+
+	var keys []*datastore.Key
+	it := client.Run(ctx, query)
+	for len(keys) < page.Limit {
+		entity := reflect.New(elemType)
+		key, err := it.Next(entity.Interface())
+		if err == iterator.Done {
+			return keys, "", nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		if isPointer {
+			sliceValue.Set(reflect.Append(sliceValue, entity))
+		} else {
+			sliceValue.Set(reflect.Append(sliceValue, entity.Elem()))
+		}
+		keys = append(keys, key)
+	}
+
+	cursor, err := it.Cursor()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if _, err := it.Next(nil); err == iterator.Done {
+		return keys, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	return keys, cursor.String(), nil
+}
@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+// ErrNoSuchEntity is returned by repositories when the entity is not found.
+var ErrNoSuchEntity = datastore.ErrNoSuchEntity
+
+// Transactor runs the function in a transaction.
+// repositories called with the context passed to the function join the transaction.
+type Transactor interface {
+	RunInTransaction(ctx context.Context, f func(ctx context.Context) error) error
+}
+
+type LessonRepository interface {
+	Get(ctx context.Context, id int64) (Lesson, error)
+	// GetMulti returns lessons in order of ids, the lessons not found are skipped.
+	GetMulti(ctx context.Context, ids []int64) ([]Lesson, error)
+	ListByUserID(ctx context.Context, userID int64, page Page) ([]Lesson, string, error)
+	// ListByReviewerUserID returns the lessons whose review is requested to the user in order of newest first.
+	ListByReviewerUserID(ctx context.Context, userID int64, page Page) ([]Lesson, string, error)
+	// ListScheduledIDs returns the IDs of lessons whose PublishAt or UnpublishAt is due at now.
+	ListScheduledIDs(ctx context.Context, now time.Time) ([]int64, error)
+	Create(ctx context.Context, lesson *Lesson) error
+	Put(ctx context.Context, lesson *Lesson) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type LessonMaterialRepository interface {
+	Get(ctx context.Context, lessonID int64, id int64) (LessonMaterial, error)
+	Create(ctx context.Context, lessonID int64, lessonMaterial *LessonMaterial) error
+	Put(ctx context.Context, lessonID int64, lessonMaterial *LessonMaterial) error
+	DeleteByLessonID(ctx context.Context, lessonID int64) error
+}
+
+// LessonMaterialVersionRepository stores the versions under the material.
+type LessonMaterialVersionRepository interface {
+	Get(ctx context.Context, lessonID int64, materialID int64, id int64) (LessonMaterialVersion, error)
+	// ListByMaterialID returns all versions of the material in order of newest first.
+	ListByMaterialID(ctx context.Context, lessonID int64, materialID int64) ([]LessonMaterialVersion, error)
+	Create(ctx context.Context, lessonID int64, materialID int64, version *LessonMaterialVersion) error
+	Delete(ctx context.Context, lessonID int64, materialID int64, id int64) error
+	// DeleteByLessonID deletes the versions of all materials of the lesson.
+	DeleteByLessonID(ctx context.Context, lessonID int64) error
+}
+
+// LessonViewRepository stores the views of lessons, the shards and viewers are root entities keyed by name.
+type LessonViewRepository interface {
+	GetViewer(ctx context.Context, id string) (LessonViewer, error)
+	PutViewer(ctx context.Context, viewer *LessonViewer) error
+	DeleteViewersBefore(ctx context.Context, before time.Time) error
+	GetShard(ctx context.Context, name string) (LessonViewShard, error)
+	// ListShards returns the shards not aggregated yet up to limit.
+	ListShards(ctx context.Context, limit int) ([]LessonViewShard, error)
+	PutShard(ctx context.Context, shard *LessonViewShard) error
+	DeleteShard(ctx context.Context, name string) error
+	// GetDailyViews returns the views of the lesson in order of days, the days not found are zero.
+	GetDailyViews(ctx context.Context, lessonID int64, days []string) ([]LessonDailyViews, error)
+	PutDailyViews(ctx context.Context, lessonID int64, views *LessonDailyViews) error
+	// DeleteByLessonID deletes the daily views of the lesson.
+	DeleteByLessonID(ctx context.Context, lessonID int64) error
+}
+
+// LessonReviewCommentRepository stores the review comments under the lesson.
+type LessonReviewCommentRepository interface {
+	Get(ctx context.Context, lessonID int64, id int64) (LessonReviewComment, error)
+	ListByLessonID(ctx context.Context, lessonID int64) ([]LessonReviewComment, error)
+	Create(ctx context.Context, lessonID int64, comment *LessonReviewComment) error
+	Delete(ctx context.Context, lessonID int64, id int64) error
+	DeleteByLessonID(ctx context.Context, lessonID int64) error
+}
+
+type UserRepository interface {
+	Get(ctx context.Context, id int64) (User, error)
+	GetByProviderID(ctx context.Context, providerID string) (User, error)
+	// Create creates the user with exclusion control of ProviderID, returns AlreadyProviderIDExists when it is used.
+	Create(ctx context.Context, user *User) error
+	Put(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// AvatarRepository stores public avatars with userID 0, and the others belongs to user.
+type AvatarRepository interface {
+	Get(ctx context.Context, userID int64, id int64) (Avatar, error)
+	ListByUserID(ctx context.Context, userID int64, page Page) ([]Avatar, string, error)
+	ListPublic(ctx context.Context, page Page) ([]Avatar, string, error)
+	Create(ctx context.Context, userID int64, avatar *Avatar) error
+	Delete(ctx context.Context, userID int64, id int64) error
+}
+
+type GraphicRepository interface {
+	Get(ctx context.Context, userID int64, id int64) (Graphic, error)
+	// GetMulti returns graphics of the user in order of ids, the graphics not found are skipped.
+	GetMulti(ctx context.Context, userID int64, ids []int64) ([]Graphic, error)
+	ListByLessonID(ctx context.Context, lessonID int64, page Page) ([]*Graphic, string, error)
+	CreateMulti(ctx context.Context, userID int64, graphics []*Graphic) error
+	Delete(ctx context.Context, userID int64, id int64) error
+}
+
+type VoiceRepository interface {
+	Get(ctx context.Context, lessonID int64, id int64) (Voice, error)
+	// GetMulti returns voices of the lesson in order of ids including synthesized, the voices not found are skipped.
+	GetMulti(ctx context.Context, lessonID int64, ids []int64) ([]Voice, error)
+	// ListByLessonID returns voices except synthesized in order of ElapsedTime.
+	ListByLessonID(ctx context.Context, lessonID int64, page Page) ([]Voice, string, error)
+	Create(ctx context.Context, lessonID int64, voice *Voice) error
+	// DeleteByLessonID deletes all voices of the lesson including synthesized.
+	DeleteByLessonID(ctx context.Context, lessonID int64) error
+}
+
+type BackgroundMusicRepository interface {
+	Get(ctx context.Context, userID int64, id int64) (BackgroundMusic, error)
+	ListPublic(ctx context.Context, page Page) ([]BackgroundMusic, string, error)
+	ListByUserID(ctx context.Context, userID int64, page Page) ([]BackgroundMusic, string, error)
+	// ListAvailableIDs returns IDs of the public musics and the musics of the user.
+	ListAvailableIDs(ctx context.Context, userID int64) ([]int64, error)
+	Create(ctx context.Context, userID int64, backgroundMusic *BackgroundMusic) error
+	Delete(ctx context.Context, userID int64, id int64) error
+}
+
+type BackgroundImageRepository interface {
+	List(ctx context.Context) ([]BackgroundImage, error)
+}
+
+// SubjectRepository stores subjects and the categories belongs to them.
+type SubjectRepository interface {
+	ListSubjects(ctx context.Context) ([]Subject, error)
+	GetSubject(ctx context.Context, id int64) (Subject, error)
+	ListJapaneseCategories(ctx context.Context, subjectID int64) ([]Category, error)
+	GetJapaneseCategory(ctx context.Context, subjectID int64, id int64) (Category, error)
+}
+
+type SeriesRepository interface {
+	Get(ctx context.Context, id int64) (Series, error)
+	ListByUserID(ctx context.Context, userID int64, page Page) ([]Series, string, error)
+	Create(ctx context.Context, series *Series) error
+	Put(ctx context.Context, series *Series) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// Repositories is the set of storages used by the domain.
+type Repositories struct {
+	Transactor             Transactor
+	Lessons                LessonRepository
+	LessonMaterials        LessonMaterialRepository
+	LessonMaterialVersions LessonMaterialVersionRepository
+	Series                 SeriesRepository
+	LessonViews            LessonViewRepository
+	LessonReviewComments   LessonReviewCommentRepository
+	Users                  UserRepository
+	Avatars                AvatarRepository
+	Graphics               GraphicRepository
+	Voices                 VoiceRepository
+	BackgroundMusics       BackgroundMusicRepository
+	BackgroundImages       BackgroundImageRepository
+	Subjects               SubjectRepository
+}
+
+var repositories Repositories
+
+// SetRepositories replaces the storages used by the domain. this must be called before handling requests.
+func SetRepositories(r Repositories) {
+	repositories = r
+}
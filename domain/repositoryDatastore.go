@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"context"
+
+	"cloud.google.com/go/datastore"
+)
+
+type transactionKey struct{}
+
+// NewDatastoreRepositories returns the repositories share the long-lived client.
+func NewDatastoreRepositories(client *datastore.Client) Repositories {
+	store := datastoreStore{client: client}
+
+	return Repositories{
+		Transactor:             store,
+		Lessons:                datastoreLessonRepository{store},
+		LessonMaterials:        datastoreLessonMaterialRepository{store},
+		LessonMaterialVersions: datastoreLessonMaterialVersionRepository{store},
+		Series:                 datastoreSeriesRepository{store},
+		LessonViews:            datastoreLessonViewRepository{store},
+		LessonReviewComments:   datastoreLessonReviewCommentRepository{store},
+		Users:                  datastoreUserRepository{store},
+		Avatars:                datastoreAvatarRepository{store},
+		Graphics:               datastoreGraphicRepository{store},
+		Voices:                 datastoreVoiceRepository{store},
+		BackgroundMusics:       datastoreBackgroundMusicRepository{store},
+		BackgroundImages:       datastoreBackgroundImageRepository{store},
+		Subjects:               datastoreSubjectRepository{store},
+	}
+}
+
+// datastoreStore reads and writes entities in the transaction when the context has it.
+// queries always run outside of the transaction, because Datastore allows only ancestor queries in it.
+type datastoreStore struct {
+	client *datastore.Client
+}
+
+func (s datastoreStore) RunInTransaction(ctx context.Context, f func(ctx context.Context) error) error {
+	if transactionFromContext(ctx) != nil {
+		return f(ctx) // join the current transaction, because Datastore doesn't support nested transactions.
+	}
+
+	_, err := s.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		return f(context.WithValue(ctx, transactionKey{}, tx))
+	})
+
+	return err
+}
+
+func transactionFromContext(ctx context.Context) *datastore.Transaction {
+	tx, _ := ctx.Value(transactionKey{}).(*datastore.Transaction)
+	return tx
+}
+
+func (s datastoreStore) get(ctx context.Context, key *datastore.Key, dst interface{}) error {
+	if tx := transactionFromContext(ctx); tx != nil {
+		return tx.Get(key, dst)
+	}
+	return s.client.Get(ctx, key, dst)
+}
+
+func (s datastoreStore) getMulti(ctx context.Context, keys []*datastore.Key, dst interface{}) error {
+	if tx := transactionFromContext(ctx); tx != nil {
+		return tx.GetMulti(keys, dst)
+	}
+	return s.client.GetMulti(ctx, keys, dst)
+}
+
+// put returns the complete key. the ID of incomplete key is allocated before putting in transaction,
+// because the key put in transaction is pending until commit.
+func (s datastoreStore) put(ctx context.Context, key *datastore.Key, src interface{}) (*datastore.Key, error) {
+	keys, err := s.putMulti(ctx, []*datastore.Key{key}, []interface{}{src})
+	if err != nil {
+		if multiErr, ok := err.(datastore.MultiError); ok {
+			return nil, multiErr[0]
+		}
+		return nil, err
+	}
+
+	return keys[0], nil
+}
+
+func (s datastoreStore) putMulti(ctx context.Context, keys []*datastore.Key, src interface{}) ([]*datastore.Key, error) {
+	tx := transactionFromContext(ctx)
+	if tx == nil {
+		return s.client.PutMulti(ctx, keys, src)
+	}
+
+	completeKeys, err := s.completeKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.PutMulti(completeKeys, src); err != nil {
+		return nil, err
+	}
+
+	return completeKeys, nil
+}
+
+func (s datastoreStore) completeKeys(ctx context.Context, keys []*datastore.Key) ([]*datastore.Key, error) {
+	var incompleteKeys []*datastore.Key
+	for _, key := range keys {
+		if key.Incomplete() {
+			incompleteKeys = append(incompleteKeys, key)
+		}
+	}
+
+	if len(incompleteKeys) == 0 {
+		return keys, nil
+	}
+
+	allocatedKeys, err := s.client.AllocateIDs(ctx, incompleteKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	completeKeys := make([]*datastore.Key, len(keys))
+	for i, key := range keys {
+		if key.Incomplete() {
+			key, allocatedKeys = allocatedKeys[0], allocatedKeys[1:]
+		}
+		completeKeys[i] = key
+	}
+
+	return completeKeys, nil
+}
+
+func (s datastoreStore) delete(ctx context.Context, key *datastore.Key) error {
+	if tx := transactionFromContext(ctx); tx != nil {
+		return tx.Delete(key)
+	}
+	return s.client.Delete(ctx, key)
+}
+
+// maxDatastoreBatchSize is the maximum number of keys in a batch operation.
+const maxDatastoreBatchSize = 500
+
+func (s datastoreStore) deleteMulti(ctx context.Context, keys []*datastore.Key) error {
+	for len(keys) > 0 {
+		size := len(keys)
+		if size > maxDatastoreBatchSize {
+			size = maxDatastoreBatchSize
+		}
+
+		var err error
+		if tx := transactionFromContext(ctx); tx != nil {
+			err = tx.DeleteMulti(keys[:size])
+		} else {
+			err = s.client.DeleteMulti(ctx, keys[:size])
+		}
+		if err != nil {
+			return err
+		}
+
+		keys = keys[size:]
+	}
+
+	return nil
+}
+
+// deleteChildren deletes all entities of the kind under the ancestor.
+func (s datastoreStore) deleteChildren(ctx context.Context, kind string, ancestor *datastore.Key) error {
+	keys, err := s.client.GetAll(ctx, datastore.NewQuery(kind).Ancestor(ancestor).KeysOnly(), nil)
+	if err != nil {
+		return err
+	}
+
+	return s.deleteMulti(ctx, keys)
+}
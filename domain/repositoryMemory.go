@@ -0,0 +1,1164 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/gob"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NewMemoryRepositories returns the repositories on memory for tests and local development.
+func NewMemoryRepositories(db *MemoryDB) Repositories {
+	return Repositories{
+		Transactor:             db,
+		Lessons:                memoryLessonRepository{db},
+		LessonMaterials:        memoryLessonMaterialRepository{db},
+		LessonMaterialVersions: memoryLessonMaterialVersionRepository{db},
+		Series:                 memorySeriesRepository{db},
+		LessonViews:            memoryLessonViewRepository{db},
+		LessonReviewComments:   memoryLessonReviewCommentRepository{db},
+		Users:                  memoryUserRepository{db},
+		Avatars:                memoryAvatarRepository{db},
+		Graphics:               memoryGraphicRepository{db},
+		Voices:                 memoryVoiceRepository{db},
+		BackgroundMusics:       memoryBackgroundMusicRepository{db},
+		BackgroundImages:       memoryBackgroundImageRepository{db},
+		Subjects:               memorySubjectRepository{db},
+	}
+}
+
+// memoryKey is the key of entity. ParentID is 0 for root entities, and Name is used instead of ID for the entities keyed by name.
+type memoryKey struct {
+	ParentID int64
+	ID       int64
+	Name     string
+}
+
+type memoryEntry struct {
+	key  memoryKey
+	data []byte
+}
+
+type memoryTransactionKey struct{}
+
+// MemoryDB stores entities encoded by gob, so the stored entities never share slices with callers.
+// transactions are serialized with each other, and each of them reads and writes its own copy of the tables.
+// only the entities written in the transaction are applied on commit, so the writes outside of it are kept.
+type MemoryDB struct {
+	mu     sync.RWMutex
+	txMu   sync.Mutex
+	lastID int64 // accessed atomically, because IDs are allocated in transactions too.
+	tables map[string]map[memoryKey][]byte
+}
+
+// memoryTables is the view of the tables, the written keys are recorded in the transaction.
+type memoryTables struct {
+	tables  map[string]map[memoryKey][]byte
+	written map[string]map[memoryKey]bool // nil when not in the transaction.
+}
+
+// NewMemoryDB returns the empty storage.
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{tables: make(map[string]map[memoryKey][]byte)}
+}
+
+// Seed stores the entity of kind such as "Subject" or "JapaneseCategory" which the application doesn't create.
+// parentID is the ID of the parent entity, or 0 for root entities. returns the allocated ID.
+func (db *MemoryDB) Seed(kind string, parentID int64, entity interface{}) (int64, error) {
+	key, err := db.put(context.Background(), kind, memoryKey{ParentID: parentID}, entity)
+	return key.ID, err
+}
+
+func (db *MemoryDB) RunInTransaction(ctx context.Context, f func(ctx context.Context) error) error {
+	if ctx.Value(memoryTransactionKey{}) != nil {
+		return f(ctx)
+	}
+
+	db.txMu.Lock()
+	defer db.txMu.Unlock()
+
+	tx := &memoryTables{tables: db.snapshot(), written: make(map[string]map[memoryKey]bool)}
+	if err := f(context.WithValue(ctx, memoryTransactionKey{}, tx)); err != nil {
+		return err // the copy is discarded.
+	}
+
+	db.commit(tx)
+
+	return nil
+}
+
+func (db *MemoryDB) snapshot() map[string]map[memoryKey][]byte {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	tables := make(map[string]map[memoryKey][]byte, len(db.tables))
+	for kind, table := range db.tables {
+		copied := make(map[memoryKey][]byte, len(table))
+		for key, data := range table {
+			copied[key] = data
+		}
+		tables[kind] = copied
+	}
+
+	return tables
+}
+
+func (db *MemoryDB) commit(tx *memoryTables) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	current := memoryTables{tables: db.tables}
+	for kind, keys := range tx.written {
+		for key := range keys {
+			if data, ok := tx.tables[kind][key]; ok {
+				current.set(kind, key, data)
+			} else {
+				current.remove(kind, key)
+			}
+		}
+	}
+}
+
+// view runs f with the tables of the transaction in ctx, or the current tables.
+func (db *MemoryDB) view(ctx context.Context, f func(tables *memoryTables) error) error {
+	if tx, ok := ctx.Value(memoryTransactionKey{}).(*memoryTables); ok {
+		return f(tx)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return f(&memoryTables{tables: db.tables})
+}
+
+// update runs f with the tables of the transaction in ctx, or the current tables locked until f returns.
+func (db *MemoryDB) update(ctx context.Context, f func(tables *memoryTables) error) error {
+	if tx, ok := ctx.Value(memoryTransactionKey{}).(*memoryTables); ok {
+		return f(tx)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return f(&memoryTables{tables: db.tables})
+}
+
+func (db *MemoryDB) get(ctx context.Context, kind string, key memoryKey, dst interface{}) error {
+	var data []byte
+	var ok bool
+	db.view(ctx, func(tables *memoryTables) error {
+		data, ok = tables.tables[kind][key]
+		return nil
+	})
+
+	if !ok {
+		return ErrNoSuchEntity
+	}
+
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(dst)
+}
+
+// put stores the entity, and allocates ID when key has neither of ID and Name.
+func (db *MemoryDB) put(ctx context.Context, kind string, key memoryKey, src interface{}) (memoryKey, error) {
+	err := db.update(ctx, func(tables *memoryTables) error {
+		var err error
+		key, err = db.putIn(tables, kind, key, src)
+		return err
+	})
+
+	return key, err
+}
+
+func (db *MemoryDB) putIn(tables *memoryTables, kind string, key memoryKey, src interface{}) (memoryKey, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(src); err != nil {
+		return key, err
+	}
+
+	if key.ID == 0 && key.Name == "" {
+		key.ID = atomic.AddInt64(&db.lastID, 1)
+	}
+
+	tables.set(kind, key, buffer.Bytes())
+
+	return key, nil
+}
+
+func (db *MemoryDB) delete(ctx context.Context, kind string, key memoryKey) error {
+	return db.update(ctx, func(tables *memoryTables) error {
+		tables.remove(kind, key)
+		return nil
+	})
+}
+
+// deleteChildren deletes all entities of the kind under the parent.
+func (db *MemoryDB) deleteChildren(ctx context.Context, kind string, parentID int64) error {
+	return db.update(ctx, func(tables *memoryTables) error {
+		for key := range tables.tables[kind] {
+			if key.ParentID == parentID {
+				tables.remove(kind, key)
+			}
+		}
+		return nil
+	})
+}
+
+// entries returns all entities of the kind in order of ID and Name.
+func (db *MemoryDB) entries(ctx context.Context, kind string) []memoryEntry {
+	var entries []memoryEntry
+	db.view(ctx, func(tables *memoryTables) error {
+		entries = tables.entries(kind)
+		return nil
+	})
+
+	return entries
+}
+
+func (t *memoryTables) set(kind string, key memoryKey, data []byte) {
+	if t.tables[kind] == nil {
+		t.tables[kind] = make(map[memoryKey][]byte)
+	}
+	t.tables[kind][key] = data
+	t.markWritten(kind, key)
+}
+
+func (t *memoryTables) remove(kind string, key memoryKey) {
+	delete(t.tables[kind], key)
+	t.markWritten(kind, key)
+}
+
+func (t *memoryTables) markWritten(kind string, key memoryKey) {
+	if t.written == nil {
+		return
+	}
+	if t.written[kind] == nil {
+		t.written[kind] = make(map[memoryKey]bool)
+	}
+	t.written[kind][key] = true
+}
+
+func (t *memoryTables) entries(kind string) []memoryEntry {
+	entries := make([]memoryEntry, 0, len(t.tables[kind]))
+	for key, data := range t.tables[kind] {
+		entries = append(entries, memoryEntry{key: key, data: data})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].key.ID != entries[j].key.ID {
+			return entries[i].key.ID < entries[j].key.ID
+		}
+		return entries[i].key.Name < entries[j].key.Name
+	})
+
+	return entries
+}
+
+func (e memoryEntry) decode(dst interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(e.data)).Decode(dst)
+}
+
+// memoryPage returns the range of the page in the list has length items.
+// the cursor is the offset of the list, so the page may be shifted when entities are added while paging.
+func memoryPage(length int, page Page) (int, int, string, error) {
+	if page.Limit <= 0 {
+		page.Limit = DefaultPageLimit
+	}
+
+	start := 0
+	if page.Cursor != "" {
+		offset, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+		if err != nil {
+			return 0, 0, "", InvalidCursor
+		}
+		if start, err = strconv.Atoi(string(offset)); err != nil || start < 0 {
+			return 0, 0, "", InvalidCursor
+		}
+	}
+
+	if start > length {
+		start = length
+	}
+
+	end := start + page.Limit
+	if end >= length {
+		return start, length, "", nil
+	}
+
+	return start, end, base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(end))), nil
+}
+
+type memoryLessonRepository struct {
+	db *MemoryDB
+}
+
+func (r memoryLessonRepository) Get(ctx context.Context, id int64) (Lesson, error) {
+	var lesson Lesson
+	if err := r.db.get(ctx, "Lesson", memoryKey{ID: id}, &lesson); err != nil {
+		return Lesson{}, err
+	}
+
+	lesson.ID = id
+
+	return lesson, nil
+}
+
+func (r memoryLessonRepository) GetMulti(ctx context.Context, ids []int64) ([]Lesson, error) {
+	var lessons []Lesson
+	for _, id := range ids {
+		lesson, err := r.Get(ctx, id)
+		if err == ErrNoSuchEntity {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		lessons = append(lessons, lesson)
+	}
+
+	return lessons, nil
+}
+
+func (r memoryLessonRepository) ListByUserID(ctx context.Context, userID int64, page Page) ([]Lesson, string, error) {
+	return r.list(ctx, page, func(lesson Lesson) bool {
+		return lesson.UserID == userID
+	})
+}
+
+func (r memoryLessonRepository) ListByReviewerUserID(ctx context.Context, userID int64, page Page) ([]Lesson, string, error) {
+	return r.list(ctx, page, func(lesson Lesson) bool {
+		return lesson.IsReviewer(userID)
+	})
+}
+
+func (r memoryLessonRepository) ListScheduledIDs(ctx context.Context, now time.Time) ([]int64, error) {
+	isDue := func(at time.Time) bool {
+		return !at.IsZero() && !at.After(now)
+	}
+
+	var ids []int64
+	for _, entry := range r.db.entries(ctx, "Lesson") {
+		var lesson Lesson
+		if err := entry.decode(&lesson); err != nil {
+			return nil, err
+		}
+		if isDue(lesson.PublishAt) || isDue(lesson.UnpublishAt) {
+			ids = append(ids, entry.key.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+// list returns the page of lessons matched in order of newest first.
+func (r memoryLessonRepository) list(ctx context.Context, page Page, match func(lesson Lesson) bool) ([]Lesson, string, error) {
+	var lessons []Lesson
+	for _, entry := range r.db.entries(ctx, "Lesson") {
+		var lesson Lesson
+		if err := entry.decode(&lesson); err != nil {
+			return nil, "", err
+		}
+		if match(lesson) {
+			lesson.ID = entry.key.ID
+			lessons = append(lessons, lesson)
+		}
+	}
+
+	sort.SliceStable(lessons, func(i, j int) bool {
+		return lessons[i].Created.After(lessons[j].Created)
+	})
+
+	start, end, nextCursor, err := memoryPage(len(lessons), page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return lessons[start:end], nextCursor, nil
+}
+
+func (r memoryLessonRepository) Create(ctx context.Context, lesson *Lesson) error {
+	key, err := r.db.put(ctx, "Lesson", memoryKey{}, lesson)
+	if err != nil {
+		return err
+	}
+
+	lesson.ID = key.ID
+
+	return nil
+}
+
+func (r memoryLessonRepository) Put(ctx context.Context, lesson *Lesson) error {
+	_, err := r.db.put(ctx, "Lesson", memoryKey{ID: lesson.ID}, lesson)
+	return err
+}
+
+func (r memoryLessonRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.delete(ctx, "Lesson", memoryKey{ID: id})
+}
+
+type memorySeriesRepository struct {
+	db *MemoryDB
+}
+
+func (r memorySeriesRepository) Get(ctx context.Context, id int64) (Series, error) {
+	var series Series
+	if err := r.db.get(ctx, "Series", memoryKey{ID: id}, &series); err != nil {
+		return Series{}, err
+	}
+
+	series.ID = id
+
+	return series, nil
+}
+
+func (r memorySeriesRepository) ListByUserID(ctx context.Context, userID int64, page Page) ([]Series, string, error) {
+	var seriesList []Series
+	for _, entry := range r.db.entries(ctx, "Series") {
+		var series Series
+		if err := entry.decode(&series); err != nil {
+			return nil, "", err
+		}
+		if series.UserID == userID {
+			series.ID = entry.key.ID
+			seriesList = append(seriesList, series)
+		}
+	}
+
+	sort.SliceStable(seriesList, func(i, j int) bool {
+		return seriesList[i].Created.After(seriesList[j].Created)
+	})
+
+	start, end, nextCursor, err := memoryPage(len(seriesList), page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return seriesList[start:end], nextCursor, nil
+}
+
+func (r memorySeriesRepository) Create(ctx context.Context, series *Series) error {
+	key, err := r.db.put(ctx, "Series", memoryKey{}, series)
+	if err != nil {
+		return err
+	}
+
+	series.ID = key.ID
+
+	return nil
+}
+
+func (r memorySeriesRepository) Put(ctx context.Context, series *Series) error {
+	_, err := r.db.put(ctx, "Series", memoryKey{ID: series.ID}, series)
+	return err
+}
+
+func (r memorySeriesRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.delete(ctx, "Series", memoryKey{ID: id})
+}
+
+type memoryLessonViewRepository struct {
+	db *MemoryDB
+}
+
+func (r memoryLessonViewRepository) GetViewer(ctx context.Context, id string) (LessonViewer, error) {
+	var viewer LessonViewer
+	if err := r.db.get(ctx, "LessonViewer", memoryKey{Name: id}, &viewer); err != nil {
+		return LessonViewer{}, err
+	}
+
+	viewer.ID = id
+
+	return viewer, nil
+}
+
+func (r memoryLessonViewRepository) PutViewer(ctx context.Context, viewer *LessonViewer) error {
+	_, err := r.db.put(ctx, "LessonViewer", memoryKey{Name: viewer.ID}, viewer)
+	return err
+}
+
+func (r memoryLessonViewRepository) DeleteViewersBefore(ctx context.Context, before time.Time) error {
+	for _, entry := range r.db.entries(ctx, "LessonViewer") {
+		var viewer LessonViewer
+		if err := entry.decode(&viewer); err != nil {
+			return err
+		}
+		if viewer.Viewed.Before(before) {
+			if err := r.db.delete(ctx, "LessonViewer", entry.key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r memoryLessonViewRepository) GetShard(ctx context.Context, name string) (LessonViewShard, error) {
+	var shard LessonViewShard
+	if err := r.db.get(ctx, "LessonViewShard", memoryKey{Name: name}, &shard); err != nil {
+		return LessonViewShard{}, err
+	}
+
+	shard.Name = name
+
+	return shard, nil
+}
+
+func (r memoryLessonViewRepository) ListShards(ctx context.Context, limit int) ([]LessonViewShard, error) {
+	var shards []LessonViewShard
+	for _, entry := range r.db.entries(ctx, "LessonViewShard") {
+		if len(shards) == limit {
+			break
+		}
+
+		var shard LessonViewShard
+		if err := entry.decode(&shard); err != nil {
+			return nil, err
+		}
+		shard.Name = entry.key.Name
+		shards = append(shards, shard)
+	}
+
+	return shards, nil
+}
+
+func (r memoryLessonViewRepository) PutShard(ctx context.Context, shard *LessonViewShard) error {
+	_, err := r.db.put(ctx, "LessonViewShard", memoryKey{Name: shard.Name}, shard)
+	return err
+}
+
+func (r memoryLessonViewRepository) DeleteShard(ctx context.Context, name string) error {
+	return r.db.delete(ctx, "LessonViewShard", memoryKey{Name: name})
+}
+
+func (r memoryLessonViewRepository) GetDailyViews(ctx context.Context, lessonID int64, days []string) ([]LessonDailyViews, error) {
+	views := make([]LessonDailyViews, len(days))
+	for i, day := range days {
+		err := r.db.get(ctx, "LessonDailyViews", memoryKey{ParentID: lessonID, Name: day}, &views[i])
+		if err != nil && err != ErrNoSuchEntity {
+			return nil, err
+		}
+		views[i].Day = day
+	}
+
+	return views, nil
+}
+
+func (r memoryLessonViewRepository) PutDailyViews(ctx context.Context, lessonID int64, views *LessonDailyViews) error {
+	_, err := r.db.put(ctx, "LessonDailyViews", memoryKey{ParentID: lessonID, Name: views.Day}, views)
+	return err
+}
+
+func (r memoryLessonViewRepository) DeleteByLessonID(ctx context.Context, lessonID int64) error {
+	return r.db.deleteChildren(ctx, "LessonDailyViews", lessonID)
+}
+
+type memoryLessonReviewCommentRepository struct {
+	db *MemoryDB
+}
+
+func (r memoryLessonReviewCommentRepository) Get(ctx context.Context, lessonID int64, id int64) (LessonReviewComment, error) {
+	var comment LessonReviewComment
+	if err := r.db.get(ctx, "LessonReviewComment", memoryKey{ParentID: lessonID, ID: id}, &comment); err != nil {
+		return LessonReviewComment{}, err
+	}
+
+	comment.ID = id
+
+	return comment, nil
+}
+
+func (r memoryLessonReviewCommentRepository) ListByLessonID(ctx context.Context, lessonID int64) ([]LessonReviewComment, error) {
+	var comments []LessonReviewComment
+	for _, entry := range r.db.entries(ctx, "LessonReviewComment") {
+		if entry.key.ParentID != lessonID {
+			continue
+		}
+
+		var comment LessonReviewComment
+		if err := entry.decode(&comment); err != nil {
+			return nil, err
+		}
+		comment.ID = entry.key.ID
+		comments = append(comments, comment)
+	}
+
+	return comments, nil
+}
+
+func (r memoryLessonReviewCommentRepository) Create(ctx context.Context, lessonID int64, comment *LessonReviewComment) error {
+	key, err := r.db.put(ctx, "LessonReviewComment", memoryKey{ParentID: lessonID}, comment)
+	if err != nil {
+		return err
+	}
+
+	comment.ID = key.ID
+
+	return nil
+}
+
+func (r memoryLessonReviewCommentRepository) Delete(ctx context.Context, lessonID int64, id int64) error {
+	return r.db.delete(ctx, "LessonReviewComment", memoryKey{ParentID: lessonID, ID: id})
+}
+
+func (r memoryLessonReviewCommentRepository) DeleteByLessonID(ctx context.Context, lessonID int64) error {
+	return r.db.deleteChildren(ctx, "LessonReviewComment", lessonID)
+}
+
+type memoryLessonMaterialRepository struct {
+	db *MemoryDB
+}
+
+func (r memoryLessonMaterialRepository) Get(ctx context.Context, lessonID int64, id int64) (LessonMaterial, error) {
+	var lessonMaterial LessonMaterial
+	if err := r.db.get(ctx, "LessonMaterial", memoryKey{ParentID: lessonID, ID: id}, &lessonMaterial); err != nil {
+		return LessonMaterial{}, err
+	}
+
+	lessonMaterial.ID = id
+
+	return lessonMaterial, nil
+}
+
+func (r memoryLessonMaterialRepository) Create(ctx context.Context, lessonID int64, lessonMaterial *LessonMaterial) error {
+	key, err := r.db.put(ctx, "LessonMaterial", memoryKey{ParentID: lessonID}, lessonMaterial)
+	if err != nil {
+		return err
+	}
+
+	lessonMaterial.ID = key.ID
+
+	return nil
+}
+
+func (r memoryLessonMaterialRepository) Put(ctx context.Context, lessonID int64, lessonMaterial *LessonMaterial) error {
+	_, err := r.db.put(ctx, "LessonMaterial", memoryKey{ParentID: lessonID, ID: lessonMaterial.ID}, lessonMaterial)
+	return err
+}
+
+func (r memoryLessonMaterialRepository) DeleteByLessonID(ctx context.Context, lessonID int64) error {
+	return r.db.deleteChildren(ctx, "LessonMaterial", lessonID)
+}
+
+// memoryLessonMaterialVersionRepository stores versions with the ID of material as the parent.
+type memoryLessonMaterialVersionRepository struct {
+	db *MemoryDB
+}
+
+func (r memoryLessonMaterialVersionRepository) Get(ctx context.Context, lessonID int64, materialID int64, id int64) (LessonMaterialVersion, error) {
+	var version LessonMaterialVersion
+	if err := r.db.get(ctx, "LessonMaterialVersion", memoryKey{ParentID: materialID, ID: id}, &version); err != nil {
+		return LessonMaterialVersion{}, err
+	}
+
+	if version.LessonID != lessonID {
+		return LessonMaterialVersion{}, ErrNoSuchEntity
+	}
+
+	version.ID = id
+
+	return version, nil
+}
+
+func (r memoryLessonMaterialVersionRepository) ListByMaterialID(ctx context.Context, lessonID int64, materialID int64) ([]LessonMaterialVersion, error) {
+	var versions []LessonMaterialVersion
+	for _, entry := range r.db.entries(ctx, "LessonMaterialVersion") {
+		var version LessonMaterialVersion
+		if err := entry.decode(&version); err != nil {
+			return nil, err
+		}
+		if entry.key.ParentID == materialID && version.LessonID == lessonID {
+			version.ID = entry.key.ID
+			versions = append(versions, version)
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Created.After(versions[j].Created)
+	})
+
+	return versions, nil
+}
+
+func (r memoryLessonMaterialVersionRepository) Create(ctx context.Context, lessonID int64, materialID int64, version *LessonMaterialVersion) error {
+	version.LessonID = lessonID
+	key, err := r.db.put(ctx, "LessonMaterialVersion", memoryKey{ParentID: materialID}, version)
+	if err != nil {
+		return err
+	}
+
+	version.ID = key.ID
+
+	return nil
+}
+
+func (r memoryLessonMaterialVersionRepository) Delete(ctx context.Context, lessonID int64, materialID int64, id int64) error {
+	return r.db.delete(ctx, "LessonMaterialVersion", memoryKey{ParentID: materialID, ID: id})
+}
+
+func (r memoryLessonMaterialVersionRepository) DeleteByLessonID(ctx context.Context, lessonID int64) error {
+	for _, entry := range r.db.entries(ctx, "LessonMaterialVersion") {
+		var version LessonMaterialVersion
+		if err := entry.decode(&version); err != nil {
+			return err
+		}
+		if version.LessonID == lessonID {
+			if err := r.db.delete(ctx, "LessonMaterialVersion", entry.key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+type memoryUserRepository struct {
+	db *MemoryDB
+}
+
+func (r memoryUserRepository) Get(ctx context.Context, id int64) (User, error) {
+	var user User
+	if err := r.db.get(ctx, "User", memoryKey{ID: id}, &user); err != nil {
+		return User{}, err
+	}
+
+	user.ID = id
+
+	return user, nil
+}
+
+func (r memoryUserRepository) GetByProviderID(ctx context.Context, providerID string) (User, error) {
+	for _, entry := range r.db.entries(ctx, "User") {
+		var user User
+		if err := entry.decode(&user); err != nil {
+			return User{}, err
+		}
+		if user.ProviderID == providerID {
+			user.ID = entry.key.ID
+			return user, nil
+		}
+	}
+
+	return User{}, ErrNoSuchEntity
+}
+
+func (r memoryUserRepository) Create(ctx context.Context, user *User) error {
+	return r.db.update(ctx, func(tables *memoryTables) error {
+		for _, entry := range tables.entries("User") {
+			var existing User
+			if err := entry.decode(&existing); err != nil {
+				return err
+			}
+			if existing.ProviderID == user.ProviderID {
+				return AlreadyProviderIDExists
+			}
+		}
+
+		key, err := r.db.putIn(tables, "User", memoryKey{}, user)
+		if err != nil {
+			return err
+		}
+
+		user.ID = key.ID
+
+		return nil
+	})
+}
+
+func (r memoryUserRepository) Put(ctx context.Context, user *User) error {
+	_, err := r.db.put(ctx, "User", memoryKey{ID: user.ID}, user)
+	return err
+}
+
+func (r memoryUserRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.delete(ctx, "User", memoryKey{ID: id})
+}
+
+type memoryAvatarRepository struct {
+	db *MemoryDB
+}
+
+func (r memoryAvatarRepository) Get(ctx context.Context, userID int64, id int64) (Avatar, error) {
+	var avatar Avatar
+	if err := r.db.get(ctx, "Avatar", memoryKey{ParentID: userID, ID: id}, &avatar); err != nil {
+		return Avatar{}, err
+	}
+
+	avatar.ID = id
+
+	return avatar, nil
+}
+
+func (r memoryAvatarRepository) list(ctx context.Context, page Page, matches func(key memoryKey, avatar Avatar) bool) ([]Avatar, string, error) {
+	var avatars []Avatar
+	for _, entry := range r.db.entries(ctx, "Avatar") {
+		var avatar Avatar
+		if err := entry.decode(&avatar); err != nil {
+			return nil, "", err
+		}
+		if matches(entry.key, avatar) {
+			avatar.ID = entry.key.ID
+			avatars = append(avatars, avatar)
+		}
+	}
+
+	sort.SliceStable(avatars, func(i, j int) bool {
+		return avatars[i].Created.After(avatars[j].Created)
+	})
+
+	start, end, nextCursor, err := memoryPage(len(avatars), page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return avatars[start:end], nextCursor, nil
+}
+
+func (r memoryAvatarRepository) ListByUserID(ctx context.Context, userID int64, page Page) ([]Avatar, string, error) {
+	return r.list(ctx, page, func(key memoryKey, avatar Avatar) bool {
+		return key.ParentID == userID
+	})
+}
+
+func (r memoryAvatarRepository) ListPublic(ctx context.Context, page Page) ([]Avatar, string, error) {
+	return r.list(ctx, page, func(key memoryKey, avatar Avatar) bool {
+		return avatar.IsPublic
+	})
+}
+
+func (r memoryAvatarRepository) Create(ctx context.Context, userID int64, avatar *Avatar) error {
+	key, err := r.db.put(ctx, "Avatar", memoryKey{ParentID: userID}, avatar)
+	if err != nil {
+		return err
+	}
+
+	avatar.ID = key.ID
+
+	return nil
+}
+
+func (r memoryAvatarRepository) Delete(ctx context.Context, userID int64, id int64) error {
+	return r.db.delete(ctx, "Avatar", memoryKey{ParentID: userID, ID: id})
+}
+
+type memoryGraphicRepository struct {
+	db *MemoryDB
+}
+
+func (r memoryGraphicRepository) Get(ctx context.Context, userID int64, id int64) (Graphic, error) {
+	var graphic Graphic
+	if err := r.db.get(ctx, "Graphic", memoryKey{ParentID: userID, ID: id}, &graphic); err != nil {
+		return Graphic{}, err
+	}
+
+	graphic.ID = id
+	graphic.UserID = userID
+
+	return graphic, nil
+}
+
+func (r memoryGraphicRepository) GetMulti(ctx context.Context, userID int64, ids []int64) ([]Graphic, error) {
+	var graphics []Graphic
+	for _, id := range ids {
+		graphic, err := r.Get(ctx, userID, id)
+		if err == ErrNoSuchEntity {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		graphics = append(graphics, graphic)
+	}
+
+	return graphics, nil
+}
+
+func (r memoryGraphicRepository) ListByLessonID(ctx context.Context, lessonID int64, page Page) ([]*Graphic, string, error) {
+	var graphics []*Graphic
+	for _, entry := range r.db.entries(ctx, "Graphic") {
+		graphic := new(Graphic)
+		if err := entry.decode(graphic); err != nil {
+			return nil, "", err
+		}
+		if graphic.LessonID == lessonID {
+			graphic.ID = entry.key.ID
+			graphic.UserID = entry.key.ParentID
+			graphics = append(graphics, graphic)
+		}
+	}
+
+	sort.SliceStable(graphics, func(i, j int) bool {
+		return graphics[i].Created.Before(graphics[j].Created)
+	})
+
+	start, end, nextCursor, err := memoryPage(len(graphics), page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return graphics[start:end], nextCursor, nil
+}
+
+func (r memoryGraphicRepository) CreateMulti(ctx context.Context, userID int64, graphics []*Graphic) error {
+	return r.db.update(ctx, func(tables *memoryTables) error {
+		for _, graphic := range graphics {
+			key, err := r.db.putIn(tables, "Graphic", memoryKey{ParentID: userID}, graphic)
+			if err != nil {
+				return err
+			}
+			graphic.ID = key.ID
+			graphic.UserID = userID
+		}
+
+		return nil
+	})
+}
+
+func (r memoryGraphicRepository) Delete(ctx context.Context, userID int64, id int64) error {
+	return r.db.delete(ctx, "Graphic", memoryKey{ParentID: userID, ID: id})
+}
+
+type memoryVoiceRepository struct {
+	db *MemoryDB
+}
+
+func (r memoryVoiceRepository) Get(ctx context.Context, lessonID int64, id int64) (Voice, error) {
+	var voice Voice
+	if err := r.db.get(ctx, "Voice", memoryKey{ParentID: lessonID, ID: id}, &voice); err != nil {
+		return Voice{}, err
+	}
+
+	voice.ID = id
+
+	return voice, nil
+}
+
+func (r memoryVoiceRepository) GetMulti(ctx context.Context, lessonID int64, ids []int64) ([]Voice, error) {
+	var voices []Voice
+	for _, id := range ids {
+		voice, err := r.Get(ctx, lessonID, id)
+		if err == ErrNoSuchEntity {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		voices = append(voices, voice)
+	}
+
+	return voices, nil
+}
+
+func (r memoryVoiceRepository) ListByLessonID(ctx context.Context, lessonID int64, page Page) ([]Voice, string, error) {
+	var voices []Voice
+	for _, entry := range r.db.entries(ctx, "Voice") {
+		var voice Voice
+		if err := entry.decode(&voice); err != nil {
+			return nil, "", err
+		}
+		if entry.key.ParentID == lessonID && !voice.IsSynthesis {
+			voice.ID = entry.key.ID
+			voices = append(voices, voice)
+		}
+	}
+
+	sort.SliceStable(voices, func(i, j int) bool {
+		return voices[i].ElapsedTime < voices[j].ElapsedTime
+	})
+
+	start, end, nextCursor, err := memoryPage(len(voices), page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return voices[start:end], nextCursor, nil
+}
+
+func (r memoryVoiceRepository) Create(ctx context.Context, lessonID int64, voice *Voice) error {
+	key, err := r.db.put(ctx, "Voice", memoryKey{ParentID: lessonID}, voice)
+	if err != nil {
+		return err
+	}
+
+	voice.ID = key.ID
+
+	return nil
+}
+
+func (r memoryVoiceRepository) DeleteByLessonID(ctx context.Context, lessonID int64) error {
+	return r.db.deleteChildren(ctx, "Voice", lessonID)
+}
+
+type memoryBackgroundMusicRepository struct {
+	db *MemoryDB
+}
+
+func (r memoryBackgroundMusicRepository) Get(ctx context.Context, userID int64, id int64) (BackgroundMusic, error) {
+	var music BackgroundMusic
+	if err := r.db.get(ctx, "BackgroundMusic", memoryKey{ParentID: userID, ID: id}, &music); err != nil {
+		return BackgroundMusic{}, err
+	}
+
+	music.ID = id
+
+	return music, nil
+}
+
+func (r memoryBackgroundMusicRepository) list(ctx context.Context, page Page, matches func(key memoryKey, music BackgroundMusic) bool, less func(a, b BackgroundMusic) bool) ([]BackgroundMusic, string, error) {
+	var musics []BackgroundMusic
+	for _, entry := range r.db.entries(ctx, "BackgroundMusic") {
+		var music BackgroundMusic
+		if err := entry.decode(&music); err != nil {
+			return nil, "", err
+		}
+		if matches(entry.key, music) {
+			music.ID = entry.key.ID
+			musics = append(musics, music)
+		}
+	}
+
+	sort.SliceStable(musics, func(i, j int) bool {
+		return less(musics[i], musics[j])
+	})
+
+	start, end, nextCursor, err := memoryPage(len(musics), page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return musics[start:end], nextCursor, nil
+}
+
+func (r memoryBackgroundMusicRepository) ListPublic(ctx context.Context, page Page) ([]BackgroundMusic, string, error) {
+	return r.list(ctx, page, func(key memoryKey, music BackgroundMusic) bool {
+		return music.IsPublic
+	}, func(a, b BackgroundMusic) bool {
+		return a.SortID < b.SortID
+	})
+}
+
+func (r memoryBackgroundMusicRepository) ListByUserID(ctx context.Context, userID int64, page Page) ([]BackgroundMusic, string, error) {
+	return r.list(ctx, page, func(key memoryKey, music BackgroundMusic) bool {
+		return key.ParentID == userID
+	}, func(a, b BackgroundMusic) bool {
+		return a.Created.After(b.Created)
+	})
+}
+
+func (r memoryBackgroundMusicRepository) ListAvailableIDs(ctx context.Context, userID int64) ([]int64, error) {
+	var ids []int64
+	for _, entry := range r.db.entries(ctx, "BackgroundMusic") {
+		var music BackgroundMusic
+		if err := entry.decode(&music); err != nil {
+			return nil, err
+		}
+		if music.IsPublic || entry.key.ParentID == userID {
+			ids = append(ids, entry.key.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+func (r memoryBackgroundMusicRepository) Create(ctx context.Context, userID int64, backgroundMusic *BackgroundMusic) error {
+	key, err := r.db.put(ctx, "BackgroundMusic", memoryKey{ParentID: userID}, backgroundMusic)
+	if err != nil {
+		return err
+	}
+
+	backgroundMusic.ID = key.ID
+
+	return nil
+}
+
+func (r memoryBackgroundMusicRepository) Delete(ctx context.Context, userID int64, id int64) error {
+	return r.db.delete(ctx, "BackgroundMusic", memoryKey{ParentID: userID, ID: id})
+}
+
+type memoryBackgroundImageRepository struct {
+	db *MemoryDB
+}
+
+func (r memoryBackgroundImageRepository) List(ctx context.Context) ([]BackgroundImage, error) {
+	var images []BackgroundImage
+	for _, entry := range r.db.entries(ctx, "BackgroundImage") {
+		var image BackgroundImage
+		if err := entry.decode(&image); err != nil {
+			return nil, err
+		}
+		image.ID = entry.key.ID
+		images = append(images, image)
+	}
+
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].SortID < images[j].SortID
+	})
+
+	return images, nil
+}
+
+type memorySubjectRepository struct {
+	db *MemoryDB
+}
+
+func (r memorySubjectRepository) ListSubjects(ctx context.Context) ([]Subject, error) {
+	var subjects []Subject
+	for _, entry := range r.db.entries(ctx, "Subject") {
+		var subject Subject
+		if err := entry.decode(&subject); err != nil {
+			return nil, err
+		}
+		subject.ID = entry.key.ID
+		subjects = append(subjects, subject)
+	}
+
+	sort.SliceStable(subjects, func(i, j int) bool {
+		return subjects[i].SortID < subjects[j].SortID
+	})
+
+	return subjects, nil
+}
+
+func (r memorySubjectRepository) GetSubject(ctx context.Context, id int64) (Subject, error) {
+	var subject Subject
+	if err := r.db.get(ctx, "Subject", memoryKey{ID: id}, &subject); err != nil {
+		return Subject{}, err
+	}
+
+	subject.ID = id
+
+	return subject, nil
+}
+
+func (r memorySubjectRepository) ListJapaneseCategories(ctx context.Context, subjectID int64) ([]Category, error) {
+	var categories []Category
+	for _, entry := range r.db.entries(ctx, "JapaneseCategory") {
+		if entry.key.ParentID != subjectID {
+			continue
+		}
+
+		var category Category
+		if err := entry.decode(&category); err != nil {
+			return nil, err
+		}
+		category.ID = entry.key.ID
+		categories = append(categories, category)
+	}
+
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].SortID < categories[j].SortID
+	})
+
+	return categories, nil
+}
+
+func (r memorySubjectRepository) GetJapaneseCategory(ctx context.Context, subjectID int64, id int64) (Category, error) {
+	var category Category
+	if err := r.db.get(ctx, "JapaneseCategory", memoryKey{ParentID: subjectID, ID: id}, &category); err != nil {
+		return Category{}, err
+	}
+
+	category.ID = id
+
+	return category, nil
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryDBTransactionRollback(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	repos := NewMemoryRepositories(db)
+
+	series := Series{UserID: 1, Title: "before"}
+	if err := repos.Series.Create(ctx, &series); err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("failure")
+	err := db.RunInTransaction(ctx, func(ctx context.Context) error {
+		updated := series
+		updated.Title = "in transaction"
+		if err := repos.Series.Put(ctx, &updated); err != nil {
+			return err
+		}
+
+		// the write outside of the transaction while it is running must be kept after rollback.
+		other := Series{UserID: 2, Title: "outside"}
+		if err := repos.Series.Create(context.Background(), &other); err != nil {
+			return err
+		}
+
+		return failure
+	})
+	if err != failure {
+		t.Fatalf("got %v, want %v", err, failure)
+	}
+
+	got, err := repos.Series.Get(ctx, series.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "before" {
+		t.Errorf("the write in the transaction is not rolled back: %q", got.Title)
+	}
+
+	others, _, err := repos.Series.ListByUserID(ctx, 2, Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(others) != 1 {
+		t.Errorf("the write outside of the transaction is lost: %v", others)
+	}
+}
+
+func TestMemoryDBTransactionIsolation(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryDB()
+	repos := NewMemoryRepositories(db)
+
+	series := Series{UserID: 1, Title: "before"}
+	if err := repos.Series.Create(ctx, &series); err != nil {
+		t.Fatal(err)
+	}
+
+	err := db.RunInTransaction(ctx, func(txCtx context.Context) error {
+		// the write outside of the transaction is not seen from it.
+		outside := series
+		outside.Title = "outside"
+		if err := repos.Series.Put(ctx, &outside); err != nil {
+			return err
+		}
+		if got, _ := repos.Series.Get(txCtx, series.ID); got.Title != "before" {
+			t.Errorf("the transaction reads the write outside of it: %q", got.Title)
+		}
+
+		// the write in the transaction is not seen until commit.
+		inside := Series{UserID: 1, Title: "inside"}
+		if err := repos.Series.Create(txCtx, &inside); err != nil {
+			return err
+		}
+		if _, err := repos.Series.Get(ctx, inside.ID); err != ErrNoSuchEntity {
+			t.Errorf("the write in the transaction is seen before commit: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, _, err := repos.Series.ListByUserID(ctx, 1, Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("got %d series, want 2", len(list))
+	}
+
+	got, err := repos.Series.Get(ctx, series.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "outside" {
+		t.Errorf("the entity not written in the transaction is overwritten on commit: %q", got.Title)
+	}
+}
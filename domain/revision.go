@@ -0,0 +1,27 @@
+package domain
+
+type RevisionErrorCode uint
+
+const (
+	RevisionMismatch RevisionErrorCode = 1
+)
+
+func (e RevisionErrorCode) Error() string {
+	switch e {
+	case RevisionMismatch:
+		return "revision mismatch"
+	default:
+		return "unknown revision error"
+	}
+}
+
+// AnyRevision is the expected revision matches any revision, e.g. "If-Match: *".
+const AnyRevision int64 = -1
+
+// checkRevision returns RevisionMismatch when the entity was updated after the expected revision.
+func checkRevision(expected int64, actual int64) error {
+	if expected != AnyRevision && expected != actual {
+		return RevisionMismatch
+	}
+	return nil
+}
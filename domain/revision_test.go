@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"context"
+	"testing"
+)
+
+func createLessonWithMaterial(t *testing.T) (Lesson, LessonMaterial) {
+	ctx := context.Background()
+
+	lesson := Lesson{UserID: 1, Title: "before"}
+	if err := CreateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+	material := LessonMaterial{UserID: 1, AvatarLightColor: "before"}
+	if err := CreateLessonMaterial(ctx, lesson.ID, &material); err != nil {
+		t.Fatal(err)
+	}
+
+	lesson.MaterialID = material.ID
+	if err := UpdateLesson(ctx, &lesson); err != nil {
+		t.Fatal(err)
+	}
+
+	return lesson, material
+}
+
+func TestUpdateLessonMaterialChecksRevision(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lesson, material := createLessonWithMaterial(t)
+
+	patch := MergePatch{}
+	if err := patch.Set("avatarLightColor", "updated"); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := UpdateLessonMaterial(ctx, material.ID, lesson.ID, material.Revision, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Revision != material.Revision+1 {
+		t.Errorf("revision = %d, want %d", updated.Revision, material.Revision+1)
+	}
+
+	// the editor still has the revision before the update.
+	if _, err := UpdateLessonMaterial(ctx, material.ID, lesson.ID, material.Revision, patch); err != RevisionMismatch {
+		t.Errorf("got %v, want RevisionMismatch", err)
+	}
+
+	if _, err := UpdateLessonMaterial(ctx, material.ID, lesson.ID, AnyRevision, patch); err != nil {
+		t.Errorf("any revision is rejected: %v", err)
+	}
+}
+
+func TestUpdateLessonAndMaterialRollsBackOnRevisionMismatch(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lesson, material := createLessonWithMaterial(t)
+
+	lessonPatch := MergePatch{}
+	if err := lessonPatch.Set("title", "updated"); err != nil {
+		t.Fatal(err)
+	}
+	materialPatch := MergePatch{}
+	if err := materialPatch.Set("avatarLightColor", "updated"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := UpdateLessonAndMaterial(ctx, lesson.ID, material.ID, lesson.Revision-1, material.Revision, lessonPatch, materialPatch); err != RevisionMismatch {
+		t.Fatalf("got %v, want RevisionMismatch", err)
+	}
+
+	// the material is updated before the lesson in the transaction, so it must be rolled back too.
+	got, err := repositories.LessonMaterials.Get(ctx, lesson.ID, material.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AvatarLightColor != "before" || got.Revision != material.Revision {
+		t.Errorf("the material is updated by the rejected request: %q revision %d", got.AvatarLightColor, got.Revision)
+	}
+
+	updated, updatedMaterial, err := UpdateLessonAndMaterial(ctx, lesson.ID, material.ID, lesson.Revision, material.Revision, lessonPatch, materialPatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Title != "updated" || updated.Revision != lesson.Revision+1 {
+		t.Errorf("unexpected lesson: %q revision %d", updated.Title, updated.Revision)
+	}
+	if updatedMaterial.Revision != material.Revision+1 {
+		t.Errorf("unexpected material revision %d", updatedMaterial.Revision)
+	}
+}
+
+func TestUpdateLessonAndMaterialChecksMaterialRevision(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	lesson, material := createLessonWithMaterial(t)
+
+	// the material is updated by another editor after it was fetched with the lesson.
+	otherPatch := MergePatch{}
+	if err := otherPatch.Set("avatarLightColor", "other"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpdateLessonMaterial(ctx, material.ID, lesson.ID, material.Revision, otherPatch); err != nil {
+		t.Fatal(err)
+	}
+
+	lessonPatch := MergePatch{}
+	if err := lessonPatch.Set("title", "updated"); err != nil {
+		t.Fatal(err)
+	}
+	materialPatch := MergePatch{}
+	if err := materialPatch.Set("avatarLightColor", "updated"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := UpdateLessonAndMaterial(ctx, lesson.ID, material.ID, lesson.Revision, material.Revision, lessonPatch, materialPatch); err != RevisionMismatch {
+		t.Fatalf("got %v, want RevisionMismatch", err)
+	}
+
+	got, err := repositories.Lessons.Get(ctx, lesson.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title == "updated" {
+		t.Error("the lesson is updated by the rejected request")
+	}
+	if got, _ := repositories.LessonMaterials.Get(ctx, lesson.ID, material.ID); got.AvatarLightColor != "other" {
+		t.Errorf("the concurrent update of material is overwritten: %q", got.AvatarLightColor)
+	}
+}
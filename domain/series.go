@@ -0,0 +1,325 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+// maxSeriesLessons limits the lessons in the series, so that all of them can be linked in one transaction.
+const maxSeriesLessons = 100
+
+type SeriesErrorCode uint
+
+const (
+	SeriesNotFound           SeriesErrorCode = 1
+	SeriesLessonNotAvailable SeriesErrorCode = 2
+	LessonAlreadyInSeries    SeriesErrorCode = 3
+	DuplicatedSeriesLesson   SeriesErrorCode = 4
+	TooManySeriesLessons     SeriesErrorCode = 5
+	SeriesLessonsMismatch    SeriesErrorCode = 6
+)
+
+func (e SeriesErrorCode) Error() string {
+	switch e {
+	case SeriesNotFound:
+		return "series not found"
+	case SeriesLessonNotAvailable:
+		return "lesson not available for series"
+	case LessonAlreadyInSeries:
+		return "lesson already in another series"
+	case DuplicatedSeriesLesson:
+		return "duplicated lesson in series"
+	case TooManySeriesLessons:
+		return "too many lessons in series"
+	case SeriesLessonsMismatch:
+		return "lessons of series mismatch"
+	default:
+		return "unknown series error"
+	}
+}
+
+// Series is the ordered lessons of user. PrevLessonID and NextLessonID of the lessons are derived from the order.
+type Series struct {
+	ID          int64     `json:"id" datastore:"-"`
+	UserID      int64     `json:"userID"`
+	Title       string    `json:"title" datastore:",noindex"`
+	Description string    `json:"description" datastore:",noindex"`
+	LessonIDs   []int64   `json:"lessonIDs" datastore:",noindex"`
+	Lessons     []Lesson  `json:"lessons,omitempty" datastore:"-"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated" datastore:",noindex"`
+}
+
+// GetSeries returns the series, or SeriesNotFound.
+func GetSeries(ctx context.Context, id int64) (Series, error) {
+	series, err := repositories.Series.Get(ctx, id)
+	if err == ErrNoSuchEntity {
+		return series, SeriesNotFound
+	}
+	return series, err
+}
+
+func GetSeriesByUserID(ctx context.Context, userID int64, page Page) ([]Series, string, error) {
+	return repositories.Series.ListByUserID(ctx, userID, page)
+}
+
+// CreateSeries creates the series with the lessons of the owner, and links the lessons in the order.
+func CreateSeries(ctx context.Context, series *Series) error {
+	return repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		lessonIDs := series.LessonIDs
+		series.LessonIDs = nil
+
+		currentTime := time.Now()
+		series.Created = currentTime
+		series.Updated = currentTime
+		if err := repositories.Series.Create(ctx, series); err != nil {
+			return err
+		}
+
+		return setSeriesLessonsInTransaction(ctx, series, lessonIDs)
+	})
+}
+
+// UpdateSeries applies the patch to the title and description of the series.
+func UpdateSeries(ctx context.Context, id int64, patch MergePatch) (Series, error) {
+	var series Series
+	err := repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if series, err = GetSeries(ctx, id); err != nil {
+			return err
+		}
+
+		lessonIDs := series.LessonIDs
+		if err := patch.Apply(&series); err != nil {
+			return err
+		}
+		series.ID = id
+		series.LessonIDs = lessonIDs
+		series.Updated = time.Now()
+
+		return repositories.Series.Put(ctx, &series)
+	})
+
+	return series, err
+}
+
+// ReorderSeriesLessons replaces the order of lessons, lessonIDs must have the same lessons as the series.
+func ReorderSeriesLessons(ctx context.Context, id int64, lessonIDs []int64) (Series, error) {
+	return updateSeriesLessons(ctx, id, func(current []int64) ([]int64, error) {
+		if len(current) != len(lessonIDs) {
+			return nil, SeriesLessonsMismatch
+		}
+
+		included := make(map[int64]bool)
+		for _, lessonID := range current {
+			included[lessonID] = true
+		}
+		for _, lessonID := range lessonIDs {
+			if !included[lessonID] {
+				return nil, SeriesLessonsMismatch
+			}
+		}
+
+		return lessonIDs, nil
+	})
+}
+
+// AddSeriesLesson inserts the lesson at the position of the series, or appends it when position is out of range.
+func AddSeriesLesson(ctx context.Context, id int64, lessonID int64, position int) (Series, error) {
+	return updateSeriesLessons(ctx, id, func(current []int64) ([]int64, error) {
+		if position < 0 || position > len(current) {
+			position = len(current)
+		}
+
+		lessonIDs := make([]int64, 0, len(current)+1)
+		lessonIDs = append(lessonIDs, current[:position]...)
+		lessonIDs = append(lessonIDs, lessonID)
+		return append(lessonIDs, current[position:]...), nil
+	})
+}
+
+// RemoveSeriesLesson removes the lesson from the series, and links the previous and next lessons each other.
+func RemoveSeriesLesson(ctx context.Context, id int64, lessonID int64) (Series, error) {
+	return updateSeriesLessons(ctx, id, func(current []int64) ([]int64, error) {
+		var lessonIDs []int64
+		for _, currentID := range current {
+			if currentID != lessonID {
+				lessonIDs = append(lessonIDs, currentID)
+			}
+		}
+
+		if len(lessonIDs) == len(current) {
+			return nil, SeriesLessonNotAvailable
+		}
+
+		return lessonIDs, nil
+	})
+}
+
+// DeleteSeries unlinks all lessons of the series and deletes it, the lessons are not deleted.
+func DeleteSeries(ctx context.Context, id int64) error {
+	return repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		series, err := GetSeries(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := setSeriesLessonsInTransaction(ctx, &series, nil); err != nil {
+			return err
+		}
+
+		return repositories.Series.Delete(ctx, id)
+	})
+}
+
+func updateSeriesLessons(ctx context.Context, id int64, lessonIDs func(current []int64) ([]int64, error)) (Series, error) {
+	var series Series
+	err := repositories.Transactor.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if series, err = GetSeries(ctx, id); err != nil {
+			return err
+		}
+
+		newLessonIDs, err := lessonIDs(series.LessonIDs)
+		if err != nil {
+			return err
+		}
+
+		return setSeriesLessonsInTransaction(ctx, &series, newLessonIDs)
+	})
+
+	return series, err
+}
+
+// setSeriesLessonsInTransaction replaces the lessons of the series, and updates the links of the lessons changed.
+// the lessons must be owned by the owner of the series and not be in another series,
+// and each lesson can be in the series only once, so the links never make a cycle.
+func setSeriesLessonsInTransaction(ctx context.Context, series *Series, lessonIDs []int64) error {
+	if len(lessonIDs) > maxSeriesLessons {
+		return TooManySeriesLessons
+	}
+
+	lessons := make(map[int64]Lesson)
+	for _, lessonID := range lessonIDs {
+		if _, ok := lessons[lessonID]; ok {
+			return DuplicatedSeriesLesson
+		}
+
+		lesson, err := repositories.Lessons.Get(ctx, lessonID)
+		if err == ErrNoSuchEntity || (err == nil && (lesson.IsDeleting || lesson.UserID != series.UserID)) {
+			return SeriesLessonNotAvailable
+		} else if err != nil {
+			return err
+		}
+
+		if lesson.SeriesID != 0 && lesson.SeriesID != series.ID {
+			return LessonAlreadyInSeries
+		}
+
+		lessons[lessonID] = lesson
+	}
+
+	for _, lessonID := range series.LessonIDs {
+		if _, ok := lessons[lessonID]; ok {
+			continue
+		}
+
+		lesson, err := repositories.Lessons.Get(ctx, lessonID)
+		if err == ErrNoSuchEntity {
+			continue // the lesson deleted on the way is removed from the series.
+		} else if err != nil {
+			return err
+		}
+
+		if err := linkSeriesLesson(ctx, lesson, 0, 0, 0); err != nil {
+			return err
+		}
+	}
+
+	for i, lessonID := range lessonIDs {
+		var prevLessonID, nextLessonID int64
+		if i > 0 {
+			prevLessonID = lessonIDs[i-1]
+		}
+		if i < len(lessonIDs)-1 {
+			nextLessonID = lessonIDs[i+1]
+		}
+
+		if err := linkSeriesLesson(ctx, lessons[lessonID], series.ID, prevLessonID, nextLessonID); err != nil {
+			return err
+		}
+	}
+
+	series.LessonIDs = lessonIDs
+	series.Updated = time.Now()
+
+	return repositories.Series.Put(ctx, series)
+}
+
+// linkSeriesLesson updates the lesson only when the links are changed, because the revision of lesson is increased.
+func linkSeriesLesson(ctx context.Context, lesson Lesson, seriesID int64, prevLessonID int64, nextLessonID int64) error {
+	if lesson.SeriesID == seriesID && lesson.PrevLessonID == prevLessonID && lesson.NextLessonID == nextLessonID {
+		return nil
+	}
+
+	lesson.SeriesID = seriesID
+	lesson.PrevLessonID = prevLessonID
+	lesson.NextLessonID = nextLessonID
+
+	return UpdateLesson(ctx, &lesson)
+}
+
+type datastoreSeriesRepository struct {
+	datastoreStore
+}
+
+func (r datastoreSeriesRepository) Get(ctx context.Context, id int64) (Series, error) {
+	var series Series
+
+	key := datastore.IDKey("Series", id, nil)
+	if err := r.get(ctx, key, &series); err != nil {
+		return series, err
+	}
+
+	series.ID = id
+
+	return series, nil
+}
+
+func (r datastoreSeriesRepository) ListByUserID(ctx context.Context, userID int64, page Page) ([]Series, string, error) {
+	var series []Series
+
+	query := datastore.NewQuery("Series").Filter("UserID =", userID).Order("-Created")
+	keys, nextCursor, err := getPage(ctx, r.client, query, page, &series)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i, key := range keys {
+		series[i].ID = key.ID
+	}
+
+	return series, nextCursor, nil
+}
+
+func (r datastoreSeriesRepository) Create(ctx context.Context, series *Series) error {
+	key, err := r.put(ctx, datastore.IncompleteKey("Series", nil), series)
+	if err != nil {
+		return err
+	}
+
+	series.ID = key.ID
+
+	return nil
+}
+
+func (r datastoreSeriesRepository) Put(ctx context.Context, series *Series) error {
+	_, err := r.put(ctx, datastore.IDKey("Series", series.ID, nil), series)
+	return err
+}
+
+func (r datastoreSeriesRepository) Delete(ctx context.Context, id int64) error {
+	return r.delete(ctx, datastore.IDKey("Series", id, nil))
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	texttospeech "cloud.google.com/go/texttospeech/apiv1"
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+	texttospeechpb "google.golang.org/genproto/googleapis/cloud/texttospeech/v1"
+)
+
+// SpeechSynthesizer converts text to the audio of MP3.
+type SpeechSynthesizer interface {
+	// Name identifies the provider, the cache of audio is separated by this.
+	Name() string
+	Synthesize(ctx context.Context, text string, config VoiceSynthesisConfig) ([]byte, error)
+}
+
+var speechSynthesizer SpeechSynthesizer
+
+// SetSpeechSynthesizer replaces the provider of synthesis voices. this must be called before handling requests.
+func SetSpeechSynthesizer(synthesizer SpeechSynthesizer) {
+	speechSynthesizer = synthesizer
+}
+
+// synthesizeSpeech returns the audio from the cache if the same text has been synthesized with the same config.
+func synthesizeSpeech(ctx context.Context, text string, config VoiceSynthesisConfig) ([]byte, error) {
+	bucketName := infrastructure.MaterialBucketName()
+	cacheFilePath := synthesisCacheFilePath(speechSynthesizer.Name(), text, config)
+
+	audio, err := infrastructure.GetFile(ctx, bucketName, cacheFilePath)
+	if err == nil {
+		return audio, nil
+	}
+	if err != infrastructure.ErrBlobNotExist {
+		return nil, err
+	}
+
+	audio, err = speechSynthesizer.Synthesize(ctx, text, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := infrastructure.CreateFile(ctx, bucketName, cacheFilePath, "audio/mpeg", audio); err != nil {
+		return nil, err
+	}
+
+	return audio, nil
+}
+
+// synthesisCacheFilePath returns the path addressed by the hash of provider, text and config.
+func synthesisCacheFilePath(providerName string, text string, config VoiceSynthesisConfig) string {
+	configJSON, _ := json.Marshal(config)
+
+	hash := sha256.New()
+	hash.Write([]byte(providerName))
+	hash.Write([]byte{0})
+	hash.Write(configJSON)
+	hash.Write([]byte{0})
+	hash.Write([]byte(text))
+
+	return "synthesis_cache/" + hex.EncodeToString(hash.Sum(nil)) + ".mp3"
+}
+
+// googleSpeechSynthesizer synthesizes voices by Google Cloud Text-to-Speech.
+type googleSpeechSynthesizer struct {
+	client *texttospeech.Client
+}
+
+// NewGoogleSpeechSynthesizer returns the SpeechSynthesizer shares the long-lived client.
+func NewGoogleSpeechSynthesizer(client *texttospeech.Client) SpeechSynthesizer {
+	return googleSpeechSynthesizer{client: client}
+}
+
+func (s googleSpeechSynthesizer) Name() string {
+	return "google"
+}
+
+func (s googleSpeechSynthesizer) Synthesize(ctx context.Context, text string, config VoiceSynthesisConfig) ([]byte, error) {
+	req := texttospeechpb.SynthesizeSpeechRequest{
+		Input: &texttospeechpb.SynthesisInput{
+			InputSource: &texttospeechpb.SynthesisInput_Text{Text: text},
+		},
+		Voice: &texttospeechpb.VoiceSelectionParams{
+			LanguageCode: config.LanguageCode,
+			Name:         config.Name,
+		},
+		AudioConfig: &texttospeechpb.AudioConfig{
+			AudioEncoding: texttospeechpb.AudioEncoding_MP3,
+			SpeakingRate:  config.SpeakingRate,
+			Pitch:         config.Pitch,
+			VolumeGainDb:  config.VolumeGainDb,
+		},
+	}
+
+	resp, err := s.client.SynthesizeSpeech(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.AudioContent, nil
+}
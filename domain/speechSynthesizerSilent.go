@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// the frame of MPEG-1 Layer III, 32kbps, 44.1kHz, mono. the side information filled with zero decodes to silence.
+	silentMP3FrameSize     = 104 // 144 * 32000 / 44100
+	silentMP3FrameDuration = 1152.0 / 44100.0
+
+	japaneseCharactersPerSecond = 8.0
+	latinWordsPerSecond         = 2.5
+	minSilentSpeechDuration     = 0.5
+)
+
+var silentMP3FrameHeader = []byte{0xFF, 0xFB, 0x10, 0xC4}
+
+// silentSpeechSynthesizer makes the silent audio of the estimated duration for tests and local development.
+// the same text and config always make the same audio.
+type silentSpeechSynthesizer struct{}
+
+// NewSilentSpeechSynthesizer returns the SpeechSynthesizer works offline.
+func NewSilentSpeechSynthesizer() SpeechSynthesizer {
+	return silentSpeechSynthesizer{}
+}
+
+func (s silentSpeechSynthesizer) Name() string {
+	return "silent"
+}
+
+func (s silentSpeechSynthesizer) Synthesize(ctx context.Context, text string, config VoiceSynthesisConfig) ([]byte, error) {
+	frameCount := int(math.Ceil(estimateSpeechDuration(text, config.SpeakingRate) / silentMP3FrameDuration))
+
+	frame := make([]byte, silentMP3FrameSize)
+	copy(frame, silentMP3FrameHeader)
+
+	return bytes.Repeat(frame, frameCount), nil
+}
+
+// estimateSpeechDuration returns seconds to read the text aloud, Japanese is counted by characters and the others by words.
+func estimateSpeechDuration(text string, speakingRate float64) float64 {
+	var japaneseCharacters, latinWords int
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		for _, run := range splitByScript(word) {
+			if isLatinWord(run) {
+				latinWords++
+			} else {
+				japaneseCharacters += len([]rune(run))
+			}
+		}
+	}
+
+	if speakingRate <= 0 {
+		speakingRate = 1.0
+	}
+
+	duration := (float64(japaneseCharacters)/japaneseCharactersPerSecond + float64(latinWords)/latinWordsPerSecond) / speakingRate
+	return math.Max(duration, minSilentSpeechDuration)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"cloud.google.com/go/datastore"
-	"github.com/super-dog-human/teraconnectgo/infrastructure"
 )
 
 // Subject of the class type.
@@ -17,14 +16,22 @@ type Subject struct {
 
 // GetAllSubjects is return all sorted subjects.
 func GetAllSubjects(ctx context.Context) ([]Subject, error) {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return nil, err
-	}
+	return repositories.Subjects.ListSubjects(ctx)
+}
+
+// GetSubject is return a subject from id.
+func GetSubject(ctx context.Context, id int64) (Subject, error) {
+	return repositories.Subjects.GetSubject(ctx, id)
+}
 
+type datastoreSubjectRepository struct {
+	datastoreStore
+}
+
+func (r datastoreSubjectRepository) ListSubjects(ctx context.Context) ([]Subject, error) {
 	var subjects []Subject
 	query := datastore.NewQuery("Subject").Order("SortID")
-	keys, err := client.GetAll(ctx, query, &subjects)
+	keys, err := r.client.GetAll(ctx, query, &subjects)
 	if err != nil {
 		return nil, err
 	}
@@ -36,20 +43,14 @@ func GetAllSubjects(ctx context.Context) ([]Subject, error) {
 	return subjects, nil
 }
 
-// GetSubject is return a subject from id.
-func GetSubject(ctx context.Context, id int64) (Subject, error) {
-	subject := new(Subject)
-
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return *subject, err
-	}
+func (r datastoreSubjectRepository) GetSubject(ctx context.Context, id int64) (Subject, error) {
+	var subject Subject
 
 	key := datastore.IDKey("Subject", id, nil)
-	if err := client.Get(ctx, key, subject); err != nil {
-		return *subject, err
+	if err := r.get(ctx, key, &subject); err != nil {
+		return subject, err
 	}
 	subject.ID = id
 
-	return *subject, nil
+	return subject, nil
 }
@@ -4,15 +4,13 @@ import (
 	"context"
 	"fmt"
 
-	texttospeech "cloud.google.com/go/texttospeech/apiv1"
 	"github.com/super-dog-human/teraconnectgo/infrastructure"
 	"golang.org/x/sync/errgroup"
-	texttospeechpb "google.golang.org/genproto/googleapis/cloud/texttospeech/v1"
 )
 
 type CreateSynthesisVoiceParam struct {
-	LessonID int64  `json:"lessonID"`
-	Text     string `json:"text"`
+	LessonID int64  `json:"lessonID" validate:"required"`
+	Text     string `json:"text" validate:"required,max=5000"`
 	VoiceSynthesisConfig
 }
 
@@ -40,33 +38,12 @@ func CreateSynthesisVoice(ctx context.Context, params *CreateSynthesisVoiceParam
 }
 
 func createSynthesizedVoice(ctx context.Context, params *CreateSynthesisVoiceParam, bucketName, filePath string) error {
-	client, err := texttospeech.NewClient(ctx)
+	audio, err := synthesizeSpeech(ctx, params.Text, params.VoiceSynthesisConfig)
 	if err != nil {
 		return err
 	}
 
-	req := texttospeechpb.SynthesizeSpeechRequest{
-		Input: &texttospeechpb.SynthesisInput{
-			InputSource: &texttospeechpb.SynthesisInput_Text{Text: params.Text},
-		},
-		Voice: &texttospeechpb.VoiceSelectionParams{
-			LanguageCode: params.LanguageCode,
-			Name:         params.Name,
-		},
-		AudioConfig: &texttospeechpb.AudioConfig{
-			AudioEncoding: texttospeechpb.AudioEncoding_MP3,
-			SpeakingRate:  params.SpeakingRate,
-			Pitch:         params.Pitch,
-			VolumeGainDb:  params.VolumeGainDb,
-		},
-	}
-
-	resp, err := client.SynthesizeSpeech(ctx, &req)
-	if err != nil {
-		return err
-	}
-
-	if err := infrastructure.CreateFileToGCS(ctx, bucketName, filePath, "audio/mpeg", resp.AudioContent); err != nil {
+	if err := infrastructure.CreateFile(ctx, bucketName, filePath, "audio/mpeg", audio); err != nil {
 		return err
 	}
 
@@ -74,7 +51,7 @@ func createSynthesizedVoice(ctx context.Context, params *CreateSynthesisVoicePar
 }
 
 func getSignedURLOfVoiceFile(ctx context.Context, lessonID int64, voiceID int64, bucketName, filePath string, url *string) error {
-	result, err := infrastructure.GetGCSSignedURL(ctx, bucketName, filePath, "GET", "")
+	result, err := infrastructure.GetSignedURL(ctx, bucketName, filePath, "GET", "")
 	if err != nil {
 		return err
 	}
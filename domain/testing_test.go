@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+// setUpMemoryDomain replaces the repositories, the index and the blob store with the ones on memory or the temporary directory.
+func setUpMemoryDomain(t *testing.T) *MemoryDB {
+	db := NewMemoryDB()
+	SetRepositories(NewMemoryRepositories(db))
+	SetLessonIndex(NewMemoryLessonIndex())
+
+	infrastructure.SetConfig(infrastructure.Config{Buckets: infrastructure.BucketsConfig{Material: "material", Public: "public"}})
+	storageDir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(storageDir) })
+
+	signer := infrastructure.NewHMACURLSigner("https://localhost/storage", []byte("secret"))
+	infrastructure.SetBlobStore(infrastructure.NewFileSystemBlobStore(storageDir, "https://localhost/storage", signer))
+
+	return db
+}
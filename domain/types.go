@@ -13,10 +13,11 @@ type Position3D struct {
 
 // VoiceSynthesisConfig is synthesis voice settings. used from SynthesisVoice params and LessonSpeech.
 // https://github.com/googleapis/go-genproto/blob/master/googleapis/cloud/texttospeech/v1beta1/cloud_tts.pb.go#L663
+// zero values are allowed for the config not set yet.
 type VoiceSynthesisConfig struct {
-	LanguageCode string  `json:"languageCode"` // ja-JP/en-US
-	Name         string  `json:"name"`         // ja-JP-Wavenet-A~D/en-US-Wavenet-A~J
-	SpeakingRate float64 `json:"speakingRate"` // 0.25 ~ 4.0
-	Pitch        float64 `json:"pitch"`        // -20.0 ~ 20.0
-	VolumeGainDb float64 `json:"volumeGainDb"` // -5.0 ~ 10.0
+	LanguageCode string  `json:"languageCode" validate:"omitempty,oneof=ja-JP en-US"` // ja-JP/en-US
+	Name         string  `json:"name" validate:"max=50"`                              // ja-JP-Wavenet-A~D/en-US-Wavenet-A~J
+	SpeakingRate float64 `json:"speakingRate" validate:"omitempty,min=0.25,max=4.0"`  // 0.25 ~ 4.0
+	Pitch        float64 `json:"pitch" validate:"min=-20.0,max=20.0"`                 // -20.0 ~ 20.0
+	VolumeGainDb float64 `json:"volumeGainDb" validate:"min=-5.0,max=10.0"`           // -5.0 ~ 10.0
 }
@@ -2,11 +2,9 @@ package domain
 
 import (
 	"context"
-	"net/http"
 	"time"
 
 	"cloud.google.com/go/datastore"
-	"github.com/super-dog-human/teraconnectgo/infrastructure"
 )
 
 type UserProviderID struct {
@@ -15,12 +13,13 @@ type UserProviderID struct {
 
 // User is application registrated user
 type User struct {
-	ID         int64     `json:"id" datastore:"-"`
-	ProviderID string    `json:"-"`
-	Name       string    `json:"name"`
-	Email      string    `json:"email"`
-	Created    time.Time `json:"-"`
-	Updated    time.Time `json:"-"`
+	ID                     int64     `json:"id" datastore:"-"`
+	ProviderID             string    `json:"-"`
+	Name                   string    `json:"name"`
+	Email                  string    `json:"email"`
+	RequiresReviewApproval bool      `json:"requiresReviewApproval"` // the lessons can't be public until a reviewer approves them.
+	Created                time.Time `json:"-"`
+	Updated                time.Time `json:"-"`
 }
 
 // UserErrorCode is user error code.
@@ -40,64 +39,118 @@ func (e UserErrorCode) Error() string {
 	}
 }
 
-// GetCurrentUser returns user from valid token.
-func GetCurrentUser(request *http.Request) (User, error) {
-	user := new(User) // for return blank user when error
+// GetUserByProviderID returns user of the account in token, the user may be from the cache.
+func GetUserByProviderID(ctx context.Context, providerID string) (User, error) {
+	if user, ok := userCache.get(providerID); ok {
+		return user, nil
+	}
 
-	providerID, err := ProviderID(request)
+	user, err := repositories.Users.GetByProviderID(ctx, providerID)
 	if err != nil {
-		return *user, err
+		if err == ErrNoSuchEntity {
+			return user, UserNotFound
+		}
+		return user, FailedGettingUser
 	}
 
-	var users []User
-	ctx := request.Context()
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+	userCache.set(providerID, user)
+
+	return user, nil
+}
+
+// GetUserByID is return user has ID.
+func GetUserByID(ctx context.Context, id int64) (User, error) {
+	user, err := repositories.Users.Get(ctx, id)
 	if err != nil {
-		return *user, FailedDatastoreInitialize
+		if err == ErrNoSuchEntity {
+			return user, UserNotFound
+		}
+		return user, err
 	}
 
+	return user, nil
+}
+
+// CreateUser creates new user with exclusion control of ProviderID.
+func CreateUser(ctx context.Context, user *User) error {
+	user.Created = time.Now()
+
+	return repositories.Users.Create(ctx, user)
+}
+
+// UpdateUser updates user.
+func UpdateUser(ctx context.Context, user *User) error {
+	user.Updated = time.Now()
+	defer userCache.delete(user.ID)
+
+	return repositories.Users.Put(ctx, user)
+}
+
+// DeleteUser deletes user.
+func DeleteUser(ctx context.Context, id int64) error {
+	defer userCache.delete(id)
+
+	return repositories.Users.Delete(ctx, id)
+}
+
+type datastoreUserRepository struct {
+	datastoreStore
+}
+
+func (r datastoreUserRepository) Get(ctx context.Context, id int64) (User, error) {
+	var user User
+
+	key := datastore.IDKey("User", id, nil)
+	if err := r.get(ctx, key, &user); err != nil {
+		return user, err
+	}
+
+	user.ID = id
+
+	return user, nil
+}
+
+func (r datastoreUserRepository) GetByProviderID(ctx context.Context, providerID string) (User, error) {
+	var users []User
+
 	query := datastore.NewQuery("User").Filter("ProviderID =", providerID).Limit(1)
-	keys, err := client.GetAll(ctx, query, &users)
+	keys, err := r.client.GetAll(ctx, query, &users)
 	if err != nil {
-		return *user, FailedGettingUser
+		return User{}, err
 	}
 
 	if len(users) == 0 {
-		return *user, UserNotFound
+		return User{}, ErrNoSuchEntity
 	}
 
-	user = &users[0]
-	user.ID = keys[0].ID
+	users[0].ID = keys[0].ID
 
-	return *user, nil
+	return users[0], nil
 }
 
-// GetUserByID is return user has ID.
-func GetUserByID(ctx context.Context, id int64) (User, error) {
-	user := new(User)
-
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return *user, err
-	}
+func (r datastoreUserRepository) Create(ctx context.Context, user *User) error {
+	return r.RunInTransaction(ctx, func(ctx context.Context) error {
+		if err := r.reserveProviderID(ctx, user.ProviderID); err != nil {
+			return err
+		}
 
-	key := datastore.IDKey("User", id, nil)
-	if err := client.Get(ctx, key, user); err != nil {
-		if err == datastore.ErrNoSuchEntity {
-			return *user, UserNotFound
+		key, err := r.put(ctx, datastore.IncompleteKey("User", nil), user)
+		if err != nil {
+			return err
 		}
-		return *user, err
-	}
 
-	return *user, nil
+		user.ID = key.ID
+
+		return nil
+	})
 }
 
-// ReserveUserProviderIDInTransaction creates user's ProviderID for exclusion control.
-func ReserveUserProviderIDInTransaction(tx *datastore.Transaction, providerID string) error {
+// reserveProviderID creates user's ProviderID for exclusion control.
+func (r datastoreUserRepository) reserveProviderID(ctx context.Context, providerID string) error {
 	key := datastore.NameKey("UserProviderID", providerID, nil)
 	userProviderID := new(UserProviderID)
 
-	err := tx.Get(key, userProviderID)
+	err := r.get(ctx, key, userProviderID)
 	if err == nil {
 		return AlreadyProviderIDExists
 	}
@@ -106,53 +159,20 @@ func ReserveUserProviderIDInTransaction(tx *datastore.Transaction, providerID st
 	}
 
 	// Put only when ErrNoSuchEntity
-	_, err = tx.Put(key, userProviderID)
+	_, err = r.put(ctx, key, userProviderID)
 	return err
 }
 
-// CreateUserInTransaction creates new user.
-func CreateUserInTransaction(tx *datastore.Transaction, user *User) (*datastore.PendingKey, error) {
-	key := datastore.IncompleteKey("User", nil)
-
-	user.Created = time.Now()
-
-	pendingKey, err := tx.Put(key, user)
-	if err != nil {
-		return nil, err
-	}
-
-	return pendingKey, nil
-}
-
-// UpdateUser updates user.
-func UpdateUser(ctx context.Context, user *User) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
-	}
-
+func (r datastoreUserRepository) Put(ctx context.Context, user *User) error {
 	key := datastore.IDKey("User", user.ID, nil)
-
-	user.Updated = time.Now()
-
-	if _, err := client.Put(ctx, key, user); err != nil {
+	if _, err := r.put(ctx, key, user); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// DeleteUser deletes user.
-func DeleteUser(ctx context.Context, id int64) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
-	}
-
+func (r datastoreUserRepository) Delete(ctx context.Context, id int64) error {
 	key := datastore.IDKey("User", id, nil)
-	if err := client.Delete(ctx, key); err != nil {
-		return err
-	}
-
-	return nil
+	return r.delete(ctx, key)
 }
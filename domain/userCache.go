@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCachedUsers bounds the memory, the cache is cleared when it is full.
+const maxCachedUsers = 10000
+
+// userByProviderIDCache keeps users looked up by ProviderID for a short time.
+// the cache is per instance, so the user updated on the other instance may be stale until ttl passes.
+type userByProviderIDCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedUser
+}
+
+type cachedUser struct {
+	user    User
+	expires time.Time
+}
+
+var userCache = &userByProviderIDCache{}
+
+// SetUserCacheTTL enables the cache of users looked up by token. the cache is disabled when ttl is zero.
+func SetUserCacheTTL(ttl time.Duration) {
+	userCache.mu.Lock()
+	defer userCache.mu.Unlock()
+
+	userCache.ttl = ttl
+	userCache.entries = make(map[string]cachedUser)
+}
+
+func (c *userByProviderIDCache) get(providerID string) (User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[providerID]
+	if !ok {
+		return User{}, false
+	}
+
+	if time.Now().After(entry.expires) {
+		delete(c.entries, providerID)
+		return User{}, false
+	}
+
+	return entry.user, true
+}
+
+func (c *userByProviderIDCache) set(providerID string, user User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl <= 0 {
+		return
+	}
+
+	if len(c.entries) >= maxCachedUsers {
+		c.entries = make(map[string]cachedUser)
+	}
+
+	c.entries[providerID] = cachedUser{user: user, expires: time.Now().Add(c.ttl)}
+}
+
+func (c *userByProviderIDCache) delete(userID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for providerID, entry := range c.entries {
+		if entry.user.ID == userID {
+			delete(c.entries, providerID)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetUserByProviderID(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	SetUserCacheTTL(0)
+
+	if _, err := GetUserByProviderID(ctx, "google_1"); err != UserNotFound {
+		t.Errorf("got %v, want UserNotFound", err)
+	}
+
+	user := User{ProviderID: "google_1", Name: "before"}
+	if err := CreateUser(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateUser(ctx, &User{ProviderID: "google_1"}); err != AlreadyProviderIDExists {
+		t.Errorf("got %v, want AlreadyProviderIDExists", err)
+	}
+
+	// the change is seen at once without the cache.
+	user.Name = "after"
+	if err := repositories.Users.Put(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := GetUserByProviderID(ctx, "google_1"); err != nil || got.Name != "after" {
+		t.Errorf("got %q %v, want the user updated", got.Name, err)
+	}
+}
+
+func TestGetUserByProviderIDCache(t *testing.T) {
+	ctx := context.Background()
+	setUpMemoryDomain(t)
+	SetUserCacheTTL(time.Minute)
+	t.Cleanup(func() { SetUserCacheTTL(0) })
+
+	user := User{ProviderID: "google_1", Name: "before"}
+	if err := CreateUser(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetUserByProviderID(ctx, "google_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the change on the other instance is not seen until the cache expires.
+	changed := user
+	changed.Name = "other instance"
+	if err := repositories.Users.Put(ctx, &changed); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetUserByProviderID(ctx, "google_1"); got.Name != "before" {
+		t.Errorf("got %q, want the cached user", got.Name)
+	}
+
+	// the change on the same instance clears the cache.
+	user.Name = "after"
+	if err := UpdateUser(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetUserByProviderID(ctx, "google_1"); got.Name != "after" {
+		t.Errorf("got %q, want the updated user", got.Name)
+	}
+
+	if err := DeleteUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetUserByProviderID(ctx, "google_1"); err != UserNotFound {
+		t.Errorf("got %v, want UserNotFound for the deleted user", err)
+	}
+}
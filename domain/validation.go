@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+// FieldError is the invalid field of params. Field is the path of JSON, e.g. "speeches[0].elapsedTime".
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned when params have invalid fields.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	return "invalid params: " + strings.Join(messages, ", ")
+}
+
+// contentTypesByExtension is the files allowed to upload.
+var contentTypesByExtension = map[string][]string{
+	"png":  {"image/png"},
+	"jpg":  {"image/jpeg"},
+	"jpeg": {"image/jpeg"},
+	"gif":  {"image/gif"},
+	"vrm":  {"model/gltf-binary", "application/octet-stream"},
+	"mp3":  {"audio/mpeg"},
+}
+
+// embeddedFieldName is the name of embedded structs in the namespace of errors, it is removed from the path.
+const embeddedFieldName = "~"
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		if field.Anonymous {
+			return embeddedFieldName
+		}
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	v.RegisterValidation("extension", func(fl validator.FieldLevel) bool {
+		_, ok := contentTypesByExtension[fl.Field().String()]
+		return ok
+	})
+
+	// contenttype is valid when it is allowed for the extension in the field of the param.
+	v.RegisterValidation("contenttype", func(fl validator.FieldLevel) bool {
+		extension, _, _, ok := fl.GetStructFieldOK2()
+		if !ok {
+			return false
+		}
+		for _, contentType := range contentTypesByExtension[extension.String()] {
+			if fl.Field().String() == contentType {
+				return true
+			}
+		}
+		return false
+	})
+
+	return v
+}
+
+// Validate checks params with the rules in "validate" tags, and returns ValidationErrors when they are invalid.
+func Validate(params interface{}) error {
+	err := validate.Struct(params)
+	if err == nil {
+		return nil
+	}
+
+	fieldErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	errs := make(ValidationErrors, len(fieldErrs))
+	for i, fieldErr := range fieldErrs {
+		errs[i] = FieldError{
+			Field:   fieldPath(fieldErr.Namespace()),
+			Rule:    fieldErr.Tag(),
+			Message: fieldErrorMessage(fieldErr),
+		}
+	}
+
+	return errs
+}
+
+// fieldPath removes the name of the struct from the namespace, and the fields of embedded structs are flattened same as JSON.
+func fieldPath(namespace string) string {
+	names := strings.Split(namespace, ".")[1:]
+
+	path := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != embeddedFieldName {
+			path = append(path, name)
+		}
+	}
+
+	return strings.Join(path, ".")
+}
+
+func fieldErrorMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		if fieldErr.Kind() == reflect.String || fieldErr.Kind() == reflect.Slice {
+			return fmt.Sprintf("must have at least %s items or characters", fieldErr.Param())
+		}
+		return "must be greater than or equal to " + fieldErr.Param()
+	case "max", "lte":
+		if fieldErr.Kind() == reflect.String || fieldErr.Kind() == reflect.Slice {
+			return fmt.Sprintf("must have at most %s items or characters", fieldErr.Param())
+		}
+		return "must be less than or equal to " + fieldErr.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "extension":
+		return "is not allowed extension"
+	case "contenttype":
+		return "is not allowed content type for the extension"
+	default:
+		return "is invalid"
+	}
+}
+
+// ValidateFileExtensions checks the extensions of files are allowed for the entity, e.g. only "vrm" for avatars.
+func ValidateFileExtensions(fileRequests []infrastructure.FileRequest, extensions ...string) error {
+	var errs ValidationErrors
+	for i, fileRequest := range fileRequests {
+		if !containsString(extensions, fileRequest.Extension) {
+			errs = append(errs, FieldError{
+				Field:   fmt.Sprintf("fileRequests[%d].extension", i),
+				Rule:    "oneof",
+				Message: "must be one of " + strings.Join(extensions, ", "),
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+type speechesParams struct {
+	Speeches []LessonSpeech `json:"speeches" validate:"dive"`
+}
+
+// materialParams embeds the params same as the params of usecases, the fields are flattened in the path.
+type materialParams struct {
+	speechesParams
+	References []LessonReferences `json:"references" validate:"max=2,dive"`
+}
+
+func TestValidate(t *testing.T) {
+	validSpeech := LessonSpeech{
+		ElapsedTime: 1.5,
+		DurationSec: 2,
+		SynthesisConfig: VoiceSynthesisConfig{
+			LanguageCode: "ja-JP",
+			SpeakingRate: 1,
+			Pitch:        -20,
+			VolumeGainDb: 10,
+		},
+	}
+
+	tests := []struct {
+		name   string
+		params materialParams
+		errs   ValidationErrors
+	}{
+		{"valid", materialParams{
+			speechesParams: speechesParams{[]LessonSpeech{validSpeech, {}}},
+			References:     []LessonReferences{{Name: "book", ISBN: "9784873119694"}},
+		}, nil},
+		{"negative elapsed time", materialParams{
+			speechesParams: speechesParams{[]LessonSpeech{validSpeech, {ElapsedTime: -1}}},
+		}, ValidationErrors{
+			{"speeches[1].elapsedTime", "gte", "must be greater than or equal to 0"},
+		}},
+		{"synthesis config out of range", materialParams{
+			speechesParams: speechesParams{[]LessonSpeech{{SynthesisConfig: VoiceSynthesisConfig{
+				LanguageCode: "fr-FR",
+				SpeakingRate: 4.5,
+				Pitch:        -20.5,
+				VolumeGainDb: 10.5,
+			}}}},
+		}, ValidationErrors{
+			{"speeches[0].synthesisConfig.languageCode", "oneof", "must be one of ja-JP, en-US"},
+			{"speeches[0].synthesisConfig.speakingRate", "max", "must be less than or equal to 4.0"},
+			{"speeches[0].synthesisConfig.pitch", "min", "must be greater than or equal to -20.0"},
+			{"speeches[0].synthesisConfig.volumeGainDb", "max", "must be less than or equal to 10.0"},
+		}},
+		{"too many references", materialParams{
+			References: []LessonReferences{{}, {}, {}},
+		}, ValidationErrors{
+			{"references", "max", "must have at most 2 items or characters"},
+		}},
+		{"invalid ISBN", materialParams{
+			References: []LessonReferences{{ISBN: "9784873119695"}},
+		}, ValidationErrors{
+			{"references[0].isbn", "isbn13", "is invalid"},
+		}},
+	}
+
+	for _, test := range tests {
+		err := Validate(test.params)
+		if test.errs == nil {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			}
+			continue
+		}
+
+		errs, ok := err.(ValidationErrors)
+		if !ok || !reflect.DeepEqual(errs, test.errs) {
+			t.Errorf("%s: got %#v, want %#v", test.name, err, test.errs)
+		}
+	}
+}
+
+func TestValidateFileRequests(t *testing.T) {
+	tests := []struct {
+		name         string
+		fileRequests []infrastructure.FileRequest
+		errs         ValidationErrors
+	}{
+		{"valid", []infrastructure.FileRequest{
+			{Extension: "png", ContentType: "image/png"},
+			{Extension: "vrm", ContentType: "application/octet-stream"},
+		}, nil},
+		{"no files", nil, ValidationErrors{
+			{"fileRequests", "required", "is required"},
+		}},
+		{"not allowed extension", []infrastructure.FileRequest{
+			{Extension: "exe", ContentType: "application/octet-stream"},
+		}, ValidationErrors{
+			{"fileRequests[0].extension", "extension", "is not allowed extension"},
+			{"fileRequests[0].contentType", "contenttype", "is not allowed content type for the extension"},
+		}},
+		{"content type of another extension", []infrastructure.FileRequest{
+			{Extension: "png", ContentType: "image/png"},
+			{Extension: "jpg", ContentType: "image/png"},
+		}, ValidationErrors{
+			{"fileRequests[1].contentType", "contenttype", "is not allowed content type for the extension"},
+		}},
+	}
+
+	for _, test := range tests {
+		err := Validate(infrastructure.StorageObjectRequest{FileRequests: test.fileRequests})
+		if test.errs == nil {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			}
+			continue
+		}
+
+		errs, ok := err.(ValidationErrors)
+		if !ok || !reflect.DeepEqual(errs, test.errs) {
+			t.Errorf("%s: got %#v, want %#v", test.name, err, test.errs)
+		}
+	}
+}
+
+func TestValidateFileExtensions(t *testing.T) {
+	fileRequests := []infrastructure.FileRequest{
+		{Extension: "vrm"},
+		{Extension: "png"},
+	}
+
+	if err := ValidateFileExtensions(fileRequests, "vrm", "png"); err != nil {
+		t.Errorf("allowed extensions: %v", err)
+	}
+
+	err := ValidateFileExtensions(fileRequests, "vrm")
+	want := ValidationErrors{{"fileRequests[1].extension", "oneof", "must be one of vrm"}}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("got %#v, want %#v", err, want)
+	}
+}
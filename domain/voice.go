@@ -41,87 +41,140 @@ type Voice struct {
 
 // GetVoice is get voice entities belongs to lesson.
 func GetVoice(ctx context.Context, lessonID int64, voice *Voice) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+	found, err := repositories.Voices.Get(ctx, lessonID, voice.ID)
 	if err != nil {
-		return err
-	}
-
-	ancestor := datastore.IDKey("Lesson", lessonID, nil)
-	key := datastore.IDKey("Voice", voice.ID, ancestor)
-	if err := client.Get(ctx, key, voice); err != nil {
-		if err == datastore.ErrNoSuchEntity {
+		if err == ErrNoSuchEntity {
 			return VoiceNotFound
 		}
 		return err
 	}
 
+	*voice = found
 	storeVoiceURL(ctx, lessonID, voice)
 
 	return nil
 }
 
-// GetVoices is get voice entities belongs to lesson.
-func GetVoices(ctx context.Context, lessonID int64, voices *[]Voice) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
-	}
-
-	ancestor := datastore.IDKey("Lesson", lessonID, nil)
-	query := datastore.NewQuery("Voice").Filter("IsSynthesis =", false).Ancestor(ancestor).Order("ElapsedTime")
-
-	keys, err := client.GetAll(ctx, query, voices)
+// GetVoices is get the page of voice entities belongs to lesson, and returns the cursor of next page.
+func GetVoices(ctx context.Context, lessonID int64, page Page, voices *[]Voice) (string, error) {
+	found, nextCursor, err := repositories.Voices.ListByLessonID(ctx, lessonID, page)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if len(*voices) == 0 {
-		return VoiceNotFound
+	if len(found) == 0 && page.IsFirst() {
+		return "", VoiceNotFound
 	}
 
-	for i, key := range keys {
-		(*voices)[i].ID = key.ID
-	}
+	*voices = append(*voices, found...)
 
 	// 複数のVoice取得時、署名付きURLは時間がかかりすぎるので発行しない
 
-	return nil
+	return nextCursor, nil
 }
 
 // CreateVoice is creates new voice.
 func CreateVoice(ctx context.Context, lessonID int64, voice *Voice) error {
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
+	voice.Created = time.Now()
+
+	return repositories.Voices.Create(ctx, lessonID, voice)
+}
+
+func storeVoiceURL(ctx context.Context, lessonID int64, voice *Voice) error {
+	lessonIDString := strconv.FormatInt(lessonID, 10)
+
+	fileID := strconv.FormatInt(voice.ID, 10)
+	filePath := fmt.Sprintf("voice/%s/%s.mp3", lessonIDString, fileID)
+	bucketName := infrastructure.MaterialBucketName()
+
+	url, err := infrastructure.GetSignedURL(ctx, bucketName, filePath, "GET", "")
 	if err != nil {
 		return err
 	}
 
-	voice.Created = time.Now()
+	voice.URL = url
+
+	return nil
+}
+
+type datastoreVoiceRepository struct {
+	datastoreStore
+}
+
+func (r datastoreVoiceRepository) Get(ctx context.Context, lessonID int64, id int64) (Voice, error) {
+	var voice Voice
 
 	ancestor := datastore.IDKey("Lesson", lessonID, nil)
-	key := datastore.IncompleteKey("Voice", ancestor)
-	putKey, err := client.Put(ctx, key, voice)
-	if err != nil {
-		return err
+	key := datastore.IDKey("Voice", id, ancestor)
+	if err := r.get(ctx, key, &voice); err != nil {
+		return voice, err
 	}
 
-	voice.ID = putKey.ID
+	voice.ID = id
 
-	return nil
+	return voice, nil
 }
 
-func storeVoiceURL(ctx context.Context, lessonID int64, voice *Voice) error {
-	lessonIDString := strconv.FormatInt(lessonID, 10)
+func (r datastoreVoiceRepository) GetMulti(ctx context.Context, lessonID int64, ids []int64) ([]Voice, error) {
+	ancestor := datastore.IDKey("Lesson", lessonID, nil)
+	keys := make([]*datastore.Key, len(ids))
+	for i, id := range ids {
+		keys[i] = datastore.IDKey("Voice", id, ancestor)
+	}
 
-	fileID := strconv.FormatInt(voice.ID, 10)
-	filePath := fmt.Sprintf("voice/%s/%s.mp3", lessonIDString, fileID)
-	bucketName := infrastructure.MaterialBucketName()
+	found := make([]Voice, len(ids))
+	err := r.getMulti(ctx, keys, found)
+	multiErr, isMultiErr := err.(datastore.MultiError)
+	if err != nil && !isMultiErr {
+		return nil, err
+	}
+
+	var voices []Voice
+	for i := range found {
+		if isMultiErr && multiErr[i] != nil {
+			if multiErr[i] == datastore.ErrNoSuchEntity {
+				continue
+			}
+			return nil, multiErr[i]
+		}
+
+		found[i].ID = ids[i]
+		voices = append(voices, found[i])
+	}
+
+	return voices, nil
+}
 
-	url, err := infrastructure.GetGCSSignedURL(ctx, bucketName, filePath, "GET", "")
+func (r datastoreVoiceRepository) ListByLessonID(ctx context.Context, lessonID int64, page Page) ([]Voice, string, error) {
+	var voices []Voice
+
+	ancestor := datastore.IDKey("Lesson", lessonID, nil)
+	query := datastore.NewQuery("Voice").Filter("IsSynthesis =", false).Ancestor(ancestor).Order("ElapsedTime")
+	keys, nextCursor, err := getPage(ctx, r.client, query, page, &voices)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i, key := range keys {
+		voices[i].ID = key.ID
+	}
+
+	return voices, nextCursor, nil
+}
+
+func (r datastoreVoiceRepository) Create(ctx context.Context, lessonID int64, voice *Voice) error {
+	ancestor := datastore.IDKey("Lesson", lessonID, nil)
+	key := datastore.IncompleteKey("Voice", ancestor)
+	putKey, err := r.put(ctx, key, voice)
 	if err != nil {
 		return err
 	}
 
-	voice.URL = url
+	voice.ID = putKey.ID
 
 	return nil
 }
+
+func (r datastoreVoiceRepository) DeleteByLessonID(ctx context.Context, lessonID int64) error {
+	return r.deleteChildren(ctx, "Voice", datastore.IDKey("Lesson", lessonID, nil))
+}
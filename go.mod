@@ -7,7 +7,7 @@ require (
 	cloud.google.com/go/datastore v1.3.0
 	cloud.google.com/go/storage v1.12.0
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
-	github.com/imdario/mergo v0.3.11
+	github.com/go-playground/validator/v10 v10.4.1
 	github.com/jinzhu/copier v0.1.0
 	github.com/labstack/echo/v4 v4.1.17
 	github.com/mattn/go-colorable v0.1.8 // indirect
@@ -19,4 +19,5 @@ require (
 	golang.org/x/sys v0.0.0-20210309074719-68d13333faf2 // indirect
 	google.golang.org/api v0.41.0
 	google.golang.org/genproto v0.0.0-20210309190941-1aeedc14537d
+	gopkg.in/yaml.v2 v2.3.0
 )
@@ -0,0 +1,130 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrBlobNotExist is returned by BlobStore when the object is not found.
+var ErrBlobNotExist = errors.New("blob does not exist")
+
+// BlobAttrs is the metadata of stored object.
+type BlobAttrs struct {
+	Name        string
+	ContentType string
+	Size        int64
+	Updated     time.Time
+}
+
+// BlobStore is the object storage of files, e.g. GCS.
+type BlobStore interface {
+	Put(ctx context.Context, bucketName, filePath, contentType string, contents []byte) error
+	// PutReader stores the contents read from r until EOF, the object is not created when reading fails.
+	PutReader(ctx context.Context, bucketName, filePath, contentType string, r io.Reader) error
+	Get(ctx context.Context, bucketName, filePath string) ([]byte, error)
+	// GetReader returns the reader of the contents, which must be closed by the caller.
+	GetReader(ctx context.Context, bucketName, filePath string) (io.ReadCloser, error)
+	// Copy copies the object in the bucket without reading it, returns ErrBlobNotExist when the source is not found.
+	Copy(ctx context.Context, bucketName, srcPath, dstPath string) error
+	Delete(ctx context.Context, bucketName, filePath string) error
+	Stat(ctx context.Context, bucketName, filePath string) (BlobAttrs, error)
+	// List returns objects whose name starts with the prefix in lexical order.
+	List(ctx context.Context, bucketName, prefix string) ([]BlobAttrs, error)
+	PublicURL(bucketName, filePath string) string
+	SignedURL(ctx context.Context, bucketName, filePath, method, contentType string) (string, error)
+}
+
+var blobStore BlobStore
+
+// SetBlobStore replaces the storage of files. this must be called before handling requests.
+func SetBlobStore(store BlobStore) {
+	blobStore = store
+}
+
+// CreateFile creates the object.
+func CreateFile(ctx context.Context, bucketName, filePath, contentType string, contents []byte) error {
+	return blobStore.Put(ctx, bucketName, filePath, contentType, contents)
+}
+
+// CreateFileFromReader creates the object with the contents read from r, without holding them in memory.
+func CreateFileFromReader(ctx context.Context, bucketName, filePath, contentType string, r io.Reader) error {
+	return blobStore.PutReader(ctx, bucketName, filePath, contentType, r)
+}
+
+// GetFile returns contents of the object.
+func GetFile(ctx context.Context, bucketName, filePath string) ([]byte, error) {
+	return blobStore.Get(ctx, bucketName, filePath)
+}
+
+// OpenFile returns the reader of the object, without holding the contents in memory.
+func OpenFile(ctx context.Context, bucketName, filePath string) (io.ReadCloser, error) {
+	return blobStore.GetReader(ctx, bucketName, filePath)
+}
+
+// CopyFile copies the object to dstPath in the same bucket.
+func CopyFile(ctx context.Context, bucketName, srcPath, dstPath string) error {
+	return blobStore.Copy(ctx, bucketName, srcPath, dstPath)
+}
+
+// DeleteFile deletes the object.
+func DeleteFile(ctx context.Context, bucketName, filePath string) error {
+	return blobStore.Delete(ctx, bucketName, filePath)
+}
+
+// StatFile returns metadata of the object.
+func StatFile(ctx context.Context, bucketName, filePath string) (BlobAttrs, error) {
+	return blobStore.Stat(ctx, bucketName, filePath)
+}
+
+// ListFiles returns metadata of the objects whose name starts with the prefix.
+func ListFiles(ctx context.Context, bucketName, prefix string) ([]BlobAttrs, error) {
+	return blobStore.List(ctx, bucketName, prefix)
+}
+
+// GetPublicURL returns URL of the object in the public bucket.
+func GetPublicURL(bucketName, filePath string) string {
+	return blobStore.PublicURL(bucketName, filePath)
+}
+
+// GetSignedURL returns temporary URL of the object for the method.
+func GetSignedURL(ctx context.Context, bucketName, filePath, method, contentType string) (string, error) {
+	return blobStore.SignedURL(ctx, bucketName, filePath, method, contentType)
+}
+
+// CreateBlankFile creates the empty object in the material bucket, and returns the signed URL for uploading.
+func CreateBlankFile(ctx context.Context, fileID string, fileEntity string, fileRequest FileRequest) (string, error) {
+	return createBlankFile(ctx, MaterialBucketName(), fileID, fileEntity, fileRequest)
+}
+
+// CreateBlankPublicFile creates the empty object in the public bucket, and returns the signed URL for uploading.
+func CreateBlankPublicFile(ctx context.Context, fileID string, fileEntity string, fileRequest FileRequest) (string, error) {
+	return createBlankFile(ctx, PublicBucketName(), fileID, fileEntity, fileRequest)
+}
+
+func createBlankFile(ctx context.Context, bucketName string, fileID string, fileEntity string, fileRequest FileRequest) (string, error) {
+	filePath := StorageObjectFilePath(fileEntity, fileID, fileRequest.Extension)
+
+	if err := CreateFile(ctx, bucketName, filePath, fileRequest.ContentType, nil); err != nil {
+		return "", err
+	}
+
+	return GetSignedURL(ctx, bucketName, filePath, "PUT", fileRequest.ContentType)
+}
+
+// GetPublicBackgroundImageURL returns public image file URL.
+func GetPublicBackgroundImageURL(id string) string {
+	return GetPublicURL(PublicBucketName(), "background/"+id+".jpg")
+}
+
+// GetPublicBackgroundMusicURL returns public audio file URL.
+func GetPublicBackgroundMusicURL(id string) string {
+	return GetPublicURL(PublicBucketName(), "bgm/"+id+".mp3")
+}
+
+func StorageObjectFilePath(entity string, id string, extension string) string {
+	return fmt.Sprintf("%s/%s.%s", strings.ToLower(entity), id, extension)
+}
@@ -1,25 +1,11 @@
 package infrastructure
 
-// MaterialBucketName is return bucket name each environments.
+// MaterialBucketName is return bucket name of private materials.
 func MaterialBucketName() string {
-	switch AppEnv() {
-	case "production":
-		return "teraconn_material"
-	case "staging":
-		return "teraconn_material_staging"
-	default:
-		return "teraconn_material_development"
-	}
+	return config.Buckets.Material
 }
 
-// PublicBucketName is return public bucket name each environments.
+// PublicBucketName is return public bucket name.
 func PublicBucketName() string {
-	switch AppEnv() {
-	case "production":
-		return "teraconn_public"
-	case "staging":
-		return "teraconn_public_staging_2"
-	default:
-		return "teraconn_public_development"
-	}
+	return config.Buckets.Public
 }
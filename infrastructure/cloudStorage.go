@@ -3,16 +3,15 @@ package infrastructure
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
-	"fmt"
+	"io"
 	"log"
-	"strings"
 	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2/google"
 	iam "google.golang.org/api/iam/v1"
+	"google.golang.org/api/iterator"
 )
 
 type SignedURL struct {
@@ -26,14 +25,14 @@ type SignedURLs struct {
 
 type StorageObjectRequest struct {
 	LessonID     int64         `json:"lessonID"`
-	FileRequests []FileRequest `json:"fileRequests"`
+	FileRequests []FileRequest `json:"fileRequests" validate:"required,max=100,dive"`
 }
 
 type FileRequest struct {
 	ID          string `json:"id"`
 	Entity      string `json:"entity"`
-	Extension   string `json:"extension"`
-	ContentType string `json:"contentType"`
+	Extension   string `json:"extension" validate:"required,extension"`
+	ContentType string `json:"contentType" validate:"required,contenttype=Extension"`
 }
 
 type EntityBelongToFile struct {
@@ -58,15 +57,19 @@ func init() {
 	}
 }
 
-// CreateFileToGCS creates object to GCS.
-func CreateFileToGCS(ctx context.Context, bucketName, filePath, contentType string, contents []byte) error {
-	client, err := storage.NewClient(ctx)
-	if err != nil {
-		return err
-	}
-	defer client.Close()
+// gcsBlobStore stores objects in GCS with the long-lived client.
+type gcsBlobStore struct {
+	client *storage.Client
+	signer URLSigner
+}
+
+// NewGCSBlobStore returns the BlobStore of GCS, signed URLs are issued by the signer.
+func NewGCSBlobStore(client *storage.Client, signer URLSigner) BlobStore {
+	return gcsBlobStore{client: client, signer: signer}
+}
 
-	w := client.Bucket(bucketName).Object(filePath).NewWriter(ctx)
+func (s gcsBlobStore) Put(ctx context.Context, bucketName, filePath, contentType string, contents []byte) error {
+	w := s.client.Bucket(bucketName).Object(filePath).NewWriter(ctx)
 	w.ContentType = contentType
 	defer w.Close()
 
@@ -83,113 +86,111 @@ func CreateFileToGCS(ctx context.Context, bucketName, filePath, contentType stri
 	return nil
 }
 
-func CreateBlankFileToGCS(ctx context.Context, fileID string, fileEntity string, fileRequest FileRequest) (string, error) {
-	filePath := StorageObjectFilePath(fileEntity, fileID, fileRequest.Extension)
-	bucketName := MaterialBucketName()
+func (s gcsBlobStore) PutReader(ctx context.Context, bucketName, filePath, contentType string, r io.Reader) error {
+	// canceling the context aborts the upload, so the object is not created with the partial contents.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	if err := CreateFileToGCS(ctx, bucketName, filePath, fileRequest.ContentType, nil); err != nil {
-		return "", err
-	}
+	w := s.client.Bucket(bucketName).Object(filePath).NewWriter(ctx)
+	w.ContentType = contentType
 
-	url, err := GetGCSSignedURL(ctx, bucketName, filePath, "PUT", fileRequest.ContentType)
-	if err != nil {
-		return "", err
+	if _, err := io.Copy(w, r); err != nil {
+		cancel()
+		w.Close()
+		return err
 	}
 
-	return url, err
+	return w.Close()
 }
 
-func CreateBlankFileToPublicGCS(ctx context.Context, fileID string, fileEntity string, fileRequest FileRequest) (string, error) {
-	filePath := StorageObjectFilePath(fileEntity, fileID, fileRequest.Extension)
-	bucketName := PublicBucketName()
-
-	if err := CreateFileToGCS(ctx, bucketName, filePath, fileRequest.ContentType, nil); err != nil {
-		return "", err
+func (s gcsBlobStore) Get(ctx context.Context, bucketName, filePath string) ([]byte, error) {
+	r, err := s.client.Bucket(bucketName).Object(filePath).NewReader(ctx)
+	if err != nil {
+		return nil, gcsError(err)
 	}
+	defer r.Close()
 
-	url, err := GetGCSSignedURL(ctx, bucketName, filePath, "PUT", fileRequest.ContentType)
-	if err != nil {
-		return "", err
+	var buffer bytes.Buffer
+	if _, err := buffer.ReadFrom(r); err != nil {
+		return nil, err
 	}
 
-	return url, err
+	return buffer.Bytes(), nil
 }
 
-// GetFileFromGCS gets object from GCS.
-func GetFileFromGCS(ctx context.Context, bucketName, filePath string) ([]byte, error) {
-	client, err := storage.NewClient(ctx)
+func (s gcsBlobStore) GetReader(ctx context.Context, bucketName, filePath string) (io.ReadCloser, error) {
+	r, err := s.client.Bucket(bucketName).Object(filePath).NewReader(ctx)
 	if err != nil {
-		return nil, err
+		return nil, gcsError(err)
 	}
-	defer client.Close()
 
-	r, err := client.Bucket(bucketName).Object(filePath).NewReader(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer r.Close()
+	return r, nil
+}
 
-	var buffer bytes.Buffer
-	if _, err := buffer.ReadFrom(r); err != nil {
-		return nil, err
+func (s gcsBlobStore) Copy(ctx context.Context, bucketName, srcPath, dstPath string) error {
+	bucket := s.client.Bucket(bucketName)
+	if _, err := bucket.Object(dstPath).CopierFrom(bucket.Object(srcPath)).Run(ctx); err != nil {
+		return gcsError(err)
 	}
 
-	return buffer.Bytes(), nil
+	return nil
 }
 
-// GetGCSSignedURL generates signed-URL for GCS object.
-func GetGCSSignedURL(ctx context.Context, bucket string, key string, method string, contentType string) (string, error) {
-	expire := time.Now().AddDate(0, 0, 3) // expire after 3 days.
-	url, err := storage.SignedURL(bucket, key, &storage.SignedURLOptions{
-		GoogleAccessID: ServiceAccountName(),
-		SignBytes: func(b []byte) ([]byte, error) {
-			resp, err := iamService.Projects.ServiceAccounts.SignBlob(
-				ServiceAccountID(),
-				&iam.SignBlobRequest{BytesToSign: base64.StdEncoding.EncodeToString(b)},
-			).Context(ctx).Do()
-			if err != nil {
-				return nil, err
-			}
-			return base64.StdEncoding.DecodeString(resp.Signature)
-		},
-		Method:      method,
-		ContentType: contentType,
-		Expires:     expire,
-	})
-
-	if err != nil {
-		return url, err
+func (s gcsBlobStore) Delete(ctx context.Context, bucketName, filePath string) error {
+	if err := s.client.Bucket(bucketName).Object(filePath).Delete(ctx); err != nil {
+		return gcsError(err)
 	}
 
-	return url, nil
+	return nil
 }
 
-// DeleteObjectFromGCS deletes object in GCS.
-func DeleteObjectFromGCS(ctx context.Context, bucketName string, filePath string) error {
-	client, err := storage.NewClient(ctx)
+func (s gcsBlobStore) Stat(ctx context.Context, bucketName, filePath string) (BlobAttrs, error) {
+	attrs, err := s.client.Bucket(bucketName).Object(filePath).Attrs(ctx)
 	if err != nil {
-		return err
+		return BlobAttrs{}, gcsError(err)
 	}
-	defer client.Close()
 
-	o := client.Bucket(bucketName).Object(filePath)
-	if err := o.Delete(ctx); err != nil {
-		return err
+	return gcsBlobAttrs(attrs), nil
+}
+
+func (s gcsBlobStore) List(ctx context.Context, bucketName, prefix string) ([]BlobAttrs, error) {
+	var blobs []BlobAttrs
+
+	it := s.client.Bucket(bucketName).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, gcsBlobAttrs(attrs))
 	}
 
-	return nil
+	return blobs, nil
 }
 
-// GetPublicBackgroundImageURL returns public image file URL in GCS.
-func GetPublicBackgroundImageURL(id string) string {
-	return "https://storage.googleapis.com/" + PublicBucketName() + "/background/" + id + ".jpg"
+func (s gcsBlobStore) PublicURL(bucketName, filePath string) string {
+	return "https://storage.googleapis.com/" + bucketName + "/" + filePath
 }
 
-// GetPublicBackgroundMusicURL returns public audio file URL in GCS.
-func GetPublicBackgroundMusicURL(id string) string {
-	return "https://storage.googleapis.com/" + PublicBucketName() + "/bgm/" + id + ".mp3"
+func (s gcsBlobStore) SignedURL(ctx context.Context, bucketName, filePath, method, contentType string) (string, error) {
+	return s.signer.SignURL(ctx, bucketName, filePath, method, contentType, time.Now().Add(time.Duration(config.Storage.SignedURLExpiration)))
 }
 
-func StorageObjectFilePath(entity string, id string, extension string) string {
-	return fmt.Sprintf("%s/%s.%s", strings.ToLower(entity), id, extension)
+func gcsBlobAttrs(attrs *storage.ObjectAttrs) BlobAttrs {
+	return BlobAttrs{
+		Name:        attrs.Name,
+		ContentType: attrs.ContentType,
+		Size:        attrs.Size,
+		Updated:     attrs.Updated,
+	}
+}
+
+func gcsError(err error) error {
+	if err == storage.ErrObjectNotExist {
+		return ErrBlobNotExist
+	}
+	return err
 }
@@ -0,0 +1,518 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	maxSignedURLExpiration = 7 * 24 * time.Hour // the limit of GCS V4 signing.
+	maxJWTClockSkew        = 10 * time.Minute
+	maxUserCacheTTL        = 10 * time.Minute
+)
+
+// Config is the settings of application, loaded from the file and the environment variables.
+type Config struct {
+	AppEnv             string          `json:"appEnv" yaml:"appEnv"`
+	ProjectID          string          `json:"projectID" yaml:"projectID"`
+	ServiceAccountName string          `json:"serviceAccountName" yaml:"serviceAccountName"`
+	Buckets            BucketsConfig   `json:"buckets" yaml:"buckets"`
+	CORS               CORSConfig      `json:"cors" yaml:"cors"`
+	Server             ServerConfig    `json:"server" yaml:"server"`
+	JWT                JWTConfig       `json:"jwt" yaml:"jwt"`
+	Storage            StorageConfig   `json:"storage" yaml:"storage"`
+	Speech             SpeechConfig    `json:"speech" yaml:"speech"`
+	Materials          MaterialsConfig `json:"materials" yaml:"materials"`
+	Views              ViewsConfig     `json:"views" yaml:"views"`
+	Scheduler          SchedulerConfig `json:"scheduler" yaml:"scheduler"`
+}
+
+type BucketsConfig struct {
+	Material string `json:"material" yaml:"material"`
+	Public   string `json:"public" yaml:"public"`
+}
+
+type CORSConfig struct {
+	AllowOrigins []string `json:"allowOrigins" yaml:"allowOrigins"`
+}
+
+type ServerConfig struct {
+	ListenAddress string    `json:"listenAddress" yaml:"listenAddress"`
+	TLS           TLSConfig `json:"tls" yaml:"tls"`
+	// TrustedProxies are the CIDRs of the proxies in front of the server, e.g. the load balancer.
+	// the client address is read from X-Forwarded-For added by them, and it is the address of the peer when this is empty.
+	TrustedProxies []string `json:"trustedProxies" yaml:"trustedProxies"`
+}
+
+// TrustedProxyNets returns TrustedProxies parsed, the invalid ones are rejected by Validate.
+func (c ServerConfig) TrustedProxyNets() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, proxy := range c.TrustedProxies {
+		if _, ipNet, err := net.ParseCIDR(proxy); err == nil {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+// TLSConfig enables HTTPS when both of files are given.
+type TLSConfig struct {
+	CertFile string `json:"certFile" yaml:"certFile"`
+	KeyFile  string `json:"keyFile" yaml:"keyFile"`
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// JWTConfig is the source of the key verifying tokens and the expected claims.
+// the key is read from either of PublicKeyFile or JWKSURL. Issuer and Audiences have no default, because they must match the tokens exactly.
+type JWTConfig struct {
+	PublicKeyFile string   `json:"publicKeyFile" yaml:"publicKeyFile"`
+	JWKSURL       string   `json:"jwksURL" yaml:"jwksURL"`
+	Issuer        string   `json:"issuer" yaml:"issuer"`
+	Audiences     []string `json:"audiences" yaml:"audiences"`
+	ClockSkew     Duration `json:"clockSkew" yaml:"clockSkew"`
+	// UserCacheTTL caches the user of token on each instance, the cache is disabled when it is zero.
+	UserCacheTTL Duration `json:"userCacheTTL" yaml:"userCacheTTL"`
+}
+
+type StorageConfig struct {
+	SignedURLExpiration Duration `json:"signedURLExpiration" yaml:"signedURLExpiration"`
+	// SignedURLKeyFile is the key file of service account, signed URLs are signed by the IAM API when it is empty.
+	SignedURLKeyFile string `json:"signedURLKeyFile" yaml:"signedURLKeyFile"`
+	// LocalDir is the directory used instead of GCS when it is given.
+	LocalDir    string `json:"localDir" yaml:"localDir"`
+	LocalURL    string `json:"localURL" yaml:"localURL"`
+	LocalSecret string `json:"localSecret" yaml:"localSecret"`
+}
+
+// SpeechConfig is the provider of synthesis voices, "google" or "silent" for offline.
+type SpeechConfig struct {
+	Provider string `json:"provider" yaml:"provider"`
+}
+
+// MaterialsConfig is the retention of the versions of lesson materials, and the lint of them on save.
+// the last VersionsKeepLast versions and the last version of each day in VersionsKeepDays days are kept.
+// the materials with lint issues are rejected on save when StrictLint is set.
+type MaterialsConfig struct {
+	VersionsKeepLast int  `json:"versionsKeepLast" yaml:"versionsKeepLast"`
+	VersionsKeepDays int  `json:"versionsKeepDays" yaml:"versionsKeepDays"`
+	StrictLint       bool `json:"strictLint" yaml:"strictLint"`
+}
+
+// ViewsConfig is the counting of lesson views.
+// the views from the same viewer in DedupeWindow are counted once, and added to the lessons on each AggregationInterval.
+type ViewsConfig struct {
+	DedupeWindow        Duration `json:"dedupeWindow" yaml:"dedupeWindow"`
+	AggregationInterval Duration `json:"aggregationInterval" yaml:"aggregationInterval"`
+}
+
+// SchedulerConfig is the scheduled publishing of lessons.
+// the due schedules are applied on each Interval, and by POST /internal/cron/publish with CronToken in X-Cron-Token.
+// the endpoint is disabled when CronToken is empty.
+type SchedulerConfig struct {
+	Interval  Duration `json:"interval" yaml:"interval"`
+	CronToken string   `json:"cronToken" yaml:"cronToken"`
+}
+
+// Duration is time.Duration written as the string like "72h" in the file.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	return d.set(str)
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	return d.set(str)
+}
+
+func (d *Duration) set(str string) error {
+	duration, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// ConfigError is the list of invalid settings.
+type ConfigError []string
+
+func (e ConfigError) Error() string {
+	return "invalid config: " + strings.Join(e, ", ")
+}
+
+var config Config
+
+// SetConfig replaces the settings of application. this must be called before handling requests.
+func SetConfig(c Config) {
+	config = c
+}
+
+// CurrentConfig returns the settings of application.
+func CurrentConfig() Config {
+	return config
+}
+
+// LoadConfig reads the file of YAML or JSON if path is given, then applies appEnv and the environment variables.
+// the numbers not given keep the defaults, and the empty strings are filled with the defaults of the environment.
+func LoadConfig(path string, appEnv string) (Config, error) {
+	c := defaultConfig()
+
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return c, err
+		}
+
+		if strings.ToLower(filepath.Ext(path)) == ".json" {
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.DisallowUnknownFields()
+			err = decoder.Decode(&c)
+		} else {
+			err = yaml.UnmarshalStrict(data, &c)
+		}
+		if err != nil {
+			return c, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+	}
+
+	if appEnv != "" {
+		c.AppEnv = appEnv
+	}
+
+	if err := c.applyEnv(); err != nil {
+		return c, err
+	}
+
+	c.applyDefaults()
+
+	if err := c.Validate(); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (c *Config) applyEnv() error {
+	overrides := map[string]*string{
+		"APP_ENV":              &c.AppEnv,
+		"PROJECT_ID":           &c.ProjectID,
+		"SERVICE_ACCOUNT_NAME": &c.ServiceAccountName,
+		"MATERIAL_BUCKET":      &c.Buckets.Material,
+		"PUBLIC_BUCKET":        &c.Buckets.Public,
+		"LISTEN_ADDRESS":       &c.Server.ListenAddress,
+		"TLS_CERT_FILE":        &c.Server.TLS.CertFile,
+		"TLS_KEY_FILE":         &c.Server.TLS.KeyFile,
+		"JWT_PUBLIC_KEY_FILE":  &c.JWT.PublicKeyFile,
+		"JWT_JWKS_URL":         &c.JWT.JWKSURL,
+		"JWT_ISSUER":           &c.JWT.Issuer,
+		"SIGNED_URL_KEY_FILE":  &c.Storage.SignedURLKeyFile,
+		"LOCAL_STORAGE_DIR":    &c.Storage.LocalDir,
+		"LOCAL_STORAGE_URL":    &c.Storage.LocalURL,
+		"LOCAL_STORAGE_SECRET": &c.Storage.LocalSecret,
+		"SPEECH_PROVIDER":      &c.Speech.Provider,
+		"CRON_TOKEN":           &c.Scheduler.CronToken,
+	}
+	for name, field := range overrides {
+		if value := os.Getenv(name); value != "" {
+			*field = value
+		}
+	}
+
+	if port := os.Getenv("PORT"); port != "" && os.Getenv("LISTEN_ADDRESS") == "" {
+		c.Server.ListenAddress = ":" + port // App Engine gives the port by PORT.
+	}
+
+	if origins := os.Getenv("CORS_ALLOW_ORIGINS"); origins != "" {
+		c.CORS.AllowOrigins = nil
+		for _, origin := range strings.Split(origins, ",") {
+			c.CORS.AllowOrigins = append(c.CORS.AllowOrigins, strings.TrimSpace(origin))
+		}
+	}
+
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		c.Server.TrustedProxies = nil
+		for _, proxy := range strings.Split(proxies, ",") {
+			c.Server.TrustedProxies = append(c.Server.TrustedProxies, strings.TrimSpace(proxy))
+		}
+	}
+
+	if audiences := os.Getenv("JWT_AUDIENCES"); audiences != "" {
+		c.JWT.Audiences = nil
+		for _, audience := range strings.Split(audiences, ",") {
+			c.JWT.Audiences = append(c.JWT.Audiences, strings.TrimSpace(audience))
+		}
+	}
+
+	if skew := os.Getenv("JWT_CLOCK_SKEW"); skew != "" {
+		if err := c.JWT.ClockSkew.set(skew); err != nil {
+			return ConfigError{fmt.Sprintf("JWT_CLOCK_SKEW: %v", err)}
+		}
+	}
+
+	if ttl := os.Getenv("USER_CACHE_TTL"); ttl != "" {
+		if err := c.JWT.UserCacheTTL.set(ttl); err != nil {
+			return ConfigError{fmt.Sprintf("USER_CACHE_TTL: %v", err)}
+		}
+	}
+
+	if expiration := os.Getenv("SIGNED_URL_EXPIRATION"); expiration != "" {
+		if err := c.Storage.SignedURLExpiration.set(expiration); err != nil {
+			return ConfigError{fmt.Sprintf("SIGNED_URL_EXPIRATION: %v", err)}
+		}
+	}
+
+	durationOverrides := map[string]*Duration{
+		"VIEW_DEDUPE_WINDOW":        &c.Views.DedupeWindow,
+		"VIEW_AGGREGATION_INTERVAL": &c.Views.AggregationInterval,
+		"SCHEDULER_INTERVAL":        &c.Scheduler.Interval,
+	}
+	for name, field := range durationOverrides {
+		if value := os.Getenv(name); value != "" {
+			if err := field.set(value); err != nil {
+				return ConfigError{fmt.Sprintf("%s: %v", name, err)}
+			}
+		}
+	}
+
+	intOverrides := map[string]*int{
+		"MATERIAL_VERSIONS_KEEP_LAST": &c.Materials.VersionsKeepLast,
+		"MATERIAL_VERSIONS_KEEP_DAYS": &c.Materials.VersionsKeepDays,
+	}
+	for name, field := range intOverrides {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return ConfigError{fmt.Sprintf("%s: %v", name, err)}
+		}
+		*field = n
+	}
+
+	if strict := os.Getenv("MATERIAL_STRICT_LINT"); strict != "" {
+		b, err := strconv.ParseBool(strict)
+		if err != nil {
+			return ConfigError{fmt.Sprintf("MATERIAL_STRICT_LINT: %v", err)}
+		}
+		c.Materials.StrictLint = b
+	}
+
+	return nil
+}
+
+// defaultConfig returns the settings not depending on the environment. they are set before reading the file,
+// so that zero given explicitly such as "jwt.clockSkew: 0" is kept.
+func defaultConfig() Config {
+	return Config{
+		JWT:       JWTConfig{ClockSkew: Duration(time.Minute)},
+		Storage:   StorageConfig{SignedURLExpiration: Duration(3 * 24 * time.Hour)},
+		Materials: MaterialsConfig{VersionsKeepLast: 20, VersionsKeepDays: 30},
+		Views:     ViewsConfig{DedupeWindow: Duration(30 * time.Minute), AggregationInterval: Duration(5 * time.Minute)},
+		Scheduler: SchedulerConfig{Interval: Duration(time.Minute)},
+	}
+}
+
+// applyDefaults fills the empty settings with the values of each environment.
+func (c *Config) applyDefaults() {
+	if c.AppEnv == "" {
+		c.AppEnv = "development"
+	}
+
+	var projectID, materialBucket, publicBucket, origin string
+	switch c.AppEnv {
+	case "production":
+		projectID, materialBucket, publicBucket, origin = "teraconnect-209509", "teraconn_material", "teraconn_public", "https://teraconnect.org"
+	case "staging":
+		projectID, materialBucket, publicBucket, origin = "teraconnect-staging", "teraconn_material_staging", "teraconn_public_staging_2", "https://teraconnect-staging.an.r.appspot.com"
+	default:
+		projectID, materialBucket, publicBucket, origin = "teraconnect-development", "teraconn_material_development", "teraconn_public_development", "https://dev.teraconnect.org:3000"
+	}
+
+	if c.ProjectID == "" {
+		c.ProjectID = projectID
+	}
+	if c.ServiceAccountName == "" {
+		c.ServiceAccountName = c.ProjectID + "@appspot.gserviceaccount.com"
+	}
+	if c.Buckets.Material == "" {
+		c.Buckets.Material = materialBucket
+	}
+	if c.Buckets.Public == "" {
+		c.Buckets.Public = publicBucket
+	}
+	if len(c.CORS.AllowOrigins) == 0 {
+		c.CORS.AllowOrigins = []string{origin}
+	}
+
+	if c.Server.ListenAddress == "" {
+		if c.AppEnv == "development" {
+			c.Server.ListenAddress = ":443"
+		} else {
+			c.Server.ListenAddress = ":8080"
+		}
+	}
+	if c.AppEnv == "development" && !c.Server.TLS.Enabled() {
+		c.Server.TLS = TLSConfig{CertFile: "localhost.crt", KeyFile: "localhost.key"}
+	}
+
+	if c.JWT.PublicKeyFile == "" && c.JWT.JWKSURL == "" {
+		c.JWT.PublicKeyFile = "./public.pem"
+	}
+
+	if c.Storage.LocalDir != "" && c.Storage.LocalURL == "" {
+		c.Storage.LocalURL = "https://localhost/storage"
+	}
+
+	if c.Speech.Provider == "" {
+		c.Speech.Provider = "google"
+	}
+}
+
+// Validate returns ConfigError with all of invalid settings.
+func (c Config) Validate() error {
+	var errs ConfigError
+
+	switch c.AppEnv {
+	case "development", "staging", "production":
+	default:
+		errs = append(errs, fmt.Sprintf("appEnv must be development, staging or production: %q", c.AppEnv))
+	}
+
+	if c.ProjectID == "" {
+		errs = append(errs, "projectID is required")
+	}
+	if c.ServiceAccountName == "" {
+		errs = append(errs, "serviceAccountName is required")
+	}
+	if c.Buckets.Material == "" || c.Buckets.Public == "" {
+		errs = append(errs, "buckets.material and buckets.public are required")
+	}
+
+	if len(c.CORS.AllowOrigins) == 0 {
+		errs = append(errs, "cors.allowOrigins is required")
+	}
+	for _, origin := range c.CORS.AllowOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			errs = append(errs, fmt.Sprintf("cors.allowOrigins has invalid origin: %q", origin))
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.ListenAddress); err != nil {
+		errs = append(errs, fmt.Sprintf("server.listenAddress is invalid: %q", c.Server.ListenAddress))
+	}
+	if c.Server.TLS.Enabled() {
+		if c.Server.TLS.CertFile == "" || c.Server.TLS.KeyFile == "" {
+			errs = append(errs, "server.tls needs both of certFile and keyFile")
+		}
+		errs = appendFileError(errs, "server.tls.certFile", c.Server.TLS.CertFile)
+		errs = appendFileError(errs, "server.tls.keyFile", c.Server.TLS.KeyFile)
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil {
+			errs = append(errs, fmt.Sprintf("server.trustedProxies has invalid CIDR: %q", proxy))
+		}
+	}
+
+	if (c.JWT.PublicKeyFile == "") == (c.JWT.JWKSURL == "") {
+		errs = append(errs, "either of jwt.publicKeyFile or jwt.jwksURL is required")
+	}
+	errs = appendFileError(errs, "jwt.publicKeyFile", c.JWT.PublicKeyFile)
+	if c.JWT.JWKSURL != "" {
+		if u, err := url.Parse(c.JWT.JWKSURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("jwt.jwksURL must be the URL of https: %q", c.JWT.JWKSURL))
+		}
+	}
+	if c.JWT.Issuer == "" {
+		errs = append(errs, "jwt.issuer is required")
+	}
+	if len(c.JWT.Audiences) == 0 {
+		errs = append(errs, "jwt.audiences is required")
+	}
+	for _, audience := range c.JWT.Audiences {
+		if audience == "" {
+			errs = append(errs, "jwt.audiences has empty audience")
+		}
+	}
+	if skew := time.Duration(c.JWT.ClockSkew); skew < 0 || skew > maxJWTClockSkew {
+		errs = append(errs, fmt.Sprintf("jwt.clockSkew must be between 0 and %v: %v", maxJWTClockSkew, skew))
+	}
+
+	if ttl := time.Duration(c.JWT.UserCacheTTL); ttl < 0 || ttl > maxUserCacheTTL {
+		errs = append(errs, fmt.Sprintf("jwt.userCacheTTL must be between 0 and %v: %v", maxUserCacheTTL, ttl))
+	}
+
+	if expiration := time.Duration(c.Storage.SignedURLExpiration); expiration <= 0 || expiration > maxSignedURLExpiration {
+		errs = append(errs, fmt.Sprintf("storage.signedURLExpiration must be between 0 and %v: %v", maxSignedURLExpiration, expiration))
+	}
+	errs = appendFileError(errs, "storage.signedURLKeyFile", c.Storage.SignedURLKeyFile)
+	if c.Storage.LocalDir != "" {
+		if u, err := url.Parse(c.Storage.LocalURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("storage.localURL is invalid: %q", c.Storage.LocalURL))
+		}
+	}
+
+	switch c.Speech.Provider {
+	case "google", "silent":
+	default:
+		errs = append(errs, fmt.Sprintf("speech.provider must be google or silent: %q", c.Speech.Provider))
+	}
+
+	if c.Materials.VersionsKeepLast < 1 {
+		errs = append(errs, fmt.Sprintf("materials.versionsKeepLast must be 1 or more: %d", c.Materials.VersionsKeepLast))
+	}
+	if c.Materials.VersionsKeepDays < 0 {
+		errs = append(errs, fmt.Sprintf("materials.versionsKeepDays must be 0 or more: %d", c.Materials.VersionsKeepDays))
+	}
+
+	if window := time.Duration(c.Views.DedupeWindow); window < 0 {
+		errs = append(errs, fmt.Sprintf("views.dedupeWindow must be 0 or more: %v", window))
+	}
+	if interval := time.Duration(c.Views.AggregationInterval); interval < time.Second {
+		errs = append(errs, fmt.Sprintf("views.aggregationInterval must be 1s or more: %v", interval))
+	}
+
+	if interval := time.Duration(c.Scheduler.Interval); interval < time.Second {
+		errs = append(errs, fmt.Sprintf("scheduler.interval must be 1s or more: %v", interval))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func appendFileError(errs ConfigError, name, path string) ConfigError {
+	if path == "" {
+		return errs
+	}
+	if _, err := os.Stat(path); err != nil {
+		return append(errs, fmt.Sprintf("%s is not readable: %v", name, err))
+	}
+	return errs
+}
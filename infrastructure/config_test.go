@@ -0,0 +1,126 @@
+package infrastructure
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, contents string) string {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigKeepsExplicitZero(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+appEnv: production
+jwt:
+  jwksURL: https://example.com/jwks.json
+  issuer: https://example.com
+  audiences:
+    - example
+  clockSkew: 0s
+materials:
+  versionsKeepDays: 0
+`)
+
+	c, err := LoadConfig(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.JWT.ClockSkew != 0 {
+		t.Errorf("jwt.clockSkew = %v, want 0", time.Duration(c.JWT.ClockSkew))
+	}
+	if c.Materials.VersionsKeepDays != 0 {
+		t.Errorf("materials.versionsKeepDays = %d, want 0", c.Materials.VersionsKeepDays)
+	}
+	if c.Materials.VersionsKeepLast != 20 {
+		t.Errorf("materials.versionsKeepLast = %d, want the default 20", c.Materials.VersionsKeepLast)
+	}
+	if c.Views.AggregationInterval != Duration(5*time.Minute) {
+		t.Errorf("views.aggregationInterval = %v, want the default 5m", time.Duration(c.Views.AggregationInterval))
+	}
+}
+
+func TestLoadConfigRejectsZeroVersionsKeepLast(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{"appEnv": "production", "jwt": {"jwksURL": "https://example.com/jwks.json", "issuer": "https://example.com", "audiences": ["example"]}, "materials": {"versionsKeepLast": 0}}`)
+
+	_, err := LoadConfig(path, "")
+	if err == nil || !strings.Contains(err.Error(), "materials.versionsKeepLast") {
+		t.Errorf("got %v, want the error of materials.versionsKeepLast", err)
+	}
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	for name, contents := range map[string]string{
+		"config.yaml": "appEnv: production\nunknownKey: 1\n",
+		"config.json": `{"appEnv": "production", "unknownKey": 1}`,
+	} {
+		if _, err := LoadConfig(writeConfigFile(t, name, contents), ""); err == nil || !strings.Contains(err.Error(), "unknownKey") {
+			t.Errorf("%s: got %v, want the error of unknownKey", name, err)
+		}
+	}
+}
+
+func TestLoadConfigTrustedProxies(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+appEnv: production
+jwt:
+  jwksURL: https://example.com/jwks.json
+  issuer: https://example.com
+  audiences:
+    - example
+server:
+  trustedProxies:
+    - 35.191.0.0/16
+`)
+
+	c, err := LoadConfig(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nets := c.Server.TrustedProxyNets(); len(nets) != 1 || nets[0].String() != "35.191.0.0/16" {
+		t.Errorf("got %v, want 35.191.0.0/16", nets)
+	}
+
+	path = writeConfigFile(t, "config.yaml", `
+appEnv: production
+jwt:
+  jwksURL: https://example.com/jwks.json
+  issuer: https://example.com
+  audiences:
+    - example
+server:
+  trustedProxies:
+    - 35.191.0.0
+`)
+	if _, err := LoadConfig(path, ""); err == nil || !strings.Contains(err.Error(), "server.trustedProxies") {
+		t.Errorf("got %v, want the error of server.trustedProxies", err)
+	}
+}
+
+func TestLoadConfigRequiresIssuerAndAudiences(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+appEnv: production
+jwt:
+  jwksURL: https://example.com/jwks.json
+`)
+
+	_, err := LoadConfig(path, "")
+	if err == nil || !strings.Contains(err.Error(), "jwt.issuer") || !strings.Contains(err.Error(), "jwt.audiences") {
+		t.Errorf("got %v, want the errors of jwt.issuer and jwt.audiences", err)
+	}
+}
@@ -0,0 +1,222 @@
+package infrastructure
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fileSystemBlobStore stores objects under the directory for local development and tests.
+// the object is saved to "rootDir/bucketName/filePath".
+type fileSystemBlobStore struct {
+	rootDir string
+	baseURL string
+	signer  URLSigner
+}
+
+// NewFileSystemBlobStore returns the BlobStore on the local directory.
+// baseURL is the URL where the directory is served, URLs of objects are "baseURL/bucketName/filePath".
+func NewFileSystemBlobStore(rootDir, baseURL string, signer URLSigner) BlobStore {
+	return fileSystemBlobStore{rootDir: rootDir, baseURL: strings.TrimSuffix(baseURL, "/"), signer: signer}
+}
+
+func (s fileSystemBlobStore) Put(ctx context.Context, bucketName, filePath, contentType string, contents []byte) error {
+	localPath, err := s.localPath(bucketName, filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(localPath, contents, 0644)
+}
+
+func (s fileSystemBlobStore) PutReader(ctx context.Context, bucketName, filePath, contentType string, r io.Reader) error {
+	localPath, err := s.localPath(bucketName, filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return err
+	}
+
+	// the contents are written to the temporary file and renamed, so the object is not created with the partial contents.
+	f, err := ioutil.TempFile(filepath.Dir(localPath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), localPath)
+}
+
+func (s fileSystemBlobStore) Get(ctx context.Context, bucketName, filePath string) ([]byte, error) {
+	localPath, err := s.localPath(bucketName, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		return nil, fileSystemError(err)
+	}
+
+	return contents, nil
+}
+
+func (s fileSystemBlobStore) GetReader(ctx context.Context, bucketName, filePath string) (io.ReadCloser, error) {
+	localPath, err := s.localPath(bucketName, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return nil, fileSystemError(err)
+	}
+
+	return f, nil
+}
+
+func (s fileSystemBlobStore) Copy(ctx context.Context, bucketName, srcPath, dstPath string) error {
+	localPath, err := s.localPath(bucketName, srcPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return fileSystemError(err)
+	}
+	defer f.Close()
+
+	return s.PutReader(ctx, bucketName, dstPath, "", f)
+}
+
+func (s fileSystemBlobStore) Delete(ctx context.Context, bucketName, filePath string) error {
+	localPath, err := s.localPath(bucketName, filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(localPath); err != nil {
+		return fileSystemError(err)
+	}
+
+	return nil
+}
+
+func (s fileSystemBlobStore) Stat(ctx context.Context, bucketName, filePath string) (BlobAttrs, error) {
+	localPath, err := s.localPath(bucketName, filePath)
+	if err != nil {
+		return BlobAttrs{}, err
+	}
+
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return BlobAttrs{}, fileSystemError(err)
+	}
+	if info.IsDir() {
+		return BlobAttrs{}, ErrBlobNotExist
+	}
+
+	return fileSystemBlobAttrs(filePath, info), nil
+}
+
+func (s fileSystemBlobStore) List(ctx context.Context, bucketName, prefix string) ([]BlobAttrs, error) {
+	bucketDir, err := s.localPath(bucketName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs []BlobAttrs
+	err = filepath.Walk(bucketDir, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil // the bucket has no objects yet.
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(bucketDir, localPath)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(relPath)
+		if strings.HasPrefix(name, prefix) {
+			blobs = append(blobs, fileSystemBlobAttrs(name, info))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return blobs, nil
+}
+
+func (s fileSystemBlobStore) PublicURL(bucketName, filePath string) string {
+	return s.baseURL + "/" + bucketName + "/" + filePath
+}
+
+func (s fileSystemBlobStore) SignedURL(ctx context.Context, bucketName, filePath, method, contentType string) (string, error) {
+	return s.signer.SignURL(ctx, bucketName, filePath, method, contentType, time.Now().Add(time.Duration(config.Storage.SignedURLExpiration)))
+}
+
+// localPath returns the path of the object in the directory, and rejects the path out of the bucket.
+func (s fileSystemBlobStore) localPath(bucketName, filePath string) (string, error) {
+	if bucketName == "" || strings.ContainsAny(bucketName, `/\`) || bucketName == "." || bucketName == ".." {
+		return "", errors.Errorf("invalid bucket name: %q", bucketName)
+	}
+
+	cleanPath := path.Clean("/" + filePath)
+	if filePath != "" && (cleanPath == "/" || strings.TrimPrefix(cleanPath, "/") != strings.TrimSuffix(filePath, "/")) {
+		return "", errors.Errorf("invalid file path: %q", filePath)
+	}
+
+	return filepath.Join(s.rootDir, bucketName, filepath.FromSlash(cleanPath)), nil
+}
+
+func fileSystemBlobAttrs(name string, info os.FileInfo) BlobAttrs {
+	return BlobAttrs{
+		Name:        name,
+		ContentType: mime.TypeByExtension(path.Ext(name)),
+		Size:        info.Size(),
+		Updated:     info.ModTime(),
+	}
+}
+
+func fileSystemError(err error) error {
+	if os.IsNotExist(err) {
+		return ErrBlobNotExist
+	}
+	return err
+}
@@ -2,7 +2,7 @@ package infrastructure
 
 // ServiceAccountName returns email address format of google service account.
 func ServiceAccountName() string {
-	return ProjectID() + "@appspot.gserviceaccount.com"
+	return config.ServiceAccountName
 }
 
 // ServiceAccountID returns full account id.
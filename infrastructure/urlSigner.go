@@ -0,0 +1,124 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"golang.org/x/oauth2/google"
+	iam "google.golang.org/api/iam/v1"
+)
+
+// ErrInvalidSignature is returned when the signed URL is forged or expired.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// URLSigner signs URL of the object for temporary access without credentials.
+type URLSigner interface {
+	SignURL(ctx context.Context, bucketName, filePath, method, contentType string, expires time.Time) (string, error)
+}
+
+// iamURLSigner signs URL for GCS by the IAM SignBlob API with the default service account.
+// this needs no private key, but requests the API on every signing.
+type iamURLSigner struct{}
+
+// NewIAMURLSigner returns the URLSigner uses the IAM SignBlob API.
+func NewIAMURLSigner() URLSigner {
+	return iamURLSigner{}
+}
+
+func (s iamURLSigner) SignURL(ctx context.Context, bucketName, filePath, method, contentType string, expires time.Time) (string, error) {
+	return storage.SignedURL(bucketName, filePath, &storage.SignedURLOptions{
+		GoogleAccessID: ServiceAccountName(),
+		SignBytes: func(b []byte) ([]byte, error) {
+			resp, err := iamService.Projects.ServiceAccounts.SignBlob(
+				ServiceAccountID(),
+				&iam.SignBlobRequest{BytesToSign: base64.StdEncoding.EncodeToString(b)},
+			).Context(ctx).Do()
+			if err != nil {
+				return nil, err
+			}
+			return base64.StdEncoding.DecodeString(resp.Signature)
+		},
+		Method:      method,
+		ContentType: contentType,
+		Expires:     expires,
+	})
+}
+
+// privateKeyURLSigner signs URL for GCS locally with the private key of service account.
+type privateKeyURLSigner struct {
+	googleAccessID string
+	privateKey     []byte
+}
+
+// NewPrivateKeyURLSigner returns the URLSigner uses the key file of service account in JSON format.
+func NewPrivateKeyURLSigner(jsonKey []byte) (URLSigner, error) {
+	config, err := google.JWTConfigFromJSON(jsonKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return privateKeyURLSigner{googleAccessID: config.Email, privateKey: config.PrivateKey}, nil
+}
+
+func (s privateKeyURLSigner) SignURL(ctx context.Context, bucketName, filePath, method, contentType string, expires time.Time) (string, error) {
+	return storage.SignedURL(bucketName, filePath, &storage.SignedURLOptions{
+		GoogleAccessID: s.googleAccessID,
+		PrivateKey:     s.privateKey,
+		Method:         method,
+		ContentType:    contentType,
+		Expires:        expires,
+	})
+}
+
+// HMACURLSigner signs URL served by this application for development.
+// the signature is HMAC-SHA256 of the method, bucket, path, content type and expiration.
+type HMACURLSigner struct {
+	baseURL string
+	secret  []byte
+}
+
+// NewHMACURLSigner returns the URLSigner for the objects served at "baseURL/bucketName/filePath".
+func NewHMACURLSigner(baseURL string, secret []byte) *HMACURLSigner {
+	return &HMACURLSigner{baseURL: strings.TrimSuffix(baseURL, "/"), secret: secret}
+}
+
+func (s *HMACURLSigner) SignURL(ctx context.Context, bucketName, filePath, method, contentType string, expires time.Time) (string, error) {
+	expiresString := strconv.FormatInt(expires.Unix(), 10)
+
+	query := url.Values{}
+	query.Set("Expires", expiresString)
+	query.Set("Signature", s.signature(bucketName, filePath, method, contentType, expiresString))
+
+	return s.baseURL + "/" + bucketName + "/" + filePath + "?" + query.Encode(), nil
+}
+
+// Verify checks the query of signed URL requested with the method and content type.
+func (s *HMACURLSigner) Verify(bucketName, filePath, method, contentType string, query url.Values) error {
+	expiresString := query.Get("Expires")
+	expires, err := strconv.ParseInt(expiresString, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return ErrInvalidSignature
+	}
+
+	expected := s.signature(bucketName, filePath, method, contentType, expiresString)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("Signature"))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (s *HMACURLSigner) signature(bucketName, filePath, method, contentType, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(strings.Join([]string{method, contentType, expires, bucketName, filePath}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
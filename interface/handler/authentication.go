@@ -1,25 +1,82 @@
 package handler
 
 import (
-	"log"
-	"net/http"
+	"crypto/subtle"
 
 	"github.com/labstack/echo/v4"
-	"github.com/pkg/errors"
 	"github.com/super-dog-human/teraconnectgo/domain"
 )
 
-// Authentication validates JWT in header.
+const (
+	tokenClaimsKey = "tokenClaims"
+	currentUserKey = "currentUser"
+)
+
+// Authentication validates JWT in header, and resolves the user of token once per request.
+// the request from the account not registered yet is rejected.
 func Authentication() echo.MiddlewareFunc {
+	return authentication(true, true)
+}
+
+// TokenAuthentication validates JWT in header, and allows the account not registered yet, e.g. for registering.
+func TokenAuthentication() echo.MiddlewareFunc {
+	return authentication(true, false)
+}
+
+// OptionalAuthentication resolves the user when the request has JWT. the request can't have the invalid token.
+func OptionalAuthentication() echo.MiddlewareFunc {
+	return authentication(false, true)
+}
+
+// CronAuthentication allows the request with token in X-Cron-Token, all requests are rejected when token is empty.
+func CronAuthentication(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			requestToken := c.Request().Header.Get("X-Cron-Token")
+			if token == "" || subtle.ConstantTimeCompare([]byte(requestToken), []byte(token)) != 1 {
+				return InvalidCronToken
+			}
+			return next(c)
+		}
+	}
+}
+
+func authentication(tokenRequired bool, userRequired bool) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			request := c.Request()
-			if _, err := domain.ValidTokenClaims(request); err != nil {
-				log.Printf("failed to token validation.\n")
-				log.Printf("%v\n", errors.WithStack(err.(error)).Error())
-				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token.")
+
+			claims, err := domain.ValidTokenClaims(request)
+			if err == domain.TokenNotFound && !tokenRequired {
+				return next(c)
+			}
+			if err != nil {
+				return err
+			}
+			c.Set(tokenClaimsKey, claims)
+
+			user, err := domain.GetUserByProviderID(request.Context(), claims.ProviderID())
+			if err == domain.UserNotFound && !userRequired {
+				return next(c)
 			}
+			if err != nil {
+				return err
+			}
+			c.Set(currentUserKey, user)
+
 			return next(c)
 		}
 	}
 }
+
+// currentUser returns the user resolved by the middleware.
+func currentUser(c echo.Context) (domain.User, bool) {
+	user, ok := c.Get(currentUserKey).(domain.User)
+	return user, ok
+}
+
+// tokenClaims returns the claims validated by the middleware.
+func tokenClaims(c echo.Context) (domain.TokenClaims, bool) {
+	claims, ok := c.Get(tokenClaimsKey).(domain.TokenClaims)
+	return claims, ok
+}
@@ -9,42 +9,42 @@ import (
 	"github.com/super-dog-human/teraconnectgo/usecase"
 )
 
+type getAvatarsResponse struct {
+	Avatars    []domain.Avatar `json:"avatars"`
+	NextCursor string          `json:"nextCursor"`
+}
+
 func getAvatars(c echo.Context) error {
-	avatars, err := usecase.GetAvailableAvatars(c.Request())
+	user, _ := currentUser(c)
+
+	page, err := pageParams(c)
+	if err != nil {
+		return err
+	}
 
+	avatars, nextCursor, err := usecase.GetAvailableAvatars(c.Request().Context(), user, page)
 	if err != nil {
-		authErr, ok := err.(domain.AuthErrorCode)
-		if ok && authErr == domain.UserNotFound {
-			// when token is valid but user account not exists.
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	if len(avatars) == 0 {
-		errMessage := "avatars not found"
-		warnLog(errMessage)
-		return c.JSON(http.StatusNotFound, errMessage)
+	if len(avatars) == 0 && page.IsFirst() {
+		return domain.AvatarNotFound
 	}
 
-	return c.JSON(http.StatusOK, avatars)
+	return c.JSON(http.StatusOK, getAvatarsResponse{Avatars: avatars, NextCursor: nextCursor})
 }
 
 func postAvatars(c echo.Context) error {
+	user, _ := currentUser(c)
+
 	objectRequest := new(infrastructure.StorageObjectRequest)
-	if err := c.Bind(objectRequest); err != nil {
-		fatalLog(err)
-		authErr, ok := err.(domain.AuthErrorCode)
-		if ok && authErr == domain.UserNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		return c.JSON(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, objectRequest); err != nil {
+		return err
 	}
 
-	signedURLs, err := usecase.CreateAvatarsAndBlankFile(c.Request(), *objectRequest)
+	signedURLs, err := usecase.CreateAvatarsAndBlankFile(c.Request().Context(), user, *objectRequest)
 	if err != nil {
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, signedURLs)
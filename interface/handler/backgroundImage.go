@@ -8,9 +8,9 @@ import (
 )
 
 func getBackgroundImages(c echo.Context) error {
-	images, err := usecase.GetBackgroundImages(c.Request())
+	images, err := usecase.GetBackgroundImages(c.Request().Context())
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, images)
 }
@@ -10,44 +10,47 @@ import (
 	"github.com/super-dog-human/teraconnectgo/usecase"
 )
 
+type getBackgroundMusicsResponse struct {
+	BackgroundMusics []domain.BackgroundMusic `json:"backgroundMusics"`
+	NextCursor       string                   `json:"nextCursor"`
+}
+
 func getBackgroundMusics(c echo.Context) error {
-	musics, err := usecase.GetBackgroundMusics(c.Request())
+	user, _ := currentUser(c)
+
+	page, err := pageParams(c)
 	if err != nil {
-		_, ok := err.(domain.AuthErrorCode)
-		if ok {
-			return c.JSON(http.StatusBadRequest, err.Error())
-		}
-		fatalLog(err)
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return err
 	}
-	return c.JSON(http.StatusOK, musics)
+
+	musics, nextCursor, err := usecase.GetBackgroundMusics(c.Request().Context(), user, page)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, getBackgroundMusicsResponse{BackgroundMusics: musics, NextCursor: nextCursor})
 }
 
 func postBackgroundMusic(c echo.Context) error {
+	user, _ := currentUser(c)
+
 	param := new(usecase.CreateBackgroundMusicParam)
 
 	if err := c.Bind(param); err != nil {
-		fatalLog(err)
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return err
 	}
 
 	param.Name = strings.TrimSpace(param.Name)
-	if len(param.Name) == 0 {
-		return c.JSON(http.StatusBadRequest, "invalid name.")
+	if err := c.Validate(param); err != nil {
+		return err
 	}
 
 	if utf8.RuneCountInString(param.Name) > 50 {
 		param.Name = string([]rune(param.Name)[:50])
 	}
 
-	signedURL, err := usecase.CreateBackgroundMusicAndBlankFile(c.Request(), param)
+	signedURL, err := usecase.CreateBackgroundMusicAndBlankFile(c.Request().Context(), user, param)
 	if err != nil {
-		_, ok := err.(domain.AuthErrorCode)
-		if ok {
-			return c.JSON(http.StatusBadRequest, err.Error())
-		}
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, signedURL)
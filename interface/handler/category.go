@@ -8,9 +8,9 @@ import (
 )
 
 func getCategories(c echo.Context) error {
-	categories, err := usecase.GetCategories(c.Request())
+	categories, err := usecase.GetCategories(c.Request().Context(), c.QueryParams())
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, categories)
 
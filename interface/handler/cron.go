@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/usecase"
+)
+
+// postCronPublish is called by Cloud Scheduler, it is safe to call with the in-process scheduler at the same time.
+func postCronPublish(c echo.Context) error {
+	result, err := usecase.ApplyLessonSchedules(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/domain"
+)
+
+// the material patched with the lesson has its own revision, which is sent and returned in these headers.
+const (
+	materialETagHeader    = "Material-ETag"
+	materialIfMatchHeader = "Material-If-Match"
+)
+
+// setETag sets the revision of the entity as the strong ETag, e.g. "3".
+func setETag(c echo.Context, revision int64) {
+	setRevisionHeader(c, "ETag", revision)
+}
+
+func setRevisionHeader(c echo.Context, header string, revision int64) {
+	c.Response().Header().Set(header, strconv.Quote(strconv.FormatInt(revision, 10)))
+}
+
+// ifMatchRevision returns the revision in If-Match header, or domain.AnyRevision for "*".
+// the weak or malformed ETags never match, so they are returned as domain.RevisionMismatch.
+func ifMatchRevision(c echo.Context) (int64, error) {
+	return revisionHeader(c, "If-Match")
+}
+
+// revisionHeader returns the revision in the header in the same format as If-Match.
+func revisionHeader(c echo.Context, header string) (int64, error) {
+	ifMatch := strings.TrimSpace(c.Request().Header.Get(header))
+	if ifMatch == "" {
+		return 0, IfMatchRequired
+	}
+
+	if ifMatch == "*" {
+		return domain.AnyRevision, nil
+	}
+
+	tag, err := strconv.Unquote(ifMatch)
+	if err != nil || !strings.HasPrefix(ifMatch, `"`) {
+		return 0, domain.RevisionMismatch
+	}
+
+	revision, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || revision < 0 {
+		return 0, domain.RevisionMismatch
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/domain"
+)
+
+func TestIfMatchRevision(t *testing.T) {
+	tests := []struct {
+		ifMatch  string
+		revision int64
+		err      error
+	}{
+		{`"3"`, 3, nil},
+		{`*`, domain.AnyRevision, nil},
+		{``, 0, IfMatchRequired},
+		{`W/"3"`, 0, domain.RevisionMismatch},
+		{`3`, 0, domain.RevisionMismatch},
+		{`"-1"`, 0, domain.RevisionMismatch},
+		{`"abc"`, 0, domain.RevisionMismatch},
+	}
+
+	e := echo.New()
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPatch, "/", nil)
+		if test.ifMatch != "" {
+			req.Header.Set("If-Match", test.ifMatch)
+		}
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		revision, err := ifMatchRevision(c)
+		if revision != test.revision || err != test.err {
+			t.Errorf("If-Match %s: got %d %v, want %d %v", test.ifMatch, revision, err, test.revision, test.err)
+		}
+	}
+}
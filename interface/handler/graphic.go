@@ -1,9 +1,7 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/super-dog-human/teraconnectgo/domain"
@@ -12,91 +10,77 @@ import (
 )
 
 func getGraphic(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	user, _ := currentUser(c)
+
+	id, err := idParam(c, "id")
 	if err != nil {
-		errMessage := "Invalid ID(s) error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	graphic, err := usecase.GetGraphicByID(c.Request(), id)
+	graphic, err := usecase.GetGraphicByID(c.Request().Context(), user, id)
 	if err != nil {
-		if ok := errors.Is(err, domain.GraphicNotFound); ok {
-			warnLog(err)
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, graphic)
 }
 
+type getGraphicsResponse struct {
+	Graphics   []*domain.Graphic `json:"graphics"`
+	NextCursor string            `json:"nextCursor"`
+}
+
 func getGraphics(c echo.Context) error {
-	lessonID, err := strconv.ParseInt(c.QueryParam("lesson_id"), 10, 64)
+	user, _ := currentUser(c)
+
+	lessonID, err := idQueryParam(c, "lesson_id")
 	if err != nil {
-		errMessage := "Invalid ID(s) error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	graphics, err := usecase.GetGraphicsByLessonID(c.Request(), lessonID)
+	page, err := pageParams(c)
+	if err != nil {
+		return err
+	}
 
+	graphics, nextCursor, err := usecase.GetGraphicsByLessonID(c.Request().Context(), user, lessonID, c.QueryParam("view_key"), page)
 	if err != nil {
-		fatalLog(err)
-		graphicErr, ok := err.(domain.GraphicErrorCode)
-		if ok && graphicErr == domain.GraphicNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	if len(graphics) == 0 {
-		errMessage := "graphics not found"
-		warnLog(errMessage)
-		return c.JSON(http.StatusNotFound, errMessage)
+	if len(graphics) == 0 && page.IsFirst() {
+		return domain.GraphicNotFound
 	}
 
-	return c.JSON(http.StatusOK, graphics)
+	return c.JSON(http.StatusOK, getGraphicsResponse{Graphics: graphics, NextCursor: nextCursor})
 }
 
 func postGraphics(c echo.Context) error {
+	user, _ := currentUser(c)
+
 	objectRequest := new(infrastructure.StorageObjectRequest)
-	if err := c.Bind(objectRequest); err != nil {
-		fatalLog(err)
-		authErr, ok := err.(domain.AuthErrorCode)
-		if ok && authErr == domain.UserNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		return c.JSON(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, objectRequest); err != nil {
+		return err
 	}
 
-	signedURLs, err := usecase.CreateGraphicsAndBlankFiles(c.Request(), *objectRequest)
+	signedURLs, err := usecase.CreateGraphicsAndBlankFiles(c.Request().Context(), user, *objectRequest)
 	if err != nil {
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, signedURLs)
 }
 
 func deleteGraphic(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil {
-		errMessage := "Invalid ID(s) error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
-	}
+	user, _ := currentUser(c)
 
-	err = usecase.DeleteGraphic(c.Request(), id)
+	id, err := idParam(c, "id")
 	if err != nil {
-		fatalLog(err)
-
-		if ok := errors.Is(err, domain.GraphicNotFound); ok {
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
+		return err
+	}
 
-		return c.JSON(http.StatusInternalServerError, err.Error())
+	if err := usecase.DeleteGraphic(c.Request().Context(), user, id); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, "the graphic has deleted.")
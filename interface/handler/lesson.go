@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/super-dog-human/teraconnectgo/domain"
@@ -10,128 +9,146 @@ import (
 )
 
 func getLessons(c echo.Context) error {
-	lessons, err := usecase.GetLessonsByConditions(c.Request())
+	lessons, nextCursor, err := usecase.GetLessonsByConditions(c.Request().Context(), c.QueryParams())
 	if err != nil {
-		lessonErr, ok := err.(usecase.LessonErrorCode)
-		if ok && lessonErr == usecase.LessonNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		} else if ok {
-			fatalLog(lessonErr)
-			return c.JSON(http.StatusInternalServerError, err.Error())
-		}
-
-		authErr, ok := err.(domain.AuthErrorCode)
-		if ok {
-			fatalLog(authErr)
-			return c.JSON(http.StatusUnauthorized, err.Error())
-		}
-
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	return c.JSON(http.StatusOK, lessons)
+	return c.JSON(http.StatusOK, getLessonsResponse{Lessons: lessons, NextCursor: nextCursor})
 }
 
 func getLesson(c echo.Context) error {
 	var lesson domain.Lesson
-	var err error
 
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := idParam(c, "id")
 	if err != nil {
-		errMessage := "Invalid ID(s) error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
 	if c.QueryParam("for_authoring") == "true" {
-		lesson, err = usecase.GetPrivateLesson(c.Request(), id)
+		user, ok := currentUser(c)
+		if !ok {
+			return AuthenticationRequired
+		}
+		lesson, err = usecase.GetPrivateLesson(c.Request().Context(), user, id)
 	} else {
-		lesson, err = usecase.GetPublicLesson(c.Request(), id)
+		user, _ := currentUser(c)
+		lesson, err = usecase.GetPublicLesson(c.Request().Context(), user, id, c.QueryParam("view_key"))
 	}
 
 	if err != nil {
-		lessonErr, ok := err.(usecase.LessonErrorCode)
-		if ok && lessonErr == usecase.LessonNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		} else if ok && lessonErr == usecase.LessonNotAvailable {
-			warnLog(lessonErr)
-			return c.JSON(http.StatusForbidden, err.Error())
-		}
-
-		authErr, ok := err.(domain.AuthErrorCode)
-		if ok {
-			warnLog(authErr)
-			return c.JSON(http.StatusUnauthorized, err.Error())
-		}
-
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
+	setETag(c, lesson.Revision)
 	return c.JSON(http.StatusOK, lesson)
 }
 
+type getLessonsResponse struct {
+	Lessons    []domain.Lesson `json:"lessons"`
+	NextCursor string          `json:"nextCursor"`
+}
+
 func getCurrentUserLessons(c echo.Context) error {
-	lessons, err := usecase.GetCurrentUserLessons(c.Request())
+	user, _ := currentUser(c)
 
+	page, err := pageParams(c)
 	if err != nil {
-		fatalLog(err)
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return err
 	}
 
-	if len(lessons) == 0 {
-		return c.JSON(http.StatusNotFound, "lesson doesn't exist.")
+	lessons, nextCursor, err := usecase.GetCurrentUserLessons(c.Request().Context(), user, page)
+	if err != nil {
+		return err
+	}
+
+	if len(lessons) == 0 && page.IsFirst() {
+		return usecase.LessonNotFound
 	}
 
-	return c.JSON(http.StatusOK, lessons)
+	return c.JSON(http.StatusOK, getLessonsResponse{Lessons: lessons, NextCursor: nextCursor})
 }
 
 func postLesson(c echo.Context) error {
+	user, _ := currentUser(c)
+
 	params := new(usecase.NewLessonParams)
 	lesson := new(domain.Lesson)
 
-	if err := c.Bind(params); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, params); err != nil {
+		return err
 	}
 
-	// TODO validate newLesson
-
-	if err := usecase.CreateLesson(c.Request(), params, lesson); err != nil {
-		fatalLog(err)
-		LessonErr, ok := err.(usecase.LessonErrorCode)
-		if ok && LessonErr == usecase.InvalidLessonParams {
-			return c.JSON(http.StatusBadRequest, err.Error())
-		}
-		return c.JSON(http.StatusInternalServerError, err.Error())
+	if err := usecase.CreateLesson(c.Request().Context(), user, params, lesson); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, lesson)
 }
 
 func patchLesson(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := idParam(c, "id")
 	if err != nil {
-		errMessage := "Invalid lessonID error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	params := new(usecase.PatchLessonAndMaterialParams)
-	if err := c.Bind(params); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+	revision, err := ifMatchRevision(c)
+	if err != nil {
+		return err
+	}
+
+	patch, err := bindMergePatch(c, new(usecase.PatchLessonAndMaterialParams))
+	if err != nil {
+		return err
 	}
 
-	if err := usecase.UpdateLessonWithMaterial(id, c.Request(), params); err != nil {
-		fatalLog(err)
-		LessonErr, ok := err.(usecase.LessonErrorCode)
-		if ok && LessonErr == usecase.LessonNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		} else if ok && LessonErr == usecase.InvalidLessonParams {
-			return c.JSON(http.StatusBadRequest, err.Error())
+	// the material has its own revision, so the patch of it requires Material-If-Match too.
+	var materialRevision int64
+	hasMaterialPatch := len(patch.Only(usecase.PatchLessonMaterialParams{})) > 0
+	if hasMaterialPatch {
+		if materialRevision, err = revisionHeader(c, materialIfMatchHeader); err != nil {
+			return err
 		}
-		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
 
+	user, _ := currentUser(c)
+	newRevision, newMaterialRevision, err := usecase.UpdateLessonWithMaterial(c.Request().Context(), user, id, revision, materialRevision, patch)
+	if err != nil {
+		return err
+	}
+
+	setETag(c, newRevision)
+	if hasMaterialPatch {
+		setRevisionHeader(c, materialETagHeader, newMaterialRevision)
+	}
 	return c.JSON(http.StatusOK, "")
 }
+
+func postLessonDuplicate(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	lesson, err := usecase.DuplicateLesson(c.Request().Context(), user, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, lesson)
+}
+
+func deleteLesson(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	if err := usecase.DeleteLesson(c.Request().Context(), user, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
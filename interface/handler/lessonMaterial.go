@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/jinzhu/copier"
@@ -22,37 +21,38 @@ type getLessonMaterialShortResponse struct {
 	Updated              time.Time                   `json:"updated"`
 }
 
+type getLessonMaterialVersionsResponse struct {
+	Versions []domain.LessonMaterialVersion `json:"versions"`
+}
+
+type getLessonMaterialLintResponse struct {
+	Issues domain.LessonMaterialLintIssues `json:"issues"`
+}
+
 type postMaterialResponse struct {
 	MaterialID int64 `json:"materialID"`
 }
 
 func getLessonMaterials(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	user, _ := currentUser(c)
+
+	id, err := idParam(c, "id")
 	if err != nil {
-		errMessage := "Invalid ID error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	lessonID, err := strconv.ParseInt(c.Param("lessonID"), 10, 64)
+	lessonID, err := idParam(c, "lessonID")
 	if err != nil {
-		errMessage := "Invalid ID error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	lessonMaterial, err := usecase.GetLessonMaterial(c.Request(), id, lessonID)
+	lessonMaterial, err := usecase.GetLessonMaterial(c.Request().Context(), user, id, lessonID, c.QueryParam("view_key"))
 	if err != nil {
-		fatalLog(err)
-		lessonErr, ok := err.(usecase.LessonMaterialErrorCode)
-		if ok && lessonErr == usecase.LessonMaterialNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		} else if ok && lessonErr == usecase.LessonMaterialNotAvailable {
-			return c.JSON(http.StatusForbidden, err.Error())
-		}
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
+	setETag(c, lessonMaterial.Revision)
+
 	isShort := c.Request().URL.Query().Get("is_short")
 	if isShort == "true" {
 		var response getLessonMaterialShortResponse
@@ -65,29 +65,21 @@ func getLessonMaterials(c echo.Context) error {
 }
 
 func postLessonMaterial(c echo.Context) error {
-	lessonID, err := strconv.ParseInt(c.Param("lessonID"), 10, 64)
+	user, _ := currentUser(c)
+
+	lessonID, err := idParam(c, "lessonID")
 	if err != nil {
-		errMessage := "Invalid lessonID error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
 	params := new(usecase.LessonMaterialParams)
-	if err := c.Bind(params); err != nil {
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+	if err := bindAndValidate(c, params); err != nil {
+		return err
 	}
 
-	id, err := usecase.CreateLessonMaterial(c.Request(), lessonID, *params)
+	id, err := usecase.CreateLessonMaterial(c.Request().Context(), user, lessonID, *params)
 	if err != nil {
-		fatalLog(err)
-		lessonErr, ok := err.(usecase.LessonMaterialErrorCode)
-		if ok && lessonErr == usecase.LessonMaterialNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		} else if ok && lessonErr == usecase.LessonMaterialNotAvailable {
-			return c.JSON(http.StatusForbidden, err.Error())
-		}
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	response := postMaterialResponse{id}
@@ -95,36 +87,129 @@ func postLessonMaterial(c echo.Context) error {
 }
 
 func patchLessonMaterial(c echo.Context) error {
-	lessonID, err := strconv.ParseInt(c.Param("lessonID"), 10, 64)
+	user, _ := currentUser(c)
+
+	lessonID, err := idParam(c, "lessonID")
 	if err != nil {
-		errMessage := "Invalid lessonID error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := idParam(c, "id")
 	if err != nil {
-		errMessage := "Invalid ID error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	params := new(usecase.LessonMaterialParams)
-	if err := c.Bind(params); err != nil {
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+	revision, err := ifMatchRevision(c)
+	if err != nil {
+		return err
 	}
 
-	if err := usecase.UpdateLessonMaterial(c.Request(), id, lessonID, *params); err != nil {
-		fatalLog(err)
-		lessonErr, ok := err.(usecase.LessonMaterialErrorCode)
-		if ok && lessonErr == usecase.LessonMaterialNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		} else if ok && lessonErr == usecase.LessonMaterialNotAvailable {
-			return c.JSON(http.StatusForbidden, err.Error())
-		}
-		return c.JSON(http.StatusInternalServerError, err.Error())
+	patch, err := bindMergePatch(c, new(usecase.LessonMaterialParams))
+	if err != nil {
+		return err
+	}
+
+	newRevision, err := usecase.UpdateLessonMaterial(c.Request().Context(), user, id, lessonID, revision, patch)
+	if err != nil {
+		return err
 	}
 
+	setETag(c, newRevision)
 	return c.JSON(http.StatusCreated, "succeeded")
 }
+
+func getLessonMaterialLint(c echo.Context) error {
+	user, _ := currentUser(c)
+
+	lessonID, err := idParam(c, "lessonID")
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	issues, err := usecase.LintLessonMaterial(c.Request().Context(), user, id, lessonID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, getLessonMaterialLintResponse{Issues: issues})
+}
+
+func getLessonMaterialVersions(c echo.Context) error {
+	user, _ := currentUser(c)
+
+	lessonID, err := idParam(c, "lessonID")
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	versions, err := usecase.GetLessonMaterialVersions(c.Request().Context(), user, id, lessonID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, getLessonMaterialVersionsResponse{Versions: versions})
+}
+
+func getLessonMaterialVersion(c echo.Context) error {
+	user, _ := currentUser(c)
+
+	lessonID, err := idParam(c, "lessonID")
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	versionID, err := idParam(c, "versionID")
+	if err != nil {
+		return err
+	}
+
+	lessonMaterial, err := usecase.GetLessonMaterialVersion(c.Request().Context(), user, id, lessonID, versionID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lessonMaterial)
+}
+
+func postLessonMaterialVersionRestore(c echo.Context) error {
+	user, _ := currentUser(c)
+
+	lessonID, err := idParam(c, "lessonID")
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	versionID, err := idParam(c, "versionID")
+	if err != nil {
+		return err
+	}
+
+	lessonMaterial, err := usecase.RestoreLessonMaterialVersion(c.Request().Context(), user, id, lessonID, versionID)
+	if err != nil {
+		return err
+	}
+
+	setETag(c, lessonMaterial.Revision)
+
+	return c.JSON(http.StatusOK, lessonMaterial)
+}
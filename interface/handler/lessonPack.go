@@ -1,25 +1,54 @@
 package handler
 
 import (
+	"io"
+	"io/ioutil"
 	"net/http"
-	"strconv"
+	"os"
 
 	"github.com/labstack/echo/v4"
 	"github.com/super-dog-human/teraconnectgo/usecase"
 )
 
 func putLessonPack(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	user, _ := currentUser(c)
+
+	id, err := idParam(c, "id")
 	if err != nil {
-		errMessage := "Invalid ID(s) error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	if err := usecase.PackLesson(c.Request(), id); err != nil {
-		fatalLog(err)
-		return c.JSON(http.StatusBadRequest, err.Error())
+	if err := usecase.PackLesson(c.Request().Context(), user, id); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, "succeeded")
 }
+
+// maxLessonPackSize limits the size of the package zip to import.
+const maxLessonPackSize = 1 << 30
+
+func postLessonImport(c echo.Context) error {
+	user, _ := currentUser(c)
+
+	// the zip is read from the temporary file, because the directory of zip is at the end of it.
+	f, err := ioutil.TempFile("", "lesson-import-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, maxLessonPackSize)
+	size, err := io.Copy(f, body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge).SetInternal(err)
+	}
+
+	lesson, err := usecase.ImportLesson(c.Request().Context(), user, f, size)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, lesson)
+}
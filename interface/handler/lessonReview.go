@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/domain"
+	"github.com/super-dog-human/teraconnectgo/usecase"
+)
+
+type getLessonReviewCommentsResponse struct {
+	Comments []domain.LessonReviewComment `json:"comments"`
+}
+
+func getReviewRequestedLessons(c echo.Context) error {
+	user, _ := currentUser(c)
+
+	page, err := pageParams(c)
+	if err != nil {
+		return err
+	}
+
+	lessons, nextCursor, err := usecase.GetReviewRequestedLessons(c.Request().Context(), user, page)
+	if err != nil {
+		return err
+	}
+
+	if lessons == nil {
+		lessons = []domain.Lesson{}
+	}
+
+	return c.JSON(http.StatusOK, getLessonsResponse{Lessons: lessons, NextCursor: nextCursor})
+}
+
+func postLessonReview(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	params := new(usecase.NewLessonReviewParams)
+	if err := bindAndValidate(c, params); err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	reviews, err := usecase.RequestLessonReview(c.Request().Context(), user, id, *params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, reviews)
+}
+
+func deleteLessonReview(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	reviewerUserID, err := idParam(c, "userID")
+	if err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	reviews, err := usecase.CancelLessonReview(c.Request().Context(), user, id, reviewerUserID)
+	if err != nil {
+		return err
+	}
+
+	if reviews == nil {
+		reviews = []domain.LessonReview{}
+	}
+
+	return c.JSON(http.StatusOK, reviews)
+}
+
+// putLessonReview is the decision of current user as the reviewer.
+func putLessonReview(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	params := new(usecase.LessonReviewParams)
+	if err := bindAndValidate(c, params); err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	review, err := usecase.ReviewLesson(c.Request().Context(), user, id, *params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, review)
+}
+
+func getLessonReviewComments(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	comments, err := usecase.GetLessonReviewComments(c.Request().Context(), user, id)
+	if err != nil {
+		return err
+	}
+
+	if comments == nil {
+		comments = []domain.LessonReviewComment{}
+	}
+
+	return c.JSON(http.StatusOK, getLessonReviewCommentsResponse{Comments: comments})
+}
+
+func postLessonReviewComment(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	params := new(usecase.NewLessonReviewCommentParams)
+	if err := bindAndValidate(c, params); err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	comment, err := usecase.CreateLessonReviewComment(c.Request().Context(), user, id, *params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, comment)
+}
+
+func deleteLessonReviewComment(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	commentID, err := idParam(c, "commentID")
+	if err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	if err := usecase.DeleteLessonReviewComment(c.Request().Context(), user, id, commentID); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
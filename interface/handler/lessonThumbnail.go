@@ -2,24 +2,23 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/super-dog-human/teraconnectgo/usecase"
 )
 
 func postLessonThumbnail(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := idParam(c, "id")
 	if err != nil {
-		errMessage := "Invalid ID(s) error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	url, err := usecase.CreateLessonThumbnailBlankFile(c.Request(), id)
+	user, _ := currentUser(c)
+	isPublic := c.QueryParam("is_public") == "true"
+
+	url, err := usecase.CreateLessonThumbnailBlankFile(c.Request().Context(), user, id, isPublic)
 	if err != nil {
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	response := response{url}
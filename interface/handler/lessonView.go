@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/domain"
+	"github.com/super-dog-human/teraconnectgo/usecase"
+)
+
+const defaultLessonViewDays = 30
+
+func postLessonView(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := usecase.RecordLessonView(c.Request().Context(), id, c.QueryParam("view_key"), viewerKey(c)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func getLessonViews(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	days := defaultLessonViewDays
+	if daysParam := c.QueryParam("days"); daysParam != "" {
+		if days, err = strconv.Atoi(daysParam); err != nil {
+			return domain.InvalidLessonViewDays
+		}
+	}
+
+	user, _ := currentUser(c)
+	views, err := usecase.GetLessonViews(c.Request().Context(), user, id, days)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, views)
+}
+
+// viewerKey identifies the viewer by the user signed in, otherwise by the client address extracted by e.IPExtractor.
+func viewerKey(c echo.Context) string {
+	if user, ok := currentUser(c); ok {
+		return fmt.Sprintf("user:%d", user.ID)
+	}
+	return fmt.Sprintf("ip:%s", c.RealIP())
+}
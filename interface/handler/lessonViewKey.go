@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/usecase"
+)
+
+func getLessonViewKey(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	viewKey, err := usecase.GetLessonViewKey(c.Request().Context(), user, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, viewKey)
+}
+
+// postLessonViewKey regenerates the key, the body is optional and only has the expiry of the new link.
+func postLessonViewKey(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	params := new(usecase.LessonViewKeyParams)
+	if err := bindAndValidate(c, params); err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	viewKey, err := usecase.RegenerateLessonViewKey(c.Request().Context(), user, id, *params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, viewKey)
+}
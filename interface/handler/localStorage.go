@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+)
+
+// localURLSigner verifies the signed URLs of the local storage, this is set only when the local storage is used.
+var localURLSigner *infrastructure.HMACURLSigner
+
+// getLocalStorageObject serves the object in the local storage instead of GCS for development.
+func getLocalStorageObject(c echo.Context) error {
+	bucketName := c.Param("bucket")
+	filePath := c.Param("*")
+
+	if bucketName != infrastructure.PublicBucketName() {
+		if err := localURLSigner.Verify(bucketName, filePath, http.MethodGet, "", c.QueryParams()); err != nil {
+			return err
+		}
+	}
+
+	ctx := c.Request().Context()
+
+	attrs, err := infrastructure.StatFile(ctx, bucketName, filePath)
+	if err != nil {
+		return err
+	}
+
+	contents, err := infrastructure.GetFile(ctx, bucketName, filePath)
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(http.StatusOK, attrs.ContentType, contents)
+}
+
+// putLocalStorageObject saves the object uploaded to the signed URL in the local storage.
+func putLocalStorageObject(c echo.Context) error {
+	bucketName := c.Param("bucket")
+	filePath := c.Param("*")
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+
+	if err := localURLSigner.Verify(bucketName, filePath, http.MethodPut, contentType, c.QueryParams()); err != nil {
+		return err
+	}
+
+	contents, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+
+	if err := infrastructure.CreateFile(c.Request().Context(), bucketName, filePath, contentType, contents); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// localStoragePath returns the path of routes from the URL of local storage.
+func localStoragePath(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
@@ -1,70 +1,238 @@
 package handler
 
 import (
+	"context"
+	"crypto/rand"
+	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
-	"os"
+	"time"
 
+	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/storage"
+	texttospeech "cloud.google.com/go/texttospeech/apiv1"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/super-dog-human/teraconnectgo/domain"
 	"github.com/super-dog-human/teraconnectgo/infrastructure"
 )
 
 // Main is handling API request.
-func Main(appEnv string) {
-	infrastructure.SetAppEnv(appEnv)
+func Main(config infrastructure.Config) {
+	infrastructure.SetConfig(config)
+
+	domain.SetTokenValidator(tokenValidator(config.JWT))
+	domain.SetUserCacheTTL(time.Duration(config.JWT.UserCacheTTL))
+	domain.SetLessonMaterialRetention(config.Materials.VersionsKeepLast, config.Materials.VersionsKeepDays)
+	domain.SetLessonMaterialStrictLint(config.Materials.StrictLint)
+	domain.SetLessonViewDedupeWindow(time.Duration(config.Views.DedupeWindow))
+
+	client, err := datastore.NewClient(context.Background(), config.ProjectID)
+	if err != nil {
+		log.Fatalf("failed to initialize the Datastore client. %v\n", err)
+	}
+	defer client.Close()
+
+	domain.SetRepositories(domain.NewDatastoreRepositories(client))
+	domain.SetLessonIndex(domain.NewDatastoreLessonIndex(client))
+
+	if config.Storage.LocalDir != "" {
+		localURLSigner = infrastructure.NewHMACURLSigner(config.Storage.LocalURL, localStorageSecret(config.Storage))
+		infrastructure.SetBlobStore(infrastructure.NewFileSystemBlobStore(config.Storage.LocalDir, config.Storage.LocalURL, localURLSigner))
+	} else {
+		storageClient, err := storage.NewClient(context.Background())
+		if err != nil {
+			log.Fatalf("failed to initialize the Cloud Storage client. %v\n", err)
+		}
+		defer storageClient.Close()
+
+		infrastructure.SetBlobStore(infrastructure.NewGCSBlobStore(storageClient, gcsURLSigner(config.Storage)))
+	}
+
+	switch config.Speech.Provider {
+	case "silent":
+		domain.SetSpeechSynthesizer(domain.NewSilentSpeechSynthesizer())
+	default:
+		speechClient, err := texttospeech.NewClient(context.Background())
+		if err != nil {
+			log.Fatalf("failed to initialize the Text-to-Speech client. %v\n", err)
+		}
+		defer speechClient.Close()
+
+		domain.SetSpeechSynthesizer(domain.NewGoogleSpeechSynthesizer(speechClient))
+	}
+
+	go aggregateLessonViewsPeriodically(time.Duration(config.Views.AggregationInterval))
+	go applyLessonSchedulesPeriodically(time.Duration(config.Scheduler.Interval))
 
 	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Validator = requestValidator{}
+	e.IPExtractor = ipExtractor(config.Server.TrustedProxyNets())
 	http.Handle("/", e)
 
 	e.Pre(middleware.RemoveTrailingSlash())
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{infrastructure.OriginURL()},
+		AllowOrigins:  config.CORS.AllowOrigins,
+		ExposeHeaders: []string{"ETag", materialETagHeader}, // the editor sends them back as If-Match and Material-If-Match.
 	}))
 
 	e.GET("/subjects", getSubjects)
 	e.GET("/categories", getCategories)
 	e.GET("/background_images", getBackgroundImages)
 	e.GET("/lessons", getLessons)
-	e.GET("/lessons/:id", getLesson)
+	e.GET("/lessons/:id", getLesson, OptionalAuthentication())
+	e.POST("/lessons/:id/views", postLessonView, OptionalAuthentication())
+	e.GET("/lessons/:lessonID/materials/:id", getLessonMaterials, OptionalAuthentication())
+	e.GET("/graphics", getGraphics, OptionalAuthentication())
+	e.GET("/voices/:id", getVoice, OptionalAuthentication())
+	e.GET("/voices", getVoices, OptionalAuthentication())
 	e.GET("/users/:id", getUser)
+	e.GET("/series/:id", getSeries, OptionalAuthentication())
+	e.POST("/internal/cron/publish", postCronPublish, CronAuthentication(config.Scheduler.CronToken))
+
+	if localURLSigner != nil {
+		localStoragePath := localStoragePath(config.Storage.LocalURL)
+		e.GET(localStoragePath+"/:bucket/*", getLocalStorageObject)
+		e.PUT(localStoragePath+"/:bucket/*", putLocalStorageObject)
+	}
+
+	e.POST("/users", postUser, TokenAuthentication())
 
 	auth := e.Group("", Authentication())
 	auth.GET("/users/me", getUserMe)
-	auth.POST("/users", postUser)
 	auth.PATCH("/users", patchUser)
 	auth.DELETE("/users", deleteUser)
 	auth.GET("/users/me/lessons", getCurrentUserLessons)
+	auth.GET("/users/me/series", getCurrentUserSeries)
+	auth.GET("/users/me/review_requests", getReviewRequestedLessons)
 	auth.GET("/avatars", getAvatars)
 	auth.POST("/avatars", postAvatars)
 	auth.GET("/background_musics", getBackgroundMusics)
 	auth.POST("/background_musics", postBackgroundMusic)
 	auth.GET("/graphics/:id", getGraphic)
-	auth.GET("/graphics", getGraphics)
 	auth.POST("/graphics", postGraphics)
 	auth.DELETE("/graphics/:id", deleteGraphic)
-	auth.GET("/voices/:id", getVoice)
-	auth.GET("/voices", getVoices)
 	auth.POST("/voice", postVoice)
 	auth.POST("/synthesis_voice", postSynthesisVoice)
 	auth.POST("/lessons", postLesson)
 	auth.PATCH("/lessons/:id", patchLesson)
-	auth.GET("/lessons/:lessonID/materials/:id", getLessonMaterials)
+	auth.DELETE("/lessons/:id", deleteLesson)
+	auth.POST("/lessons/:id/duplicate", postLessonDuplicate)
+	auth.GET("/lessons/:id/views", getLessonViews)
+	auth.GET("/lessons/:id/view_key", getLessonViewKey)
+	auth.POST("/lessons/:id/view_key", postLessonViewKey)
+	auth.POST("/lessons/:id/reviews", postLessonReview)
+	auth.PUT("/lessons/:id/reviews/me", putLessonReview)
+	auth.DELETE("/lessons/:id/reviews/:userID", deleteLessonReview)
+	auth.GET("/lessons/:id/review_comments", getLessonReviewComments)
+	auth.POST("/lessons/:id/review_comments", postLessonReviewComment)
+	auth.DELETE("/lessons/:id/review_comments/:commentID", deleteLessonReviewComment)
 	auth.POST("/lessons/:lessonID/materials", postLessonMaterial)
 	auth.PATCH("/lessons/:lessonID/materials/:id", patchLessonMaterial)
+	auth.GET("/lessons/:lessonID/materials/:id/lint", getLessonMaterialLint)
+	auth.GET("/lessons/:lessonID/materials/:id/versions", getLessonMaterialVersions)
+	auth.GET("/lessons/:lessonID/materials/:id/versions/:versionID", getLessonMaterialVersion)
+	auth.POST("/lessons/:lessonID/materials/:id/versions/:versionID/restore", postLessonMaterialVersionRestore)
 	auth.PUT("/lessons/:id/pack", putLessonPack)
+	auth.POST("/lessons/import", postLessonImport)
 	auth.POST("lessons/:id/thumbnail", postLessonThumbnail)
+	auth.POST("/series", postSeries)
+	auth.PATCH("/series/:id", patchSeries)
+	auth.DELETE("/series/:id", deleteSeries)
+	auth.PUT("/series/:id/lessons", putSeriesLessons)
+	auth.POST("/series/:id/lessons", postSeriesLesson)
+	auth.DELETE("/series/:id/lessons/:lessonID", deleteSeriesLesson)
+
+	if config.Server.TLS.Enabled() {
+		log.Fatal(e.StartTLS(config.Server.ListenAddress, config.Server.TLS.CertFile, config.Server.TLS.KeyFile))
+	} else {
+		log.Fatal(e.Start(config.Server.ListenAddress))
+	}
+}
+
+// aggregateLessonViewsPeriodically adds the recorded views to the lessons on each interval.
+func aggregateLessonViewsPeriodically(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := domain.AggregateLessonViews(context.Background()); err != nil {
+			fatalLog(err)
+		}
+	}
+}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+// applyLessonSchedulesPeriodically publishes and unpublishes the scheduled lessons on each interval.
+func applyLessonSchedulesPeriodically(interval time.Duration) {
+	for range time.Tick(interval) {
+		if _, err := domain.ApplyLessonSchedules(context.Background(), time.Now()); err != nil {
+			fatalLog(err)
+		}
 	}
+}
 
-	if infrastructure.AppEnv() == "development" {
-		log.Fatal(http.ListenAndServeTLS(":443", "localhost.crt", "localhost.key", nil))
+// tokenValidator returns the validator with the key of PEM file or JWKS.
+func tokenValidator(config infrastructure.JWTConfig) *domain.TokenValidator {
+	var keys domain.KeySource
+	if config.JWKSURL != "" {
+		keys = domain.NewJWKSKeySource(config.JWKSURL, &http.Client{Timeout: 10 * time.Second})
 	} else {
-		log.Fatal(e.Start(":" + port))
+		pemKeys, err := domain.NewPEMKeySource(config.PublicKeyFile)
+		if err != nil {
+			log.Fatalf("failed to load the public key of JWT. %v\n", err)
+		}
+		keys = pemKeys
+	}
+
+	return domain.NewTokenValidator(keys, config.Issuer, config.Audiences, time.Duration(config.ClockSkew))
+}
+
+// ipExtractor reads the client address from X-Forwarded-For only through the trusted proxies, so that clients can't spoof it by the header.
+// the address of the peer is used when no proxy is trusted.
+func ipExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, ipNet := range trustedProxies {
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// gcsURLSigner returns the signer with the key file of service account if it is given, otherwise uses the IAM API.
+func gcsURLSigner(config infrastructure.StorageConfig) infrastructure.URLSigner {
+	if config.SignedURLKeyFile == "" {
+		return infrastructure.NewIAMURLSigner()
 	}
+
+	jsonKey, err := ioutil.ReadFile(config.SignedURLKeyFile)
+	if err != nil {
+		log.Fatalf("failed to read the key file of service account. %v\n", err)
+	}
+
+	signer, err := infrastructure.NewPrivateKeyURLSigner(jsonKey)
+	if err != nil {
+		log.Fatalf("failed to parse the key file of service account. %v\n", err)
+	}
+
+	return signer
+}
+
+// localStorageSecret returns the key of signed URLs for the local storage.
+// the random key is generated when it is not given, so the URLs are invalidated by restarting.
+func localStorageSecret(config infrastructure.StorageConfig) []byte {
+	if config.LocalSecret != "" {
+		return []byte(config.LocalSecret)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("failed to generate the secret of local storage. %v\n", err)
+	}
+
+	return secret
 }
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPExtractor(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("35.191.0.0/16")
+
+	tests := []struct {
+		name           string
+		trustedProxies []*net.IPNet
+		remoteAddr     string
+		forwardedFor   string
+		want           string
+	}{
+		{"no proxy", nil, "203.0.113.1:1234", "198.51.100.1", "203.0.113.1"},
+		{"through the proxy", []*net.IPNet{proxies}, "35.191.0.1:1234", "198.51.100.1", "198.51.100.1"},
+		{"spoofed before the proxy", []*net.IPNet{proxies}, "35.191.0.1:1234", "192.0.2.1, 198.51.100.1", "198.51.100.1"},
+		{"not through the proxy", []*net.IPNet{proxies}, "203.0.113.1:1234", "198.51.100.1", "203.0.113.1"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/lessons/1/views", nil)
+		req.RemoteAddr = test.remoteAddr
+		req.Header.Set("X-Forwarded-For", test.forwardedFor)
+
+		if got := ipExtractor(test.trustedProxies)(req); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/domain"
+)
+
+// pageParams returns the page from "limit" and "cursor" query params.
+func pageParams(c echo.Context) (domain.Page, error) {
+	var limit int
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitParam); err != nil {
+			return domain.Page{}, domain.InvalidPageLimit
+		}
+	}
+
+	return domain.NewPage(limit, c.QueryParam("cursor"))
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// idParam returns the ID in the path param.
+func idParam(c echo.Context, name string) (int64, error) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		return 0, InvalidID
+	}
+	return id, nil
+}
+
+// idQueryParam returns the ID in the query param.
+func idQueryParam(c echo.Context, name string) (int64, error) {
+	id, err := strconv.ParseInt(c.QueryParam(name), 10, 64)
+	if err != nil {
+		return 0, InvalidID
+	}
+	return id, nil
+}
@@ -0,0 +1,461 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/domain"
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
+	"github.com/super-dog-human/teraconnectgo/usecase"
+)
+
+const (
+	mimeApplicationProblemJSON = "application/problem+json"
+	problemTypePrefix          = "urn:teraconnect:problem:"
+	defaultProblemLanguage     = "en"
+)
+
+// RequestErrorCode is the error of request found by handlers before calling usecases.
+type RequestErrorCode uint
+
+const (
+	InvalidID              RequestErrorCode = 1
+	InvalidRequestBody     RequestErrorCode = 2
+	AuthenticationRequired RequestErrorCode = 3
+	IfMatchRequired        RequestErrorCode = 4
+	InvalidCronToken       RequestErrorCode = 5
+)
+
+func (e RequestErrorCode) Error() string {
+	switch e {
+	case InvalidID:
+		return "invalid ID"
+	case InvalidRequestBody:
+		return "invalid request body"
+	case AuthenticationRequired:
+		return "authentication required"
+	case IfMatchRequired:
+		return "If-Match header required"
+	case InvalidCronToken:
+		return "invalid cron token"
+	default:
+		return "unknown request error"
+	}
+}
+
+// problemType is the kind of error shown to clients. Code is stable for clients, so it must not be changed.
+type problemType struct {
+	Status   int
+	Code     string
+	Messages map[string]string // message by language, "en" is required.
+}
+
+// problem is the response body of RFC 7807.
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// InvalidParams is the list of invalid fields, only for the validation error.
+	InvalidParams domain.ValidationErrors `json:"invalidParams,omitempty"`
+	// MissingAssets is the list of files not found, only for packing the lesson.
+	MissingAssets domain.MissingLessonAssets `json:"missingAssets,omitempty"`
+	// UnmetRequirements is the list of requirements not met, only for making the lesson public.
+	UnmetRequirements domain.UnmetLessonRequirements `json:"unmetRequirements,omitempty"`
+	// LintIssues is the list of issues of the material, only for saving it in strict mode.
+	LintIssues domain.LessonMaterialLintIssues `json:"lintIssues,omitempty"`
+}
+
+var validationFailedProblem = problemType{http.StatusBadRequest, "validation_failed", map[string]string{
+	"en": "validation failed",
+	"ja": "入力内容が正しくありません",
+}}
+
+var lessonAssetsMissingProblem = problemType{http.StatusUnprocessableEntity, "lesson_assets_missing", map[string]string{
+	"en": "lesson assets missing",
+	"ja": "授業で使われているファイルが見つかりません",
+}}
+
+var lessonRequirementsUnmetProblem = problemType{http.StatusUnprocessableEntity, "lesson_requirements_unmet", map[string]string{
+	"en": "lesson requirements unmet",
+	"ja": "授業を公開する条件を満たしていません",
+}}
+
+var lessonMaterialLintFailedProblem = problemType{http.StatusUnprocessableEntity, "lesson_material_lint_failed", map[string]string{
+	"en": "lesson material has lint issues",
+	"ja": "授業の素材に問題があります",
+}}
+
+var internalServerErrorProblem = problemType{http.StatusInternalServerError, "internal_server_error", map[string]string{
+	"en": "internal server error",
+	"ja": "サーバーでエラーが発生しました",
+}}
+
+// problemTypes is the registry of errors returned from handlers. the error not found here is shown as the internal server error.
+var problemTypes = map[error]problemType{
+	InvalidID: {http.StatusBadRequest, "invalid_id", map[string]string{
+		"en": "invalid ID",
+		"ja": "IDが正しくありません",
+	}},
+	InvalidRequestBody: {http.StatusBadRequest, "invalid_request_body", map[string]string{
+		"en": "invalid request body",
+		"ja": "リクエストの内容が正しくありません",
+	}},
+	AuthenticationRequired: {http.StatusUnauthorized, "authentication_required", map[string]string{
+		"en": "authentication required",
+		"ja": "ログインが必要です",
+	}},
+	IfMatchRequired: {http.StatusPreconditionRequired, "if_match_required", map[string]string{
+		"en": "If-Match header required",
+		"ja": "If-Matchヘッダーが必要です",
+	}},
+	InvalidCronToken: {http.StatusForbidden, "invalid_cron_token", map[string]string{
+		"en": "invalid cron token",
+		"ja": "定期実行のトークンが正しくありません",
+	}},
+
+	domain.TokenNotFound:           invalidTokenProblem("token_not_found"),
+	domain.UnexpectedSigningMethod: invalidTokenProblem("unexpected_signing_method"),
+	domain.InvalidToken:            invalidTokenProblem("invalid_token"),
+	domain.InvalidTokenIssuer:      invalidTokenProblem("invalid_token_issuer"),
+	domain.InvalidTokenAudience:    invalidTokenProblem("invalid_token_audience"),
+	domain.InvalidTokenClaims:      invalidTokenProblem("invalid_token_claims"),
+	domain.SigningKeyNotFound:      invalidTokenProblem("signing_key_not_found"),
+	domain.TokenExpired: {http.StatusUnauthorized, "token_expired", map[string]string{
+		"en": "token expired",
+		"ja": "ログインの有効期限が切れました",
+	}},
+	domain.TokenNotValidYet: {http.StatusUnauthorized, "token_not_valid_yet", map[string]string{
+		"en": "token not valid yet",
+		"ja": "ログイン情報がまだ有効ではありません",
+	}},
+	domain.UserNotFound: {http.StatusNotFound, "user_not_found", map[string]string{
+		"en": "user not found",
+		"ja": "ユーザーが見つかりません",
+	}},
+
+	domain.InvalidPageLimit: {http.StatusBadRequest, "invalid_page_limit", map[string]string{
+		"en": "invalid page limit",
+		"ja": "取得件数が正しくありません",
+	}},
+	domain.InvalidCursor: {http.StatusBadRequest, "invalid_cursor", map[string]string{
+		"en": "invalid cursor",
+		"ja": "カーソルが正しくありません",
+	}},
+	domain.RevisionMismatch: {http.StatusPreconditionFailed, "revision_mismatch", map[string]string{
+		"en": "updated by another request",
+		"ja": "他の画面で更新されています。再読み込みしてください",
+	}},
+	domain.ErrNoSuchEntity: {http.StatusNotFound, "not_found", map[string]string{
+		"en": "not found",
+		"ja": "見つかりません",
+	}},
+	domain.AvatarNotFound: {http.StatusNotFound, "avatar_not_found", map[string]string{
+		"en": "avatar not found",
+		"ja": "アバターが見つかりません",
+	}},
+	domain.GraphicNotFound: {http.StatusNotFound, "graphic_not_found", map[string]string{
+		"en": "graphic not found",
+		"ja": "画像が見つかりません",
+	}},
+	domain.VoiceNotFound: {http.StatusNotFound, "voice_not_found", map[string]string{
+		"en": "voice not found",
+		"ja": "音声が見つかりません",
+	}},
+	domain.AlreadyProviderIDExists: alreadyUserExistsProblem,
+
+	usecase.LessonNotFound: {http.StatusNotFound, "lesson_not_found", map[string]string{
+		"en": "lesson not found",
+		"ja": "授業が見つかりません",
+	}},
+	usecase.LessonNotAvailable: {http.StatusForbidden, "lesson_not_available", map[string]string{
+		"en": "lesson not available",
+		"ja": "この授業は利用できません",
+	}},
+	usecase.InvalidLessonParams: {http.StatusBadRequest, "invalid_lesson_params", map[string]string{
+		"en": "invalid lesson params",
+		"ja": "授業の内容が正しくありません",
+	}},
+	usecase.LessonMaterialNotFound: {http.StatusNotFound, "lesson_material_not_found", map[string]string{
+		"en": "lesson material not found",
+		"ja": "授業の素材が見つかりません",
+	}},
+	usecase.LessonMaterialNotAvailable: {http.StatusForbidden, "lesson_material_not_available", map[string]string{
+		"en": "lesson material not available",
+		"ja": "この授業の素材は利用できません",
+	}},
+	domain.LessonMaterialVersionNotFound: {http.StatusNotFound, "lesson_material_version_not_found", map[string]string{
+		"en": "lesson material version not found",
+		"ja": "授業の素材の履歴が見つかりません",
+	}},
+	domain.SeriesNotFound: {http.StatusNotFound, "series_not_found", map[string]string{
+		"en": "series not found",
+		"ja": "シリーズが見つかりません",
+	}},
+	domain.SeriesLessonNotAvailable: {http.StatusBadRequest, "series_lesson_not_available", map[string]string{
+		"en": "lesson not available for series",
+		"ja": "この授業はシリーズに追加できません",
+	}},
+	domain.LessonAlreadyInSeries: {http.StatusConflict, "lesson_already_in_series", map[string]string{
+		"en": "lesson already in another series",
+		"ja": "この授業は既に他のシリーズに含まれています",
+	}},
+	domain.DuplicatedSeriesLesson: {http.StatusBadRequest, "duplicated_series_lesson", map[string]string{
+		"en": "duplicated lesson in series",
+		"ja": "シリーズに同じ授業が含まれています",
+	}},
+	domain.TooManySeriesLessons: {http.StatusBadRequest, "too_many_series_lessons", map[string]string{
+		"en": "too many lessons in series",
+		"ja": "シリーズの授業が多すぎます",
+	}},
+	domain.SeriesLessonsMismatch: {http.StatusConflict, "series_lessons_mismatch", map[string]string{
+		"en": "lessons of series mismatch",
+		"ja": "シリーズの授業が変更されています。再読み込みしてください",
+	}},
+	usecase.SeriesNotAvailable: {http.StatusForbidden, "series_not_available", map[string]string{
+		"en": "series not available",
+		"ja": "このシリーズは利用できません",
+	}},
+	domain.InvalidLessonViewDays: {http.StatusBadRequest, "invalid_lesson_view_days", map[string]string{
+		"en": "invalid days of lesson views",
+		"ja": "閲覧数の日数が正しくありません",
+	}},
+	domain.LessonNotLimited: {http.StatusConflict, "lesson_not_limited", map[string]string{
+		"en": "lesson not limited",
+		"ja": "限定公開の授業ではありません",
+	}},
+	domain.InvalidViewKeyExpires: {http.StatusBadRequest, "invalid_view_key_expires", map[string]string{
+		"en": "invalid expiry of view key",
+		"ja": "限定公開URLの有効期限が正しくありません",
+	}},
+	domain.SelfReviewNotAllowed: {http.StatusBadRequest, "self_review_not_allowed", map[string]string{
+		"en": "self review not allowed",
+		"ja": "自分の授業のレビューは依頼できません",
+	}},
+	domain.TooManyLessonReviewers: {http.StatusBadRequest, "too_many_lesson_reviewers", map[string]string{
+		"en": "too many reviewers of lesson",
+		"ja": "レビューを依頼できる人数を超えています",
+	}},
+	domain.LessonReviewNotRequested: {http.StatusForbidden, "lesson_review_not_requested", map[string]string{
+		"en": "lesson review not requested",
+		"ja": "この授業のレビューは依頼されていません",
+	}},
+	domain.InvalidLessonReviewStatus: {http.StatusBadRequest, "invalid_lesson_review_status", map[string]string{
+		"en": "invalid status of lesson review",
+		"ja": "レビューの状態が正しくありません",
+	}},
+	domain.InvalidLessonSchedule: {http.StatusBadRequest, "invalid_lesson_schedule", map[string]string{
+		"en": "invalid schedule of lesson",
+		"ja": "非公開にする日時は公開する日時より後にしてください",
+	}},
+	domain.LessonScheduleNotAvailable: {http.StatusConflict, "lesson_schedule_not_available", map[string]string{
+		"en": "schedule not available for the status of lesson",
+		"ja": "この状態の授業には予定を設定できません",
+	}},
+	domain.InvalidLessonStatusTransition: {http.StatusConflict, "invalid_lesson_status_transition", map[string]string{
+		"en": "invalid transition of lesson status",
+		"ja": "この状態には変更できません",
+	}},
+	domain.LessonReviewCommentNotFound: {http.StatusNotFound, "lesson_review_comment_not_found", map[string]string{
+		"en": "lesson review comment not found",
+		"ja": "レビューのコメントが見つかりません",
+	}},
+	usecase.UserNotAvailable: {http.StatusForbidden, "user_not_available", map[string]string{
+		"en": "user not available",
+		"ja": "このユーザーは利用できません",
+	}},
+	usecase.AlreadyUserExists: alreadyUserExistsProblem,
+
+	domain.InvalidLessonPack: {http.StatusBadRequest, "invalid_lesson_package", map[string]string{
+		"en": "invalid lesson package",
+		"ja": "授業のパッケージが正しくありません",
+	}},
+	domain.UnsupportedLessonPackVersion: {http.StatusBadRequest, "unsupported_lesson_package_version", map[string]string{
+		"en": "unsupported version of lesson package",
+		"ja": "対応していないバージョンの授業パッケージです",
+	}},
+
+	infrastructure.ErrBlobNotExist: {http.StatusNotFound, "file_not_found", map[string]string{
+		"en": "file not found",
+		"ja": "ファイルが見つかりません",
+	}},
+	infrastructure.ErrInvalidSignature: {http.StatusForbidden, "invalid_signature", map[string]string{
+		"en": "invalid signature",
+		"ja": "URLの署名が正しくありません",
+	}},
+}
+
+var alreadyUserExistsProblem = problemType{http.StatusConflict, "user_already_exists", map[string]string{
+	"en": "user already exists",
+	"ja": "ユーザーは既に登録されています",
+}}
+
+func invalidTokenProblem(code string) problemType {
+	return problemType{http.StatusUnauthorized, code, map[string]string{
+		"en": "invalid token",
+		"ja": "ログイン情報が正しくありません",
+	}}
+}
+
+// httpErrorProblems are used for the errors of echo, e.g. routing and binding.
+var httpErrorProblems = map[int]problemType{
+	http.StatusBadRequest: problemTypes[InvalidRequestBody],
+	http.StatusUnauthorized: {http.StatusUnauthorized, "unauthorized", map[string]string{
+		"en": "unauthorized",
+		"ja": "認証されていません",
+	}},
+	http.StatusNotFound: {http.StatusNotFound, "route_not_found", map[string]string{
+		"en": "route not found",
+		"ja": "APIが見つかりません",
+	}},
+	http.StatusMethodNotAllowed: {http.StatusMethodNotAllowed, "method_not_allowed", map[string]string{
+		"en": "method not allowed",
+		"ja": "このメソッドは利用できません",
+	}},
+	http.StatusRequestEntityTooLarge: {http.StatusRequestEntityTooLarge, "request_entity_too_large", map[string]string{
+		"en": "request entity too large",
+		"ja": "リクエストが大きすぎます",
+	}},
+	http.StatusUnsupportedMediaType: {http.StatusUnsupportedMediaType, "unsupported_media_type", map[string]string{
+		"en": "unsupported media type",
+		"ja": "このContent-Typeは利用できません",
+	}},
+}
+
+// findProblemType returns the registered type of err or errors wrapped by it.
+func findProblemType(err error) (problemType, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if !reflect.TypeOf(err).Comparable() {
+			continue // e.g. datastore.MultiError can't be the key of map.
+		}
+		if t, ok := problemTypes[err]; ok {
+			return t, true
+		}
+	}
+	return problemType{}, false
+}
+
+// HTTPErrorHandler renders all errors returned from handlers and middlewares as application/problem+json.
+// the message of unregistered error is never shown to clients, because it may contain the internal information.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	t, detail := problemTypeOf(err)
+	if t.Status >= http.StatusInternalServerError {
+		fatalLog(err)
+	} else {
+		warnLog(err)
+	}
+
+	body := problem{
+		Type:     problemTypePrefix + t.Code,
+		Title:    t.message(c.Request().Header.Get("Accept-Language")),
+		Status:   t.Status,
+		Detail:   detail,
+		Instance: c.Request().URL.Path,
+		Code:     t.Code,
+	}
+
+	var validationErrs domain.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		body.InvalidParams = validationErrs
+	}
+
+	var missingAssets domain.MissingLessonAssets
+	if errors.As(err, &missingAssets) {
+		body.MissingAssets = missingAssets
+	}
+
+	var unmetRequirements domain.UnmetLessonRequirements
+	if errors.As(err, &unmetRequirements) {
+		body.UnmetRequirements = unmetRequirements
+	}
+
+	var lintIssues domain.LessonMaterialLintIssues
+	if errors.As(err, &lintIssues) {
+		body.LintIssues = lintIssues
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, mimeApplicationProblemJSON)
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(t.Status)
+	} else {
+		err = c.JSON(t.Status, body)
+	}
+	if err != nil {
+		fatalLog(err)
+	}
+}
+
+// problemTypeOf returns the type and the detail safe to show for err.
+func problemTypeOf(err error) (problemType, string) {
+	var validationErrs domain.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return validationFailedProblem, ""
+	}
+
+	var missingAssets domain.MissingLessonAssets
+	if errors.As(err, &missingAssets) {
+		return lessonAssetsMissingProblem, ""
+	}
+
+	var unmetRequirements domain.UnmetLessonRequirements
+	if errors.As(err, &unmetRequirements) {
+		return lessonRequirementsUnmetProblem, ""
+	}
+
+	var lintIssues domain.LessonMaterialLintIssues
+	if errors.As(err, &lintIssues) {
+		return lessonMaterialLintFailedProblem, ""
+	}
+
+	if t, ok := findProblemType(err); ok {
+		return t, ""
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.Internal != nil {
+			if t, ok := findProblemType(httpErr.Internal); ok {
+				return t, ""
+			}
+		}
+
+		t, ok := httpErrorProblems[httpErr.Code]
+		if !ok {
+			if httpErr.Code >= http.StatusInternalServerError {
+				return internalServerErrorProblem, ""
+			}
+			t = problemType{httpErr.Code, strings.ToLower(strings.ReplaceAll(http.StatusText(httpErr.Code), " ", "_")), map[string]string{
+				defaultProblemLanguage: strings.ToLower(http.StatusText(httpErr.Code)),
+			}}
+		}
+
+		// the messages of echo for client errors are safe to show, e.g. the position of the syntax error of JSON.
+		if message, ok := httpErr.Message.(string); ok && t.Status < http.StatusInternalServerError {
+			return t, message
+		}
+		return t, ""
+	}
+
+	return internalServerErrorProblem, ""
+}
+
+// message returns the message in the language preferred in Accept-Language header.
+func (t problemType) message(acceptLanguage string) string {
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		language := strings.TrimSpace(strings.SplitN(tag, ";", 2)[0])
+		language = strings.ToLower(strings.SplitN(language, "-", 2)[0])
+		if message, ok := t.Messages[language]; ok {
+			return message
+		}
+	}
+	return t.Messages[defaultProblemLanguage]
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/domain"
+	"github.com/super-dog-human/teraconnectgo/usecase"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		acceptLanguage string
+		status         int
+		code           string
+		title          string
+		detail         string
+	}{
+		{"not found", usecase.LessonNotFound, "", http.StatusNotFound, "lesson_not_found", "lesson not found", ""},
+		{"forbidden", usecase.LessonNotAvailable, "", http.StatusForbidden, "lesson_not_available", "lesson not available", ""},
+		{"conflict", usecase.AlreadyUserExists, "", http.StatusConflict, "user_already_exists", "user already exists", ""},
+		{"wrapped", fmt.Errorf("get lesson: %w", domain.RevisionMismatch), "", http.StatusPreconditionFailed, "revision_mismatch", "updated by another request", ""},
+		{"japanese", usecase.LessonNotFound, "ja-JP,en;q=0.8", http.StatusNotFound, "lesson_not_found", "授業が見つかりません", ""},
+		{"unsupported language", usecase.LessonNotFound, "fr", http.StatusNotFound, "lesson_not_found", "lesson not found", ""},
+		{"unregistered", errors.New("datastore: secret key"), "", http.StatusInternalServerError, "internal_server_error", "internal server error", ""},
+		{"route", echo.ErrNotFound, "", http.StatusNotFound, "route_not_found", "route not found", "Not Found"},
+		{"echo internal", echo.NewHTTPError(http.StatusInternalServerError, "datastore: secret key"), "", http.StatusInternalServerError, "internal_server_error", "internal server error", ""},
+	}
+
+	for _, test := range tests {
+		rec := serveError(t, http.MethodGet, test.acceptLanguage, test.err)
+
+		if rec.Code != test.status {
+			t.Errorf("%s: status %d, want %d", test.name, rec.Code, test.status)
+		}
+		if contentType := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(contentType, mimeApplicationProblemJSON) {
+			t.Errorf("%s: Content-Type %s", test.name, contentType)
+		}
+		if strings.Contains(rec.Body.String(), "secret") {
+			t.Errorf("%s: internal message shown, %s", test.name, rec.Body.String())
+		}
+
+		var body problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Code != test.code || body.Type != problemTypePrefix+test.code || body.Status != test.status {
+			t.Errorf("%s: got %+v, want code %s", test.name, body, test.code)
+		}
+		if body.Title != test.title || body.Detail != test.detail {
+			t.Errorf("%s: title %q detail %q, want %q %q", test.name, body.Title, body.Detail, test.title, test.detail)
+		}
+		if body.Instance != "/lessons/1" {
+			t.Errorf("%s: instance %s", test.name, body.Instance)
+		}
+	}
+}
+
+func TestHTTPErrorHandlerValidationErrors(t *testing.T) {
+	invalidParams := domain.ValidationErrors{{Field: "speeches[0].elapsedTime", Rule: "gte", Message: "must be 0 or greater"}}
+	rec := serveError(t, http.MethodPost, "", fmt.Errorf("create lesson: %w", invalidParams))
+
+	var body problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusBadRequest || body.Code != "validation_failed" {
+		t.Errorf("got %d %s, want validation_failed", rec.Code, body.Code)
+	}
+	if len(body.InvalidParams) != 1 || body.InvalidParams[0] != invalidParams[0] {
+		t.Errorf("invalidParams %+v, want %+v", body.InvalidParams, invalidParams)
+	}
+}
+
+func TestHTTPErrorHandlerHead(t *testing.T) {
+	rec := serveError(t, http.MethodHead, "", usecase.LessonNotFound)
+
+	if rec.Code != http.StatusNotFound || rec.Body.Len() != 0 {
+		t.Errorf("got %d %q, want 404 without body", rec.Code, rec.Body.String())
+	}
+}
+
+func TestProblemTypesHaveDefaultMessage(t *testing.T) {
+	for err, problemType := range problemTypes {
+		if problemType.Messages[defaultProblemLanguage] == "" {
+			t.Errorf("%v: no message in %s", err, defaultProblemLanguage)
+		}
+		if problemType.Status < http.StatusBadRequest || problemType.Code == "" {
+			t.Errorf("%v: invalid problem %+v", err, problemType)
+		}
+	}
+}
+
+func serveError(t *testing.T, method, acceptLanguage string, err error) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, "/lessons/1", nil)
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	rec := httptest.NewRecorder()
+	HTTPErrorHandler(err, echo.New().NewContext(req, rec))
+	return rec
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/domain"
+	"github.com/super-dog-human/teraconnectgo/usecase"
+)
+
+type getSeriesListResponse struct {
+	Series     []domain.Series `json:"series"`
+	NextCursor string          `json:"nextCursor"`
+}
+
+func getSeries(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	series, err := usecase.GetSeries(c.Request().Context(), user, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, series)
+}
+
+func getCurrentUserSeries(c echo.Context) error {
+	user, _ := currentUser(c)
+
+	page, err := pageParams(c)
+	if err != nil {
+		return err
+	}
+
+	series, nextCursor, err := usecase.GetCurrentUserSeries(c.Request().Context(), user, page)
+	if err != nil {
+		return err
+	}
+
+	if series == nil {
+		series = []domain.Series{}
+	}
+
+	return c.JSON(http.StatusOK, getSeriesListResponse{Series: series, NextCursor: nextCursor})
+}
+
+func postSeries(c echo.Context) error {
+	params := new(usecase.NewSeriesParams)
+	if err := bindAndValidate(c, params); err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	series, err := usecase.CreateSeries(c.Request().Context(), user, *params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, series)
+}
+
+func patchSeries(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	patch, err := bindMergePatch(c, new(usecase.PatchSeriesParams))
+	if err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	series, err := usecase.UpdateSeries(c.Request().Context(), user, id, patch)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, series)
+}
+
+func deleteSeries(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	if err := usecase.DeleteSeries(c.Request().Context(), user, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func putSeriesLessons(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	params := new(usecase.SeriesLessonsParams)
+	if err := bindAndValidate(c, params); err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	series, err := usecase.ReorderSeriesLessons(c.Request().Context(), user, id, *params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, series)
+}
+
+func postSeriesLesson(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	params := new(usecase.NewSeriesLessonParams)
+	if err := bindAndValidate(c, params); err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	series, err := usecase.AddSeriesLesson(c.Request().Context(), user, id, *params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, series)
+}
+
+func deleteSeriesLesson(c echo.Context) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	lessonID, err := idParam(c, "lessonID")
+	if err != nil {
+		return err
+	}
+
+	user, _ := currentUser(c)
+	series, err := usecase.RemoveSeriesLesson(c.Request().Context(), user, id, lessonID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, series)
+}
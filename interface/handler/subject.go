@@ -8,9 +8,9 @@ import (
 )
 
 func getSubjects(c echo.Context) error {
-	subjects, err := usecase.GetSubjects(c.Request())
+	subjects, err := usecase.GetSubjects(c.Request().Context())
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, subjects)
 }
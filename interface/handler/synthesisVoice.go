@@ -9,21 +9,17 @@ import (
 )
 
 func postSynthesisVoice(c echo.Context) error {
+	user, _ := currentUser(c)
+
 	param := new(domain.CreateSynthesisVoiceParam)
 
-	if err := c.Bind(param); err != nil {
-		fatalLog(err)
-		authErr, ok := err.(domain.AuthErrorCode)
-		if ok && authErr == domain.UserNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		return c.JSON(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, param); err != nil {
+		return err
 	}
 
-	voice, err := usecase.CreateSynthesisVoice(c.Request(), param)
+	voice, err := usecase.CreateSynthesisVoice(c.Request().Context(), user, param)
 	if err != nil {
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, voice)
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/super-dog-human/teraconnectgo/domain"
@@ -10,38 +9,20 @@ import (
 )
 
 func getUserMe(c echo.Context) error {
-	user, err := usecase.GetCurrentUser(c.Request())
-
-	if err != nil {
-		authErr, ok := err.(domain.AuthErrorCode)
-		if ok && authErr == domain.UserNotFound {
-			// when token is valid but user account not exists.
-			warnLog(err)
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
-	}
+	user, _ := currentUser(c)
 
 	return c.JSON(http.StatusOK, user)
 }
 
 func getUser(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := idParam(c, "id")
 	if err != nil {
 		return err
 	}
 
-	user, err := usecase.GetUser(c.Request(), id)
-
+	user, err := usecase.GetUser(c.Request().Context(), id)
 	if err != nil {
-		authErr, ok := err.(domain.AuthErrorCode)
-		if ok && authErr == domain.UserNotFound {
-			warnLog(err)
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, user)
@@ -50,49 +31,37 @@ func getUser(c echo.Context) error {
 func postUser(c echo.Context) error {
 	user := new(domain.User)
 
-	if err := c.Bind(user); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, user); err != nil {
+		return err
 	}
 
-	if err := usecase.CreateUser(c.Request(), user); err != nil {
-		userErr, ok := err.(usecase.UserErrorCode)
-		if ok && userErr == usecase.AlreadyUserExists {
-			return c.JSON(http.StatusConflict, err.Error())
-		}
-		return c.JSON(http.StatusInternalServerError, err.Error())
+	claims, _ := tokenClaims(c)
+	if err := usecase.CreateUser(c.Request().Context(), claims.ProviderID(), user); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, user)
 }
 
 func patchUser(c echo.Context) error {
-	user := new(domain.User)
-
-	if err := c.Bind(user); err != nil {
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+	patch, err := bindMergePatch(c, new(usecase.PatchUserParams))
+	if err != nil {
+		return err
 	}
 
-	if err := usecase.UpdateUser(c.Request(), user); err != nil {
-		fatalLog(err)
-		userErr, ok := err.(usecase.UserErrorCode)
-		if ok && userErr == usecase.UserNotAvailable {
-			return c.JSON(http.StatusForbidden, err.Error())
-		}
-		return c.JSON(http.StatusInternalServerError, err.Error())
+	current, _ := currentUser(c)
+	user, err := usecase.UpdateUser(c.Request().Context(), current, patch)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, user)
 }
 
 func deleteUser(c echo.Context) error {
-	if err := usecase.UnsubscribeCurrentUser(c.Request()); err != nil {
-		fatalLog(err)
-		authErr, ok := err.(domain.AuthErrorCode)
-		if ok && authErr == domain.UserNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		return c.JSON(http.StatusInternalServerError, err.Error())
+	user, _ := currentUser(c)
+	if err := usecase.UnsubscribeCurrentUser(c.Request().Context(), user); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusNoContent, "succeeded")
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+
+	"github.com/labstack/echo/v4"
+	"github.com/super-dog-human/teraconnectgo/domain"
+)
+
+// requestValidator validates params bound from requests with the rules in "validate" tags.
+type requestValidator struct{}
+
+func (requestValidator) Validate(i interface{}) error {
+	return domain.Validate(i)
+}
+
+// bindAndValidate binds the request to params, and validates them.
+func bindAndValidate(c echo.Context, params interface{}) error {
+	if err := c.Bind(params); err != nil {
+		return err
+	}
+	return c.Validate(params)
+}
+
+// bindMergePatch reads the request as JSON Merge Patch (RFC 7396), and validates the members sent with the rules of params.
+// the members set to null are not validated, because they clear the fields.
+func bindMergePatch(c echo.Context, params interface{}) (domain.MergePatch, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if mediaType != echo.MIMEApplicationJSON && mediaType != "application/merge-patch+json" {
+		return nil, echo.ErrUnsupportedMediaType
+	}
+
+	body, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var patch domain.MergePatch
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		return nil, InvalidRequestBody
+	}
+
+	if err := json.Unmarshal(body, params); err != nil {
+		return nil, InvalidRequestBody
+	}
+
+	if err := c.Validate(params); err != nil {
+		return nil, err
+	}
+
+	return patch, nil
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/super-dog-human/teraconnectgo/domain"
@@ -10,67 +9,63 @@ import (
 )
 
 func getVoice(c echo.Context) error {
-	lessonID, err := strconv.ParseInt(c.QueryParam("lesson_id"), 10, 64)
+	user, _ := currentUser(c)
+
+	lessonID, err := idQueryParam(c, "lesson_id")
 	if err != nil {
-		errMessage := "Invalid ID(s) error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := idParam(c, "id")
 	if err != nil {
-		errMessage := "Invalid ID(s) error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	voice, err := usecase.GetVoice(c.Request(), lessonID, id)
+	voice, err := usecase.GetVoice(c.Request().Context(), user, lessonID, id, c.QueryParam("view_key"))
 	if err != nil {
-		voiceErr, ok := err.(domain.VoiceErrorCode)
-		if ok && voiceErr == domain.VoiceNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, voice)
 }
 
+type getVoicesResponse struct {
+	Voices     []domain.Voice `json:"voices"`
+	NextCursor string         `json:"nextCursor"`
+}
+
 func getVoices(c echo.Context) error {
-	lessonID, err := strconv.ParseInt(c.QueryParam("lesson_id"), 10, 64)
+	user, _ := currentUser(c)
+
+	lessonID, err := idQueryParam(c, "lesson_id")
 	if err != nil {
-		errMessage := "Invalid ID(s) error"
-		warnLog(errMessage)
-		return c.JSON(http.StatusBadRequest, errMessage)
+		return err
 	}
 
-	voices, err := usecase.GetVoices(c.Request(), lessonID)
+	page, err := pageParams(c)
 	if err != nil {
-		voiceErr, ok := err.(domain.VoiceErrorCode)
-		if ok && voiceErr == domain.VoiceNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return err
 	}
 
-	return c.JSON(http.StatusOK, voices)
+	voices, nextCursor, err := usecase.GetVoices(c.Request().Context(), user, lessonID, c.QueryParam("view_key"), page)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, getVoicesResponse{Voices: voices, NextCursor: nextCursor})
 }
 
 func postVoice(c echo.Context) error {
+	user, _ := currentUser(c)
+
 	param := new(usecase.CreateVoiceParam)
-	if err := c.Bind(param); err != nil {
-		fatalLog(err)
-		authErr, ok := err.(domain.AuthErrorCode)
-		if ok && authErr == domain.UserNotFound {
-			return c.JSON(http.StatusNotFound, err.Error())
-		}
-		return c.JSON(http.StatusBadRequest, err.Error())
+	if err := bindAndValidate(c, param); err != nil {
+		return err
 	}
 
-	signedURL, err := usecase.CreateVoiceAndBlankFile(c.Request(), param)
+	signedURL, err := usecase.CreateVoiceAndBlankFile(c.Request().Context(), user, param)
 	if err != nil {
-		fatalLog(err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, signedURL)
@@ -1,13 +1,22 @@
 package main
 
 import (
-	"os"
+	"flag"
+	"log"
 
+	"github.com/super-dog-human/teraconnectgo/infrastructure"
 	"github.com/super-dog-human/teraconnectgo/interface/handler"
 )
 
 func main() {
-	if appEnv := os.Args[1]; appEnv != "" {
-		handler.Main(appEnv)
+	configPath := flag.String("config", "", "path to the config file of YAML or JSON")
+	flag.Parse()
+
+	// the environment can be given as the first argument, e.g. "go run main.go development".
+	config, err := infrastructure.LoadConfig(*configPath, flag.Arg(0))
+	if err != nil {
+		log.Fatalf("failed to load the config. %v\n", err)
 	}
+
+	handler.Main(config)
 }
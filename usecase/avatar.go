@@ -1,46 +1,41 @@
 package usecase
 
 import (
-	"net/http"
+	"context"
 	"strconv"
 
 	"github.com/super-dog-human/teraconnectgo/domain"
 	"github.com/super-dog-human/teraconnectgo/infrastructure"
 )
 
-// GetAvailableAvatars for fetch avatar object from Cloud Datastore
-func GetAvailableAvatars(request *http.Request) ([]domain.Avatar, error) {
-	ctx := request.Context()
-
+// GetAvailableAvatars for fetch avatar object from Cloud Datastore.
+// the page continues from user's avatars to public avatars.
+func GetAvailableAvatars(ctx context.Context, currentUser domain.User, page domain.Page) ([]domain.Avatar, string, error) {
 	var avatars []domain.Avatar
 
-	currentUser, err := domain.GetCurrentUser(request)
+	nextCursor, err := fetchChainedPages(page,
+		func(page domain.Page) (int, string, error) {
+			usersAvatars, nextCursor, err := domain.GetCurrentUsersAvatars(ctx, currentUser.ID, page)
+			avatars = append(avatars, usersAvatars...)
+			return len(usersAvatars), nextCursor, err
+		},
+		func(page domain.Page) (int, string, error) {
+			publicAvatars, nextCursor, err := domain.GetPublicAvatars(ctx, page)
+			avatars = append(avatars, publicAvatars...)
+			return len(publicAvatars), nextCursor, err
+		},
+	)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	usersAvatars, err := domain.GetCurrentUsersAvatars(ctx, currentUser.ID)
-	if err != nil {
-		return nil, err
-	}
-	avatars = append(avatars, usersAvatars...)
-
-	publicAvatars, err := domain.GetPublicAvatars(ctx)
-	if err != nil {
-		return nil, err
-	}
-	avatars = append(avatars, publicAvatars...)
-
-	return avatars, nil
+	return avatars, nextCursor, nil
 }
 
-func CreateAvatarsAndBlankFile(request *http.Request, objectRequest infrastructure.StorageObjectRequest) (infrastructure.SignedURLs, error) {
-	ctx := request.Context()
-
+func CreateAvatarsAndBlankFile(ctx context.Context, currentUser domain.User, objectRequest infrastructure.StorageObjectRequest) (infrastructure.SignedURLs, error) {
 	var signedURLs infrastructure.SignedURLs
 
-	currentUser, err := domain.GetCurrentUser(request)
-	if err != nil {
+	if err := domain.ValidateFileExtensions(objectRequest.FileRequests, "vrm"); err != nil {
 		return signedURLs, err
 	}
 
@@ -49,12 +44,12 @@ func CreateAvatarsAndBlankFile(request *http.Request, objectRequest infrastructu
 	for i, fileRequest := range objectRequest.FileRequests {
 		avatar := new(domain.Avatar)
 
-		if err = domain.CreateAvatar(ctx, avatar, &currentUser); err != nil {
+		if err := domain.CreateAvatar(ctx, avatar, &currentUser); err != nil {
 			return signedURLs, err
 		}
 
 		fileID := strconv.FormatInt(avatar.ID, 10)
-		url, err := infrastructure.CreateBlankFileToGCS(ctx, fileID, "avatar", fileRequest)
+		url, err := infrastructure.CreateBlankFile(ctx, fileID, "avatar", fileRequest)
 		if err != nil {
 			return signedURLs, err
 		}
@@ -1,13 +1,12 @@
 package usecase
 
 import (
-	"net/http"
+	"context"
 
 	"github.com/super-dog-human/teraconnectgo/domain"
 )
 
 // GetBackgroundImages returns image URLs in Cloud Datastore.
-func GetBackgroundImages(request *http.Request) ([]domain.BackgroundImage, error) {
-	ctx := request.Context()
+func GetBackgroundImages(ctx context.Context) ([]domain.BackgroundImage, error) {
 	return domain.GetAllBackgroundImages(ctx)
 }
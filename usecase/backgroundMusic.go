@@ -1,7 +1,7 @@
 package usecase
 
 import (
-	"net/http"
+	"context"
 	"strconv"
 
 	"github.com/super-dog-human/teraconnectgo/domain"
@@ -9,50 +9,41 @@ import (
 )
 
 type CreateBackgroundMusicParam struct {
-	Name string `json:"name"`
+	Name string `json:"name" validate:"required"`
 }
 
 // GetBackgroundMusics returns music URLs in Cloud Datastore.
-func GetBackgroundMusics(request *http.Request) ([]domain.BackgroundMusic, error) {
-	ctx := request.Context()
-
-	currentUser, err := domain.GetCurrentUser(request)
-	if err != nil {
-		return nil, err
-	}
-
+// the page continues from user's musics to public musics.
+func GetBackgroundMusics(ctx context.Context, currentUser domain.User, page domain.Page) ([]domain.BackgroundMusic, string, error) {
 	var musics []domain.BackgroundMusic
 
-	usersMusics, err := domain.GetCurrentUsersBackgroundMusics(ctx, currentUser.ID)
-	if err != nil {
-		return nil, err
-	}
-	musics = append(musics, usersMusics...)
-
-	publicMusics, err := domain.GetPublicBackgroundMusics(ctx)
+	nextCursor, err := fetchChainedPages(page,
+		func(page domain.Page) (int, string, error) {
+			usersMusics, nextCursor, err := domain.GetCurrentUsersBackgroundMusics(ctx, currentUser.ID, page)
+			musics = append(musics, usersMusics...)
+			return len(usersMusics), nextCursor, err
+		},
+		func(page domain.Page) (int, string, error) {
+			publicMusics, nextCursor, err := domain.GetPublicBackgroundMusics(ctx, page)
+			musics = append(musics, publicMusics...)
+			return len(publicMusics), nextCursor, err
+		},
+	)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	musics = append(musics, publicMusics...)
 
-	return musics, nil
+	return musics, nextCursor, nil
 }
 
-func CreateBackgroundMusicAndBlankFile(request *http.Request, param *CreateBackgroundMusicParam) (infrastructure.SignedURL, error) {
-	ctx := request.Context()
-
+func CreateBackgroundMusicAndBlankFile(ctx context.Context, currentUser domain.User, param *CreateBackgroundMusicParam) (infrastructure.SignedURL, error) {
 	var signedURL infrastructure.SignedURL
 
-	currentUser, err := domain.GetCurrentUser(request)
-	if err != nil {
-		return signedURL, err
-	}
-
 	backgroundMusic := new(domain.BackgroundMusic)
 	backgroundMusic.Name = param.Name
 	backgroundMusic.IsPublic = false
 
-	if err = domain.CreateBackgroundMusic(ctx, currentUser.ID, backgroundMusic); err != nil {
+	if err := domain.CreateBackgroundMusic(ctx, currentUser.ID, backgroundMusic); err != nil {
 		return signedURL, err
 	}
 
@@ -65,7 +56,7 @@ func CreateBackgroundMusicAndBlankFile(request *http.Request, param *CreateBackg
 		ContentType: "audio/mpeg",
 	}
 
-	url, err := infrastructure.CreateBlankFileToGCS(ctx, fileID, "bgm", mp3FileRequest)
+	url, err := infrastructure.CreateBlankFile(ctx, fileID, "bgm", mp3FileRequest)
 	if err != nil {
 		return signedURL, err
 	}
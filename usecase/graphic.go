@@ -1,27 +1,19 @@
 package usecase
 
 import (
+	"context"
 	"errors"
-	"net/http"
 	"strconv"
 
-	"cloud.google.com/go/storage"
 	"github.com/super-dog-human/teraconnectgo/domain"
 	"github.com/super-dog-human/teraconnectgo/infrastructure"
 )
 
 // GetGraphicByID is fetching a graphic by id.
-func GetGraphicByID(request *http.Request, id int64) (domain.Graphic, error) {
-	ctx := request.Context()
-
+func GetGraphicByID(ctx context.Context, currentUser domain.User, id int64) (domain.Graphic, error) {
 	var graphic domain.Graphic
 
-	currentUser, err := domain.GetCurrentUser(request)
-	if err != nil {
-		return graphic, err
-	}
-
-	graphic, err = domain.GetGraphicByID(ctx, id, currentUser.ID)
+	graphic, err := domain.GetGraphicByID(ctx, id, currentUser.ID)
 	if err != nil {
 		return graphic, err
 	}
@@ -36,30 +28,30 @@ func GetGraphicByID(request *http.Request, id int64) (domain.Graphic, error) {
 	return graphic, nil
 }
 
-// GetGraphicsByLessonID is fetching graphics belongs to lesson.
-func GetGraphicsByLessonID(request *http.Request, lessonID int64) ([]*domain.Graphic, error) {
-	ctx := request.Context()
-
+// GetGraphicsByLessonID is fetching the page of graphics belongs to lesson, viewKey is required to the others if it is limited.
+func GetGraphicsByLessonID(ctx context.Context, currentUser domain.User, lessonID int64, viewKey string, page domain.Page) ([]*domain.Graphic, string, error) {
 	var graphics []*domain.Graphic
 
-	if _, err := currentUserAccessToLesson(ctx, request, lessonID); err != nil {
-		return nil, err
+	if _, err := viewerAccessToLesson(ctx, currentUser, lessonID, viewKey); err != nil {
+		return nil, "", err
 	}
 
-	if err := domain.GetGraphicsByLessonID(ctx, lessonID, &graphics); err != nil {
-		return nil, err
+	nextCursor, err := domain.GetGraphicsByLessonID(ctx, lessonID, page, &graphics)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return graphics, nil
+	return graphics, nextCursor, nil
 }
 
-func CreateGraphicsAndBlankFiles(request *http.Request, objectRequest infrastructure.StorageObjectRequest) (infrastructure.SignedURLs, error) {
-	ctx := request.Context()
-
+func CreateGraphicsAndBlankFiles(ctx context.Context, currentUser domain.User, objectRequest infrastructure.StorageObjectRequest) (infrastructure.SignedURLs, error) {
 	var signedURLs infrastructure.SignedURLs
 
-	userID, err := currentUserAccessToLesson(ctx, request, objectRequest.LessonID)
-	if err != nil {
+	if err := domain.ValidateFileExtensions(objectRequest.FileRequests, "png", "jpg", "jpeg", "gif"); err != nil {
+		return signedURLs, err
+	}
+
+	if err := currentUserAccessToLesson(ctx, currentUser, objectRequest.LessonID); err != nil {
 		return signedURLs, err
 	}
 
@@ -73,13 +65,13 @@ func CreateGraphicsAndBlankFiles(request *http.Request, objectRequest infrastruc
 		graphics[i] = graphic
 	}
 
-	if err = domain.CreateGraphics(ctx, userID, graphics); err != nil {
+	if err := domain.CreateGraphics(ctx, currentUser.ID, graphics); err != nil {
 		return signedURLs, err
 	}
 
 	for i, fileRequest := range objectRequest.FileRequests {
 		fileID := strconv.FormatInt(graphics[i].ID, 10)
-		url, err := infrastructure.CreateBlankFileToGCS(ctx, fileID, "graphic", fileRequest)
+		url, err := infrastructure.CreateBlankFile(ctx, fileID, "graphic", fileRequest)
 		if err != nil {
 			return signedURLs, err
 		}
@@ -89,14 +81,7 @@ func CreateGraphicsAndBlankFiles(request *http.Request, objectRequest infrastruc
 	return infrastructure.SignedURLs{SignedURLs: urls}, nil
 }
 
-func DeleteGraphic(request *http.Request, id int64) error {
-	ctx := request.Context()
-
-	currentUser, err := domain.GetCurrentUser(request)
-	if err != nil {
-		return err
-	}
-
+func DeleteGraphic(ctx context.Context, currentUser domain.User, id int64) error {
 	graphic, err := domain.GetGraphicByID(ctx, id, currentUser.ID)
 	if err != nil {
 		return err
@@ -107,7 +92,7 @@ func DeleteGraphic(request *http.Request, id int64) error {
 	}
 
 	if err := domain.DeleteGraphicFileByID(ctx, graphic); err != nil {
-		if ok := errors.Is(err, storage.ErrObjectNotExist); ok {
+		if ok := errors.Is(err, infrastructure.ErrBlobNotExist); ok {
 			return nil // 削除しようとするファイルが存在しなくてもエラーにしない
 		}
 		return err
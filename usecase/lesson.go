@@ -2,10 +2,10 @@ package usecase
 
 import (
 	"context"
-	"net/http"
-	"reflect"
+	"net/url"
+	"strconv"
+	"time"
 
-	"cloud.google.com/go/datastore"
 	"github.com/jinzhu/copier"
 	"github.com/super-dog-human/teraconnectgo/domain"
 )
@@ -38,23 +38,25 @@ type NewLessonParams struct {
 	HasThumbnail       bool   `json:"hasThumbnail"`
 	SubjectID          int64  `json:"subjectID"`
 	JapaneseCategoryID int64  `json:"japaneseCategoryID"`
-	Title              string `json:"title"`
+	Title              string `json:"title" validate:"max=100"`
 }
 
+// PatchLessonAndMaterialParams validates the JSON Merge Patch of the lesson and the material.
 type PatchLessonAndMaterialParams struct {
 	PatchLessonParams
 	PatchLessonMaterialParams
 }
 
 type PatchLessonParams struct {
-	PrevLessonID       int64                     `json:"prevLessonID"`
-	NextLessonID       int64                     `json:"nextLessonID"`
 	SubjectID          int64                     `json:"subjectID"`
 	JapaneseCategoryID int64                     `json:"japaneseCategoryID"`
 	Status             domain.LessonStatus       `json:"status"`
-	Title              string                    `json:"title"`
-	Description        string                    `json:"description"`
-	References         []domain.LessonReferences `json:"references"`
+	HasThumbnail       bool                      `json:"hasThumbnail"`
+	Title              string                    `json:"title" validate:"max=100"`
+	Description        string                    `json:"description" validate:"max=1000"`
+	References         []domain.LessonReferences `json:"references" validate:"max=20,dive"`
+	PublishAt          time.Time                 `json:"publishAt"`
+	UnpublishAt        time.Time                 `json:"unpublishAt"`
 }
 
 type PatchLessonMaterialParams struct {
@@ -64,60 +66,71 @@ type PatchLessonMaterialParams struct {
 	VoiceSynthesisConfig domain.VoiceSynthesisConfig `json:"voiceSynthesisConfig"`
 }
 
-// GetLessonsByConditions for search lessons
-func GetLessonsByConditions(request *http.Request) ([]domain.Lesson, error) {
-	//		ctx := request.Context()
-
-	//	id := c.Param("id")
-
-	// 検索パラメータ
-	// ページネーション
-	// SearchAPIが必須
+// GetLessonsByConditions returns the page of public lessons matched to the query, and the cursor of next page.
+// the page is empty when no lesson is matched.
+func GetLessonsByConditions(ctx context.Context, query url.Values) ([]domain.Lesson, string, error) {
+	conditions, err := lessonSearchConditions(query)
+	if err != nil {
+		return nil, "", InvalidLessonParams
+	}
 
-	return nil, nil
+	return domain.SearchPublicLessons(ctx, conditions)
 }
 
-// GetPublicLesson for fetch the lesson by id
-func GetPublicLesson(request *http.Request, id int64) (domain.Lesson, error) {
-	ctx := request.Context()
+func lessonSearchConditions(query url.Values) (domain.LessonSearchConditions, error) {
+	var conditions domain.LessonSearchConditions
+	var err error
+
+	int64Params := map[string]*int64{
+		"subject_id":           &conditions.SubjectID,
+		"japanese_category_id": &conditions.JapaneseCategoryID,
+		"user_id":              &conditions.UserID,
+	}
+	for name, value := range int64Params {
+		if query.Get(name) == "" {
+			continue
+		}
+		if *value, err = strconv.ParseInt(query.Get(name), 10, 64); err != nil {
+			return conditions, err
+		}
+	}
 
-	_, err := domain.GetCurrentUser(request)
-	authErr, _ := err.(domain.AuthErrorCode)
+	if query.Get("limit") != "" {
+		if conditions.Limit, err = strconv.Atoi(query.Get("limit")); err != nil {
+			return conditions, err
+		}
+	}
 
-	if err != nil && authErr != domain.TokenNotFound {
-		// can get lesson without token, but can NOT get with invalid token.
-		lesson := new(domain.Lesson)
-		return *lesson, err
+	if conditions.SortOrder, err = domain.ParseLessonSortOrder(query.Get("sort")); err != nil {
+		return conditions, err
 	}
 
-	lesson, err := domain.GetLessonByID(ctx, id)
-	if err == datastore.ErrNoSuchEntity {
+	conditions.Keywords = query.Get("q")
+	conditions.Cursor = query.Get("cursor")
+
+	return conditions, nil
+}
+
+// GetPublicLesson returns the public lesson, the limited lesson with the valid viewKey, or the lesson whose review is requested to current user.
+// the related lessons are only public ones even for the reviewers.
+func GetPublicLesson(ctx context.Context, currentUser domain.User, id int64, viewKey string) (domain.Lesson, error) {
+	lesson, err := viewerAccessToLesson(ctx, currentUser, id, viewKey)
+	if err == domain.ErrNoSuchEntity {
 		return lesson, LessonNotFound
 	} else if err != nil {
 		return lesson, err
 	}
 
-	if err = setRelationLessonTitle(ctx, &lesson); err != nil {
+	if err = setRelationLessonTitle(ctx, &lesson, true); err != nil {
 		return lesson, err
 	}
 
-	if lesson.Status == domain.LessonStatusPublic {
-		return lesson, nil
-	}
-
-	return lesson, LessonNotAvailable
+	return lesson, nil
 }
 
-func GetPrivateLesson(request *http.Request, id int64) (domain.Lesson, error) {
-	currentUser, err := domain.GetCurrentUser(request)
-	if err != nil {
-		lesson := new(domain.Lesson)
-		return *lesson, err
-	}
-
-	ctx := request.Context()
+func GetPrivateLesson(ctx context.Context, currentUser domain.User, id int64) (domain.Lesson, error) {
 	lesson, err := domain.GetLessonByID(ctx, id)
-	if err == datastore.ErrNoSuchEntity {
+	if err == domain.ErrNoSuchEntity {
 		return lesson, LessonNotFound
 	} else if err != nil {
 		return lesson, err
@@ -127,64 +140,128 @@ func GetPrivateLesson(request *http.Request, id int64) (domain.Lesson, error) {
 		return lesson, InvalidLessonParams
 	}
 
-	if err = setRelationLessonTitle(ctx, &lesson); err != nil {
+	if err = setRelationLessonTitle(ctx, &lesson, false); err != nil {
 		return lesson, err
 	}
 
 	return lesson, nil
 }
 
-func GetCurrentUserLessons(request *http.Request) ([]domain.Lesson, error) {
-	ctx := request.Context()
-
-	currentUser, err := domain.GetCurrentUser(request)
+func GetCurrentUserLessons(ctx context.Context, currentUser domain.User, page domain.Page) ([]domain.Lesson, string, error) {
+	lessons, nextCursor, err := domain.GetLessonsByUserID(ctx, currentUser.ID, page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	lessons, err := domain.GetLessonsByUserID(ctx, currentUser.ID)
-	if err != nil {
-		return nil, err
-	}
-
-	return lessons, nil
+	return lessons, nextCursor, nil
 }
 
 // CreateLesson is create the new lesson belongs to subject and category.
-func CreateLesson(request *http.Request, newLesson *NewLessonParams, lesson *domain.Lesson) error {
-	currentUser, err := domain.GetCurrentUser(request)
-	if err != nil {
-		return InvalidLessonParams
-	}
-
+func CreateLesson(ctx context.Context, currentUser domain.User, newLesson *NewLessonParams, lesson *domain.Lesson) error {
 	copier.Copy(&lesson, &newLesson)
 
-	ctx := request.Context()
-
 	if err := setCategoryAndSubject(ctx, newLesson.SubjectID, newLesson.JapaneseCategoryID, lesson); err != nil {
 		return InvalidLessonParams
 	}
 
 	lesson.UserID = currentUser.ID
 
-	if err = domain.CreateLesson(ctx, lesson); err != nil {
+	if err := domain.CreateLesson(ctx, lesson); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func UpdateLessonWithMaterial(id int64, request *http.Request, params *PatchLessonAndMaterialParams) error {
-	ctx := request.Context()
+// UpdateLessonWithMaterial applies the JSON Merge Patch to the lesson and the material of the revisions, and returns the new revisions of them.
+// the members of PatchLessonParams are applied to the lesson, and the members of PatchLessonMaterialParams are applied to the material.
+// the revision of material is only checked and returned when the patch has the members of material.
+func UpdateLessonWithMaterial(ctx context.Context, currentUser domain.User, id int64, revision int64, materialRevision int64, patch domain.MergePatch) (int64, int64, error) {
+	lesson, err := domain.GetLessonByID(ctx, id)
+	if err != nil {
+		if err == domain.ErrNoSuchEntity {
+			return 0, 0, LessonNotFound
+		}
+		return 0, 0, err
+	}
 
-	currentUser, err := domain.GetCurrentUser(request)
+	if lesson.UserID != currentUser.ID {
+		return 0, 0, InvalidLessonParams
+	}
+
+	lessonPatch := patch.Only(PatchLessonParams{})
+	materialPatch := patch.Only(PatchLessonMaterialParams{})
+
+	if lessonPatch.Has("subjectID") || lessonPatch.Has("japaneseCategoryID") {
+		if err := patchCategoryAndSubjectNames(ctx, lesson, lessonPatch); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if len(materialPatch) > 0 && lesson.MaterialID == 0 {
+		return 0, 0, LessonMaterialNotFound
+	}
+
+	newLesson, newMaterial, err := domain.UpdateLessonAndMaterial(ctx, id, lesson.MaterialID, revision, materialRevision, lessonPatch, materialPatch)
 	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := indexLesson(ctx, id); err != nil {
+		return 0, 0, err
+	}
+
+	return newLesson.Revision, newMaterial.Revision, nil
+}
+
+// patchCategoryAndSubjectNames adds the names of subject and category after the patch to the patch.
+// the names are cleared when both of subject and category are cleared.
+func patchCategoryAndSubjectNames(ctx context.Context, lesson domain.Lesson, patch domain.MergePatch) error {
+	if err := patch.Apply(&lesson); err != nil {
+		return InvalidLessonParams
+	}
+
+	if lesson.SubjectID == 0 && lesson.JapaneseCategoryID == 0 {
+		lesson.SubjectName = ""
+		lesson.JapaneseCategoryName = ""
+	} else if err := setCategoryAndSubject(ctx, lesson.SubjectID, lesson.JapaneseCategoryID, &lesson); err != nil {
+		return InvalidLessonParams
+	}
+
+	if err := patch.Set("subjectName", lesson.SubjectName); err != nil {
 		return err
 	}
+	return patch.Set("japaneseCategoryName", lesson.JapaneseCategoryName)
+}
 
+// DuplicateLesson creates the new draft of current user copied from the lesson.
+// the lessons of other users can be duplicated only when they are public.
+func DuplicateLesson(ctx context.Context, currentUser domain.User, id int64) (domain.Lesson, error) {
 	lesson, err := domain.GetLessonByID(ctx, id)
 	if err != nil {
-		if err == datastore.ErrNoSuchEntity {
+		if err == domain.ErrNoSuchEntity {
+			return domain.Lesson{}, LessonNotFound
+		}
+		return domain.Lesson{}, err
+	}
+
+	if lesson.UserID != currentUser.ID && lesson.Status != domain.LessonStatusPublic {
+		return domain.Lesson{}, LessonNotAvailable
+	}
+
+	duplicated, err := domain.DuplicateLesson(ctx, currentUser, lesson)
+	if err == domain.ErrNoSuchEntity {
+		return domain.Lesson{}, LessonMaterialNotFound
+	}
+
+	return duplicated, err
+}
+
+// DeleteLesson deletes the lesson and all things belongs to it. the lesson failed to delete on the way can be deleted again.
+func DeleteLesson(ctx context.Context, currentUser domain.User, id int64) error {
+	lesson, err := domain.GetLessonToDelete(ctx, id)
+	if err != nil {
+		if err == domain.ErrNoSuchEntity {
 			return LessonNotFound
 		}
 		return err
@@ -194,59 +271,89 @@ func UpdateLessonWithMaterial(id int64, request *http.Request, params *PatchLess
 		return InvalidLessonParams
 	}
 
-	var lessonParams PatchLessonParams
-	var materialParams PatchLessonMaterialParams
+	return domain.DeleteLesson(ctx, lesson)
+}
+
+// indexLesson syncs the search index with the latest lesson.
+func indexLesson(ctx context.Context, id int64) error {
+	lesson, err := domain.GetLessonByID(ctx, id)
+	if err != nil {
+		return err
+	}
 
-	copier.Copy(&lessonParams, *params)
-	copier.Copy(&materialParams, *params)
+	return domain.IndexLesson(ctx, lesson)
+}
 
-	var newLesson domain.Lesson
-	var newLessonMaterial domain.LessonMaterial
+// setRelationLessonTitle sets the previous and next lessons with their titles.
+// the lessons in the series are derived from its order, and only public lessons are linked when publicOnly is true.
+func setRelationLessonTitle(ctx context.Context, lesson *domain.Lesson, publicOnly bool) error {
+	if lesson.SeriesID != 0 {
+		return setSeriesRelationLessons(ctx, lesson, publicOnly)
+	}
 
-	var blankLessonParams PatchLessonParams
-	if !reflect.DeepEqual(lessonParams, blankLessonParams) {
-		copier.Copy(&newLesson, lessonParams)
+	relations := []struct {
+		id    int64
+		title *string
+	}{
+		{lesson.PrevLessonID, &lesson.PrevLessonTitle},
+		{lesson.NextLessonID, &lesson.NextLessonTitle},
 	}
 
-	if lessonParams.SubjectID != 0 && lessonParams.JapaneseCategoryID != 0 {
-		if err = setCategoryAndSubject(ctx, lessonParams.SubjectID, lessonParams.JapaneseCategoryID, &lesson); err != nil {
+	for _, relation := range relations {
+		if relation.id == 0 {
+			continue
+		}
+
+		relationLesson, err := domain.GetLessonByID(ctx, relation.id)
+		if err == domain.ErrNoSuchEntity {
+			continue // 授業が見つからなかった場合もエラーにしない
+		} else if err != nil {
 			return err
 		}
-	}
 
-	var blankMaterialParams PatchLessonParams
-	if !reflect.DeepEqual(materialParams, blankMaterialParams) {
-		copier.Copy(&newLessonMaterial, materialParams)
+		if !publicOnly || relationLesson.Status == domain.LessonStatusPublic {
+			*relation.title = relationLesson.Title
+		}
 	}
 
-	if err := domain.UpdateLessonAndMaterial(ctx, id, lesson.MaterialID, &newLesson, &newLessonMaterial); err != nil {
+	return nil
+}
+
+func setSeriesRelationLessons(ctx context.Context, lesson *domain.Lesson, publicOnly bool) error {
+	lesson.PrevLessonID, lesson.NextLessonID = 0, 0
+
+	series, err := domain.GetSeries(ctx, lesson.SeriesID)
+	if err == domain.SeriesNotFound {
+		return nil
+	} else if err != nil {
 		return err
 	}
 
-	return nil
-}
+	seriesLessons, err := domain.GetLessonsByIDs(ctx, series.LessonIDs)
+	if err != nil {
+		return err
+	}
 
-func setRelationLessonTitle(ctx context.Context, lesson *domain.Lesson) error {
-	if lesson.PrevLessonID != 0 {
-		lesson, err := domain.GetLessonByID(ctx, lesson.PrevLessonID)
-		if err != nil {
-			if err == datastore.ErrNoSuchEntity {
-				return nil // 授業が見つからなかった場合もエラーにしない
-			}
-			return err
+	var linkable []domain.Lesson
+	for _, seriesLesson := range seriesLessons {
+		if seriesLesson.ID == lesson.ID || !publicOnly || seriesLesson.Status == domain.LessonStatusPublic {
+			linkable = append(linkable, seriesLesson)
 		}
-		lesson.PrevLessonTitle = lesson.Title
 	}
 
-	if lesson.NextLessonID != 0 {
-		lesson, err := domain.GetLessonByID(ctx, lesson.NextLessonID)
-		if err != nil {
-			if err == datastore.ErrNoSuchEntity {
-				return nil // 授業が見つからなかった場合もエラーにしない
-			}
-			return err
+	for i, seriesLesson := range linkable {
+		if seriesLesson.ID != lesson.ID {
+			continue
+		}
+
+		if i > 0 {
+			lesson.PrevLessonID = linkable[i-1].ID
+			lesson.PrevLessonTitle = linkable[i-1].Title
+		}
+		if i < len(linkable)-1 {
+			lesson.NextLessonID = linkable[i+1].ID
+			lesson.NextLessonTitle = linkable[i+1].Title
 		}
-		lesson.NextLessonTitle = lesson.Title
 	}
 
 	return nil
@@ -1,27 +1,26 @@
 package usecase
 
 import (
+	"context"
 	"errors"
-	"net/http"
 
-	"cloud.google.com/go/datastore"
 	"github.com/jinzhu/copier"
 	"github.com/super-dog-human/teraconnectgo/domain"
 )
 
 // LessonMaterialParams
 type LessonMaterialParams struct {
-	DurationSec          float32                     `json:"durationSec"`
+	DurationSec          float32                     `json:"durationSec" validate:"gte=0"`
 	AvatarID             int64                       `json:"avatarID"`
 	AvatarLightColor     string                      `json:"avatarLightColor"`
 	BackgroundImageID    int64                       `json:"backgroundImageID"`
 	VoiceSynthesisConfig domain.VoiceSynthesisConfig `json:"voiceSynthesisConfig"`
-	Avatars              []domain.LessonAvatar       `json:"avatars"`
-	Drawings             []domain.LessonDrawing      `json:"drawings"`
-	Embeddings           []domain.LessonEmbedding    `json:"embeddings"`
-	Graphics             []domain.LessonGraphic      `json:"graphics"`
-	Musics               []domain.LessonMusic        `json:"musics"`
-	Speeches             []domain.LessonSpeech       `json:"speeches"`
+	Avatars              []domain.LessonAvatar       `json:"avatars" validate:"dive"`
+	Drawings             []domain.LessonDrawing      `json:"drawings" validate:"dive"`
+	Embeddings           []domain.LessonEmbedding    `json:"embeddings" validate:"dive"`
+	Graphics             []domain.LessonGraphic      `json:"graphics" validate:"dive"`
+	Musics               []domain.LessonMusic        `json:"musics" validate:"dive"`
+	Speeches             []domain.LessonSpeech       `json:"speeches" validate:"dive"`
 }
 
 type LessonMaterialErrorCode uint
@@ -42,17 +41,16 @@ func (e LessonMaterialErrorCode) Error() string {
 	}
 }
 
-func GetLessonMaterial(request *http.Request, id int64, lessonID int64) (domain.LessonMaterial, error) {
-	ctx := request.Context()
-
+// GetLessonMaterial returns the material to the owner, and to the others viewing the lesson with viewKey if it is limited.
+func GetLessonMaterial(ctx context.Context, currentUser domain.User, id int64, lessonID int64, viewKey string) (domain.LessonMaterial, error) {
 	var lessonMaterial domain.LessonMaterial
-	userID, err := currentUserAccessToLesson(ctx, request, lessonID)
+	lesson, err := viewerAccessToLesson(ctx, currentUser, lessonID, viewKey)
 	if err != nil {
 		return lessonMaterial, LessonMaterialNotAvailable
 	}
 
 	if err := domain.GetLessonMaterial(ctx, id, lessonID, &lessonMaterial); err != nil {
-		if err == datastore.ErrNoSuchEntity {
+		if err == domain.ErrNoSuchEntity {
 			return lessonMaterial, LessonMaterialNotFound
 		} else {
 			return lessonMaterial, LessonMaterialNotAvailable
@@ -63,7 +61,7 @@ func GetLessonMaterial(request *http.Request, id int64, lessonID int64) (domain.
 		avatar, err := domain.GetPublicAvatarByID(ctx, lessonMaterial.AvatarID)
 		if err != nil {
 			if ok := errors.Is(err, domain.AvatarNotFound); ok {
-				avatar, err = domain.GetCurrentUsersAvatarByID(ctx, lessonMaterial.AvatarID, userID)
+				avatar, err = domain.GetCurrentUsersAvatarByID(ctx, lessonMaterial.AvatarID, lesson.UserID)
 				if err != nil {
 					return lessonMaterial, err
 				}
@@ -78,17 +76,14 @@ func GetLessonMaterial(request *http.Request, id int64, lessonID int64) (domain.
 	return lessonMaterial, nil
 }
 
-func CreateLessonMaterial(request *http.Request, lessonID int64, params LessonMaterialParams) (int64, error) {
-	ctx := request.Context()
-
-	userID, err := currentUserAccessToLesson(ctx, request, lessonID)
-	if err != nil {
+func CreateLessonMaterial(ctx context.Context, currentUser domain.User, lessonID int64, params LessonMaterialParams) (int64, error) {
+	if err := currentUserAccessToLesson(ctx, currentUser, lessonID); err != nil {
 		return 0, LessonMaterialNotAvailable
 	}
 
 	var lessonMaterial domain.LessonMaterial
 	copier.Copy(&lessonMaterial, &params)
-	lessonMaterial.UserID = userID
+	lessonMaterial.UserID = currentUser.ID
 
 	if lessonMaterial.VoiceSynthesisConfig.LanguageCode == "" {
 		lessonMaterial.VoiceSynthesisConfig.LanguageCode = "ja-JP"
@@ -98,6 +93,10 @@ func CreateLessonMaterial(request *http.Request, lessonID int64, params LessonMa
 		lessonMaterial.VoiceSynthesisConfig.Name = "ja-JP-Wavenet-A"
 	}
 
+	if err := domain.CheckLessonMaterialLint(ctx, lessonID, lessonMaterial); err != nil {
+		return 0, err
+	}
+
 	if err := domain.CreateLessonMaterial(ctx, lessonID, &lessonMaterial); err != nil {
 		return 0, err
 	}
@@ -113,22 +112,91 @@ func CreateLessonMaterial(request *http.Request, lessonID int64, params LessonMa
 		return 0, err
 	}
 
+	if err = domain.IndexLesson(ctx, lesson); err != nil {
+		return 0, err
+	}
+
 	return lessonMaterial.ID, nil
 }
 
-func UpdateLessonMaterial(request *http.Request, id int64, lessonID int64, params LessonMaterialParams) error {
-	ctx := request.Context()
+// LintLessonMaterial returns the issues of the material to the owner.
+func LintLessonMaterial(ctx context.Context, currentUser domain.User, id int64, lessonID int64) (domain.LessonMaterialLintIssues, error) {
+	if err := currentUserAccessToLesson(ctx, currentUser, lessonID); err != nil {
+		return nil, LessonMaterialNotAvailable
+	}
 
-	if _, err := currentUserAccessToLesson(ctx, request, lessonID); err != nil {
-		return LessonMaterialNotAvailable
+	issues, err := domain.LintLessonMaterial(ctx, lessonID, id)
+	if err == domain.ErrNoSuchEntity {
+		return nil, LessonMaterialNotFound
 	}
 
-	var lessonMaterial domain.LessonMaterial
-	copier.Copy(&lessonMaterial, &params)
+	return issues, err
+}
+
+// UpdateLessonMaterial applies the JSON Merge Patch of LessonMaterialParams to the material of the revision, and returns the new revision.
+func UpdateLessonMaterial(ctx context.Context, currentUser domain.User, id int64, lessonID int64, revision int64, patch domain.MergePatch) (int64, error) {
+	if err := currentUserAccessToLesson(ctx, currentUser, lessonID); err != nil {
+		return 0, LessonMaterialNotAvailable
+	}
+
+	lessonMaterial, err := domain.UpdateLessonMaterial(ctx, id, lessonID, revision, patch.Only(LessonMaterialParams{}))
+	if err != nil {
+		if err == domain.ErrNoSuchEntity {
+			return 0, LessonMaterialNotFound
+		}
+		return 0, err
+	}
 
-	if err := domain.UpdateLessonMaterial(ctx, id, lessonID, &lessonMaterial); err != nil {
-		return err
+	if err := indexLesson(ctx, lessonID); err != nil {
+		return 0, err
 	}
 
-	return nil
+	return lessonMaterial.Revision, nil
+}
+
+// GetLessonMaterialVersions returns versions of the material in order of newest first.
+func GetLessonMaterialVersions(ctx context.Context, currentUser domain.User, id int64, lessonID int64) ([]domain.LessonMaterialVersion, error) {
+	if err := currentUserAccessToLesson(ctx, currentUser, lessonID); err != nil {
+		return nil, LessonMaterialNotAvailable
+	}
+
+	versions, err := domain.GetLessonMaterialVersions(ctx, lessonID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(versions) == 0 {
+		return nil, domain.LessonMaterialVersionNotFound
+	}
+
+	return versions, nil
+}
+
+func GetLessonMaterialVersion(ctx context.Context, currentUser domain.User, id int64, lessonID int64, versionID int64) (domain.LessonMaterial, error) {
+	if err := currentUserAccessToLesson(ctx, currentUser, lessonID); err != nil {
+		return domain.LessonMaterial{}, LessonMaterialNotAvailable
+	}
+
+	return domain.GetLessonMaterialVersion(ctx, lessonID, id, versionID)
+}
+
+// RestoreLessonMaterialVersion replaces the material with the version, the replaced material can be restored again.
+func RestoreLessonMaterialVersion(ctx context.Context, currentUser domain.User, id int64, lessonID int64, versionID int64) (domain.LessonMaterial, error) {
+	if err := currentUserAccessToLesson(ctx, currentUser, lessonID); err != nil {
+		return domain.LessonMaterial{}, LessonMaterialNotAvailable
+	}
+
+	lessonMaterial, err := domain.RestoreLessonMaterialVersion(ctx, lessonID, id, versionID)
+	if err != nil {
+		if err == domain.ErrNoSuchEntity {
+			return lessonMaterial, LessonMaterialNotFound
+		}
+		return lessonMaterial, err
+	}
+
+	if err := indexLesson(ctx, lessonID); err != nil {
+		return lessonMaterial, err
+	}
+
+	return lessonMaterial, nil
 }
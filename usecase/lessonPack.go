@@ -1,22 +1,19 @@
 package usecase
 
 import (
-	"net/http"
+	"context"
+	"io"
 
 	"github.com/super-dog-human/teraconnectgo/domain"
 )
 
 // PackLesson is packing the lesson to zip and upload GCS
-func PackLesson(request *http.Request, id int64) error {
-	ctx := request.Context()
-
-	currentUser, err := domain.GetCurrentUser(request)
-	if err != nil {
-		return err
-	}
-
+func PackLesson(ctx context.Context, currentUser domain.User, id int64) error {
 	lesson, err := domain.GetLessonByID(ctx, id)
 	if err != nil {
+		if err == domain.ErrNoSuchEntity {
+			return LessonNotFound
+		}
 		return err
 	}
 
@@ -24,36 +21,22 @@ func PackLesson(request *http.Request, id int64) error {
 		return LessonNotAvailable
 	}
 
-	/*
-
-		graphicFileTypes, err := domain.GetGraphicFileTypes(ctx, lesson.GraphicIDs)
-		if err != nil {
-			return err
-		}
-
-		voiceTexts, err := domain.GetRawVoiceTexts(ctx, id)
-		if err != nil {
-			return err
-		}
-
-		zip, err := domain.CreateLessonZip(ctx, lesson, graphicFileTypes, voiceTexts)
-		if err != nil {
-			return err
-		}
-
-		if err = domain.UploadLessonZipToGCS(ctx, id, zip); err != nil {
-			return err
-		}
-
-		lesson.IsPacked = true
-		if err = domain.UpdateLesson(ctx, &lesson); err != nil {
-			return err
+	var lessonMaterial domain.LessonMaterial
+	if err := domain.GetLessonMaterial(ctx, lesson.MaterialID, lesson.ID, &lessonMaterial); err != nil {
+		if err == domain.ErrNoSuchEntity {
+			return LessonMaterialNotFound
 		}
+		return err
+	}
 
-		if err = domain.CreateLessonIndex(ctx, currentUser, &lesson, &voiceTexts); err != nil {
-			return err
-		}
-	*/
+	if err := domain.PackLesson(ctx, &lesson, lessonMaterial); err != nil {
+		return err
+	}
 
 	return nil
 }
+
+// ImportLesson creates the new lesson of current user from the package zip of size bytes.
+func ImportLesson(ctx context.Context, currentUser domain.User, r io.ReaderAt, size int64) (domain.Lesson, error) {
+	return domain.ImportLesson(ctx, currentUser, r, size)
+}
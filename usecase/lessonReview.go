@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/super-dog-human/teraconnectgo/domain"
+)
+
+type NewLessonReviewParams struct {
+	UserID int64 `json:"userID" validate:"required"`
+}
+
+type LessonReviewParams struct {
+	Status  domain.LessonReviewStatus `json:"status"`
+	Comment string                    `json:"comment" validate:"max=1000"`
+}
+
+type NewLessonReviewCommentParams struct {
+	ElapsedTime float32 `json:"elapsedTime" validate:"gte=0"`
+	Body        string  `json:"body" validate:"required,max=1000"`
+}
+
+// RequestLessonReview requests the review of the lesson of current user to another user.
+func RequestLessonReview(ctx context.Context, currentUser domain.User, lessonID int64, params NewLessonReviewParams) ([]domain.LessonReview, error) {
+	if err := currentUserAccessToLesson(ctx, currentUser, lessonID); err == domain.ErrNoSuchEntity {
+		return nil, LessonNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	lesson, err := domain.RequestLessonReview(ctx, lessonID, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lesson.Reviews, nil
+}
+
+// CancelLessonReview removes the reviewer from the lesson of current user.
+func CancelLessonReview(ctx context.Context, currentUser domain.User, lessonID int64, reviewerUserID int64) ([]domain.LessonReview, error) {
+	if err := currentUserAccessToLesson(ctx, currentUser, lessonID); err == domain.ErrNoSuchEntity {
+		return nil, LessonNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	lesson, err := domain.CancelLessonReview(ctx, lessonID, reviewerUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lesson.Reviews, nil
+}
+
+// ReviewLesson approves or requests changes of the lesson by current user as the reviewer.
+func ReviewLesson(ctx context.Context, currentUser domain.User, lessonID int64, params LessonReviewParams) (domain.LessonReview, error) {
+	review, err := domain.DecideLessonReview(ctx, lessonID, currentUser.ID, params.Status, params.Comment)
+	if err == domain.ErrNoSuchEntity {
+		return review, LessonNotFound
+	}
+
+	return review, err
+}
+
+// GetLessonReviewComments returns the comments to the author and the reviewers.
+func GetLessonReviewComments(ctx context.Context, currentUser domain.User, lessonID int64) ([]domain.LessonReviewComment, error) {
+	if err := currentUserAccessToLessonReview(ctx, currentUser, lessonID); err != nil {
+		return nil, err
+	}
+
+	return domain.GetLessonReviewComments(ctx, lessonID)
+}
+
+// CreateLessonReviewComment adds the comment by the author or the reviewer at the position of the timeline.
+func CreateLessonReviewComment(ctx context.Context, currentUser domain.User, lessonID int64, params NewLessonReviewCommentParams) (domain.LessonReviewComment, error) {
+	if err := currentUserAccessToLessonReview(ctx, currentUser, lessonID); err != nil {
+		return domain.LessonReviewComment{}, err
+	}
+
+	comment := domain.LessonReviewComment{
+		UserID:      currentUser.ID,
+		ElapsedTime: params.ElapsedTime,
+		Body:        params.Body,
+	}
+	if err := domain.CreateLessonReviewComment(ctx, lessonID, &comment); err != nil {
+		return comment, err
+	}
+
+	return comment, nil
+}
+
+// DeleteLessonReviewComment deletes the comment written by current user.
+func DeleteLessonReviewComment(ctx context.Context, currentUser domain.User, lessonID int64, id int64) error {
+	if err := currentUserAccessToLessonReview(ctx, currentUser, lessonID); err != nil {
+		return err
+	}
+
+	return domain.DeleteLessonReviewComment(ctx, lessonID, id, currentUser.ID)
+}
+
+// GetReviewRequestedLessons returns the page of lessons whose review is requested to current user.
+func GetReviewRequestedLessons(ctx context.Context, currentUser domain.User, page domain.Page) ([]domain.Lesson, string, error) {
+	return domain.GetLessonsByReviewerUserID(ctx, currentUser.ID, page)
+}
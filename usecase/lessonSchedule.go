@@ -0,0 +1,13 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/super-dog-human/teraconnectgo/domain"
+)
+
+// ApplyLessonSchedules publishes and unpublishes the lessons whose schedules are due now.
+func ApplyLessonSchedules(ctx context.Context) (domain.LessonScheduleResult, error) {
+	return domain.ApplyLessonSchedules(ctx, time.Now())
+}
@@ -1,16 +1,17 @@
 package usecase
 
 import (
-	"net/http"
+	"context"
 
 	"github.com/super-dog-human/teraconnectgo/domain"
 )
 
 // CreateLessonThumbnailBlankFile is create blank image file to public or private bucket.
-func CreateLessonThumbnailBlankFile(request *http.Request, id int64) (string, error) {
-	ctx := request.Context()
+func CreateLessonThumbnailBlankFile(ctx context.Context, currentUser domain.User, id int64, isPublic bool) (string, error) {
+	if err := currentUserAccessToLesson(ctx, currentUser, id); err != nil {
+		return "", err
+	}
 
-	isPublic := request.URL.Query().Get("is_public") == "true"
 	url, err := domain.CreateLessonThumbnailBlankFile(ctx, id, isPublic)
 	if err != nil {
 		return "", err
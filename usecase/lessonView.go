@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/super-dog-human/teraconnectgo/domain"
+)
+
+// LessonViews is the total views of the lesson and the views of each day for the trend.
+type LessonViews struct {
+	Total int64                     `json:"total"`
+	Days  []domain.LessonDailyViews `json:"days"`
+}
+
+// RecordLessonView counts the view of the public lesson or the limited lesson with the valid viewKey,
+// the views from the same viewer in the window are counted only once.
+func RecordLessonView(ctx context.Context, id int64, viewKey string, viewerKey string) error {
+	lesson, err := domain.GetLessonByID(ctx, id)
+	if err == domain.ErrNoSuchEntity {
+		return LessonNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if !lesson.IsViewable(viewKey, time.Now()) {
+		return LessonNotAvailable
+	}
+
+	_, err = domain.RecordLessonView(ctx, id, viewerKey)
+	return err
+}
+
+// GetLessonViews returns the views of the lesson of current user in the last days.
+// the views recorded after the last aggregation are not included yet.
+func GetLessonViews(ctx context.Context, currentUser domain.User, id int64, days int) (LessonViews, error) {
+	var views LessonViews
+
+	lesson, err := domain.GetLessonByID(ctx, id)
+	if err == domain.ErrNoSuchEntity {
+		return views, LessonNotFound
+	} else if err != nil {
+		return views, err
+	}
+
+	if lesson.UserID != currentUser.ID {
+		return views, LessonNotAvailable
+	}
+
+	dailyViews, err := domain.GetLessonDailyViews(ctx, id, days, time.Now())
+	if err != nil {
+		return views, err
+	}
+
+	views.Total = lesson.ViewCount
+	views.Days = dailyViews
+
+	return views, nil
+}
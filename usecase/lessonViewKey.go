@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/super-dog-human/teraconnectgo/domain"
+)
+
+// LessonViewKeyParams is the expiry of the new link, the link never expires when it is omitted.
+type LessonViewKeyParams struct {
+	Expires time.Time `json:"expires"`
+}
+
+// GetLessonViewKey returns the key of the limited lesson to the owner for sharing the link.
+func GetLessonViewKey(ctx context.Context, currentUser domain.User, id int64) (domain.LessonViewKey, error) {
+	lesson, err := domain.GetLessonByID(ctx, id)
+	if err == domain.ErrNoSuchEntity {
+		return domain.LessonViewKey{}, LessonNotFound
+	} else if err != nil {
+		return domain.LessonViewKey{}, err
+	}
+
+	if lesson.UserID != currentUser.ID {
+		return domain.LessonViewKey{}, LessonNotAvailable
+	}
+
+	if lesson.Status != domain.LessonStatusLimited {
+		return domain.LessonViewKey{}, domain.LessonNotLimited
+	}
+
+	return domain.LessonViewKey{ViewKey: lesson.ViewKey, Expires: lesson.ViewKeyExpires}, nil
+}
+
+// RegenerateLessonViewKey replaces the key of the limited lesson, the links shared before are revoked.
+func RegenerateLessonViewKey(ctx context.Context, currentUser domain.User, id int64, params LessonViewKeyParams) (domain.LessonViewKey, error) {
+	if err := currentUserAccessToLesson(ctx, currentUser, id); err == domain.ErrNoSuchEntity {
+		return domain.LessonViewKey{}, LessonNotFound
+	} else if err != nil {
+		return domain.LessonViewKey{}, err
+	}
+
+	viewKey, err := domain.RegenerateLessonViewKey(ctx, id, params.Expires)
+	if err == domain.ErrNoSuchEntity {
+		return viewKey, LessonNotFound
+	}
+
+	return viewKey, err
+}
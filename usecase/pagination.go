@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/super-dog-human/teraconnectgo/domain"
+)
+
+// chainedCursor is the cursor over the lists fetched in sequence, e.g. user's avatars followed by public avatars.
+type chainedCursor struct {
+	ListIndex int    `json:"i"`
+	Cursor    string `json:"c"`
+}
+
+func decodeChainedCursor(str string) (chainedCursor, error) {
+	var cursor chainedCursor
+	if str == "" {
+		return cursor, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(str)
+	if err != nil {
+		return cursor, domain.InvalidCursor
+	}
+
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ListIndex < 0 {
+		return cursor, domain.InvalidCursor
+	}
+
+	return cursor, nil
+}
+
+func (c chainedCursor) String() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// pageFetcher fetches the page of a list, and returns the number of fetched entities and the cursor of next page.
+type pageFetcher func(page domain.Page) (int, string, error)
+
+// fetchChainedPages fetches the lists in order until the limit of page is filled, and returns the cursor of next page.
+func fetchChainedPages(page domain.Page, fetchers ...pageFetcher) (string, error) {
+	cursor, err := decodeChainedCursor(page.Cursor)
+	if err != nil {
+		return "", err
+	}
+
+	remaining := page.Limit
+	for i := cursor.ListIndex; i < len(fetchers); i++ {
+		var listCursor string
+		if i == cursor.ListIndex {
+			listCursor = cursor.Cursor
+		}
+
+		count, nextCursor, err := fetchers[i](domain.Page{Limit: remaining, Cursor: listCursor})
+		if err != nil {
+			return "", err
+		}
+
+		if nextCursor != "" {
+			return chainedCursor{ListIndex: i, Cursor: nextCursor}.String(), nil
+		}
+
+		remaining -= count
+		if remaining <= 0 && i+1 < len(fetchers) {
+			return chainedCursor{ListIndex: i + 1}.String(), nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/super-dog-human/teraconnectgo/domain"
+)
+
+type SeriesErrorCode uint
+
+const (
+	SeriesNotAvailable SeriesErrorCode = 1
+)
+
+func (e SeriesErrorCode) Error() string {
+	switch e {
+	case SeriesNotAvailable:
+		return "series not available"
+	default:
+		return "unknown series error"
+	}
+}
+
+type NewSeriesParams struct {
+	Title       string  `json:"title" validate:"required,max=100"`
+	Description string  `json:"description" validate:"max=1000"`
+	LessonIDs   []int64 `json:"lessonIDs" validate:"max=100"`
+}
+
+type PatchSeriesParams struct {
+	Title       string `json:"title" validate:"max=100"`
+	Description string `json:"description" validate:"max=1000"`
+}
+
+type SeriesLessonsParams struct {
+	LessonIDs []int64 `json:"lessonIDs" validate:"max=100"`
+}
+
+type NewSeriesLessonParams struct {
+	LessonID int64 `json:"lessonID" validate:"required"`
+	Position *int  `json:"position" validate:"omitempty,gte=0"` // appended when omitted.
+}
+
+// GetSeries returns the series with the lessons, the lessons not public are only shown to the owner.
+func GetSeries(ctx context.Context, currentUser domain.User, id int64) (domain.Series, error) {
+	series, err := domain.GetSeries(ctx, id)
+	if err != nil {
+		return series, err
+	}
+
+	lessons, err := domain.GetLessonsByIDs(ctx, series.LessonIDs)
+	if err != nil {
+		return series, err
+	}
+
+	isOwner := currentUser.ID != 0 && series.UserID == currentUser.ID
+	series.Lessons = []domain.Lesson{}
+	for _, lesson := range lessons {
+		if isOwner || lesson.Status == domain.LessonStatusPublic {
+			series.Lessons = append(series.Lessons, lesson)
+		}
+	}
+
+	if !isOwner {
+		series.LessonIDs = nil
+	}
+
+	return series, nil
+}
+
+func GetCurrentUserSeries(ctx context.Context, currentUser domain.User, page domain.Page) ([]domain.Series, string, error) {
+	return domain.GetSeriesByUserID(ctx, currentUser.ID, page)
+}
+
+// CreateSeries creates the series of current user with the lessons in order.
+func CreateSeries(ctx context.Context, currentUser domain.User, params NewSeriesParams) (domain.Series, error) {
+	series := domain.Series{
+		UserID:      currentUser.ID,
+		Title:       params.Title,
+		Description: params.Description,
+		LessonIDs:   params.LessonIDs,
+	}
+
+	if err := domain.CreateSeries(ctx, &series); err != nil {
+		return series, err
+	}
+
+	return series, nil
+}
+
+// UpdateSeries applies the JSON Merge Patch of PatchSeriesParams to the series.
+func UpdateSeries(ctx context.Context, currentUser domain.User, id int64, patch domain.MergePatch) (domain.Series, error) {
+	if err := currentUserAccessToSeries(ctx, currentUser, id); err != nil {
+		return domain.Series{}, err
+	}
+
+	return domain.UpdateSeries(ctx, id, patch.Only(PatchSeriesParams{}))
+}
+
+// ReorderSeriesLessons replaces the order of lessons in the series.
+func ReorderSeriesLessons(ctx context.Context, currentUser domain.User, id int64, params SeriesLessonsParams) (domain.Series, error) {
+	if err := currentUserAccessToSeries(ctx, currentUser, id); err != nil {
+		return domain.Series{}, err
+	}
+
+	return domain.ReorderSeriesLessons(ctx, id, params.LessonIDs)
+}
+
+// AddSeriesLesson adds the lesson of current user to the series.
+func AddSeriesLesson(ctx context.Context, currentUser domain.User, id int64, params NewSeriesLessonParams) (domain.Series, error) {
+	if err := currentUserAccessToSeries(ctx, currentUser, id); err != nil {
+		return domain.Series{}, err
+	}
+
+	position := -1
+	if params.Position != nil {
+		position = *params.Position
+	}
+
+	return domain.AddSeriesLesson(ctx, id, params.LessonID, position)
+}
+
+// RemoveSeriesLesson removes the lesson from the series, the lesson itself is not deleted.
+func RemoveSeriesLesson(ctx context.Context, currentUser domain.User, id int64, lessonID int64) (domain.Series, error) {
+	if err := currentUserAccessToSeries(ctx, currentUser, id); err != nil {
+		return domain.Series{}, err
+	}
+
+	return domain.RemoveSeriesLesson(ctx, id, lessonID)
+}
+
+// DeleteSeries deletes the series, the lessons in it are kept without the links.
+func DeleteSeries(ctx context.Context, currentUser domain.User, id int64) error {
+	if err := currentUserAccessToSeries(ctx, currentUser, id); err != nil {
+		return err
+	}
+
+	return domain.DeleteSeries(ctx, id)
+}
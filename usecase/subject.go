@@ -1,13 +1,12 @@
 package usecase
 
 import (
-	"net/http"
+	"context"
 
 	"github.com/super-dog-human/teraconnectgo/domain"
 )
 
 // GetSubjects for fetch avatar object from Cloud Datastore
-func GetSubjects(request *http.Request) ([]domain.Subject, error) {
-	ctx := request.Context()
+func GetSubjects(ctx context.Context) ([]domain.Subject, error) {
 	return domain.GetAllSubjects(ctx)
 }
@@ -1,28 +1,25 @@
 package usecase
 
 import (
-	"net/http"
+	"context"
 
 	"github.com/super-dog-human/teraconnectgo/domain"
 )
 
 // CreateSynthesisVoiceAndBlankFile creates Voice and blank files of mp3 and wav.
-func CreateSynthesisVoice(request *http.Request, params *domain.CreateSynthesisVoiceParam) (domain.Voice, error) {
-	ctx := request.Context()
-
+func CreateSynthesisVoice(ctx context.Context, currentUser domain.User, params *domain.CreateSynthesisVoiceParam) (domain.Voice, error) {
 	voice := domain.Voice{
 		IsSynthesis: true,
 	}
 
-	userID, err := currentUserAccessToLesson(ctx, request, params.LessonID)
-	if err != nil {
+	if err := currentUserAccessToLesson(ctx, currentUser, params.LessonID); err != nil {
 		return voice, err
 	}
 
-	voice.UserID = userID
+	voice.UserID = currentUser.ID
 
 	// ID採番のためだけにVoiceを作成する
-	if err = domain.CreateVoice(ctx, params.LessonID, &voice); err != nil {
+	if err := domain.CreateVoice(ctx, params.LessonID, &voice); err != nil {
 		return voice, err
 	}
 
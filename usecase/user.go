@@ -1,12 +1,9 @@
 package usecase
 
 import (
-	"net/http"
+	"context"
 
-	"cloud.google.com/go/datastore"
-	"github.com/imdario/mergo"
 	"github.com/super-dog-human/teraconnectgo/domain"
-	"github.com/super-dog-human/teraconnectgo/infrastructure"
 )
 
 type UserErrorCode uint
@@ -27,20 +24,8 @@ func (e UserErrorCode) Error() string {
 	}
 }
 
-// GetCurrentUser for fetch current user account
-func GetCurrentUser(request *http.Request) (domain.User, error) {
-	var currentUser domain.User
-	currentUser, err := domain.GetCurrentUser(request)
-	if err != nil {
-		return currentUser, err
-	}
-
-	return currentUser, nil
-}
-
 // GetUser for fetch user account by id.
-func GetUser(request *http.Request, id int64) (domain.User, error) {
-	ctx := request.Context()
+func GetUser(ctx context.Context, id int64) (domain.User, error) {
 	var user domain.User
 
 	user, err := domain.GetUserByID(ctx, id)
@@ -53,77 +38,43 @@ func GetUser(request *http.Request, id int64) (domain.User, error) {
 	return user, nil
 }
 
-// CreateUser creates new user with exclusion control.
-func CreateUser(request *http.Request, user *domain.User) error {
-	ctx := request.Context()
-
-	client, err := datastore.NewClient(ctx, infrastructure.ProjectID())
-	if err != nil {
-		return err
-	}
-
-	providerID, err := domain.ProviderID(request)
-	if err != nil {
-		return err
-	}
-
+// CreateUser creates new user of the account in token with exclusion control.
+func CreateUser(ctx context.Context, providerID string, user *domain.User) error {
 	user.ProviderID = providerID
 
-	var pendingKey *datastore.PendingKey
-	commit, err := client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
-		err = domain.ReserveUserProviderIDInTransaction(tx, providerID)
+	if err := domain.CreateUser(ctx, user); err != nil {
 		if err == domain.AlreadyProviderIDExists {
 			return AlreadyUserExists
 		}
-		if err != nil {
-			return err
-		}
-
-		if pendingKey, err = domain.CreateUserInTransaction(tx, user); err != nil {
-			return err
-		}
-
-		return nil
-	})
-
-	if err != nil {
 		return err
 	}
 
-	user.ID = commit.Key(pendingKey).ID
-
 	return nil
 }
 
-func UpdateUser(request *http.Request, user *domain.User) error {
-	ctx := request.Context()
-
-	currentUser, err := domain.GetCurrentUser(request)
-	if err != nil {
-		return UserNotAvailable
-	}
+// PatchUserParams is the members of JSON Merge Patch for updating user.
+type PatchUserParams struct {
+	Name                   string `json:"name"`
+	Email                  string `json:"email"`
+	RequiresReviewApproval bool   `json:"requiresReviewApproval"`
+}
 
-	if err := mergo.Merge(user, currentUser); err != nil {
-		return err
+// UpdateUser applies the JSON Merge Patch of PatchUserParams to current user.
+func UpdateUser(ctx context.Context, currentUser domain.User, patch domain.MergePatch) (domain.User, error) {
+	user := currentUser
+	if err := patch.Only(PatchUserParams{}).Apply(&user); err != nil {
+		return user, err
 	}
-	user.Created = currentUser.Created // Created field not merged because this time.Time fieled was initialized is not nil.
 
-	if err = domain.UpdateUser(ctx, user); err != nil {
-		return err
+	if err := domain.UpdateUser(ctx, &user); err != nil {
+		return user, err
 	}
 
-	return nil
+	return user, nil
 }
 
-func UnsubscribeCurrentUser(request *http.Request) error {
-	ctx := request.Context()
-
-	currentUser, err := domain.GetCurrentUser(request)
-	if err != nil {
-		return err
-	}
-
-	if err = domain.DeleteUser(ctx, currentUser.ID); err != nil {
+func UnsubscribeCurrentUser(ctx context.Context, currentUser domain.User) error {
+	if err := domain.DeleteUser(ctx, currentUser.ID); err != nil {
 		return err
 	}
 
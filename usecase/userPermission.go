@@ -2,25 +2,63 @@ package usecase
 
 import (
 	"context"
-	"net/http"
+	"time"
 
 	"github.com/super-dog-human/teraconnectgo/domain"
 )
 
-func currentUserAccessToLesson(ctx context.Context, request *http.Request, lessonID int64) (int64, error) {
-	currentUser, err := domain.GetCurrentUser(request)
+func currentUserAccessToLesson(ctx context.Context, currentUser domain.User, lessonID int64) error {
+	lesson, err := domain.GetLessonByID(ctx, lessonID)
 	if err != nil {
-		return currentUser.ID, err
+		return err
+	}
+
+	if lesson.UserID != currentUser.ID {
+		return LessonNotAvailable
 	}
 
+	return nil
+}
+
+// viewerAccessToLesson allows the owner and the reviewers, and the others viewing the public lesson or the limited lesson with the valid viewKey.
+func viewerAccessToLesson(ctx context.Context, currentUser domain.User, lessonID int64, viewKey string) (domain.Lesson, error) {
 	lesson, err := domain.GetLessonByID(ctx, lessonID)
 	if err != nil {
-		return currentUser.ID, err
+		return lesson, err
 	}
 
-	if lesson.UserID != currentUser.ID {
-		return currentUser.ID, LessonNotAvailable
+	if lesson.UserID != currentUser.ID && !lesson.IsReviewer(currentUser.ID) && !lesson.IsViewable(viewKey, time.Now()) {
+		return lesson, LessonNotAvailable
+	}
+
+	return lesson, nil
+}
+
+// currentUserAccessToLessonReview allows the owner and the reviewers of the lesson.
+func currentUserAccessToLessonReview(ctx context.Context, currentUser domain.User, lessonID int64) error {
+	lesson, err := domain.GetLessonByID(ctx, lessonID)
+	if err == domain.ErrNoSuchEntity {
+		return LessonNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if lesson.UserID != currentUser.ID && !lesson.IsReviewer(currentUser.ID) {
+		return LessonNotAvailable
+	}
+
+	return nil
+}
+
+func currentUserAccessToSeries(ctx context.Context, currentUser domain.User, id int64) error {
+	series, err := domain.GetSeries(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if series.UserID != currentUser.ID {
+		return SeriesNotAvailable
 	}
 
-	return currentUser.ID, nil
+	return nil
 }
@@ -1,7 +1,7 @@
 package usecase
 
 import (
-	"net/http"
+	"context"
 	"strconv"
 
 	"github.com/super-dog-human/teraconnectgo/domain"
@@ -9,18 +9,16 @@ import (
 )
 
 type CreateVoiceParam struct {
-	LessonID    int64   `json:"lessonID"`
-	ElapsedTime float32 `json:"elapsedTime"`
-	DurationSec float32 `json:"durationSec"`
+	LessonID    int64   `json:"lessonID" validate:"required"`
+	ElapsedTime float32 `json:"elapsedTime" validate:"gte=0"`
+	DurationSec float32 `json:"durationSec" validate:"gte=0"`
 }
 
-func GetVoice(request *http.Request, lessonID int64, id int64) (domain.Voice, error) {
-	ctx := request.Context()
-
+func GetVoice(ctx context.Context, currentUser domain.User, lessonID int64, id int64, viewKey string) (domain.Voice, error) {
 	var voice domain.Voice
 	voice.ID = id
 
-	if _, err := currentUserAccessToLesson(ctx, request, lessonID); err != nil {
+	if _, err := viewerAccessToLesson(ctx, currentUser, lessonID, viewKey); err != nil {
 		return voice, err
 	}
 
@@ -31,40 +29,36 @@ func GetVoice(request *http.Request, lessonID int64, id int64) (domain.Voice, er
 	return voice, nil
 }
 
-func GetVoices(request *http.Request, lessonID int64) ([]domain.Voice, error) {
-	ctx := request.Context()
-
+func GetVoices(ctx context.Context, currentUser domain.User, lessonID int64, viewKey string, page domain.Page) ([]domain.Voice, string, error) {
 	var voices []domain.Voice
 
-	if _, err := currentUserAccessToLesson(ctx, request, lessonID); err != nil {
-		return nil, err
+	if _, err := viewerAccessToLesson(ctx, currentUser, lessonID, viewKey); err != nil {
+		return nil, "", err
 	}
 
-	if err := domain.GetVoices(ctx, lessonID, &voices); err != nil {
-		return nil, err
+	nextCursor, err := domain.GetVoices(ctx, lessonID, page, &voices)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return voices, nil
+	return voices, nextCursor, nil
 }
 
 // CreateVoiceAndBlankFile creates Voice and blank files of mp3 and wav.
-func CreateVoiceAndBlankFile(request *http.Request, params *CreateVoiceParam) (infrastructure.SignedURL, error) {
-	ctx := request.Context()
-
+func CreateVoiceAndBlankFile(ctx context.Context, currentUser domain.User, params *CreateVoiceParam) (infrastructure.SignedURL, error) {
 	var response infrastructure.SignedURL
 
-	userID, err := currentUserAccessToLesson(ctx, request, params.LessonID)
-	if err != nil {
+	if err := currentUserAccessToLesson(ctx, currentUser, params.LessonID); err != nil {
 		return response, err
 	}
 
 	voice := domain.Voice{
-		UserID:      userID,
+		UserID:      currentUser.ID,
 		ElapsedTime: params.ElapsedTime,
 		DurationSec: params.DurationSec,
 	}
 
-	if err = domain.CreateVoice(ctx, params.LessonID, &voice); err != nil {
+	if err := domain.CreateVoice(ctx, params.LessonID, &voice); err != nil {
 		return response, err
 	}
 
@@ -79,7 +73,7 @@ func CreateVoiceAndBlankFile(request *http.Request, params *CreateVoiceParam) (i
 	}
 
 	filePath := lessonID + "/" + voiceID
-	mp3URL, err := infrastructure.CreateBlankFileToGCS(ctx, filePath, "voice", mp3FileRequest)
+	mp3URL, err := infrastructure.CreateBlankFile(ctx, filePath, "voice", mp3FileRequest)
 	if err != nil {
 		return response, err
 	}